@@ -0,0 +1,102 @@
+//go:build redis
+
+// Package redis provides a Redis-backed ExternalCache for PgGo tables.
+//
+// It lives behind the "redis" build tag so that the core package does not depend on
+// go-redis. Build with -tags redis and add github.com/redis/go-redis/v9 to your go.mod to use it.
+//
+// Example:
+//
+//	client := goredis.NewClient(&goredis.Options{Addr: "localhost:6379"})
+//	UsersTable.CacheKey = "id"
+//	UsersTable.CacheTTL = 10 * time.Minute
+//	UsersTable.EnableExternalCache(redis.New(client, "myapp:users:"))
+package redis
+
+import (
+	"context"
+	"time"
+
+	"pggo"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RedisCache implements pggo.ExternalCache on top of a go-redis client.
+// All keys are stored under Prefix, so one Redis database can be shared by several tables.
+type RedisCache struct {
+	// Client is the Redis client (a *goredis.Client, *goredis.ClusterClient, etc.).
+	Client goredis.UniversalClient
+	// Prefix is prepended to every key. Clear only removes keys with this prefix.
+	Prefix string
+	// Timeout bounds each Redis call. Defaults to 1 second when zero.
+	Timeout time.Duration
+}
+
+var _ pggo.ExternalCache = (*RedisCache)(nil)
+
+// New creates a RedisCache that stores keys under the given prefix (e.g. "myapp:users:").
+// Use a distinct prefix per table.
+func New(client goredis.UniversalClient, prefix string) *RedisCache {
+	return &RedisCache{Client: client, Prefix: prefix}
+}
+
+// context returns a context bounded by the configured Timeout.
+func (r *RedisCache) context() (context.Context, context.CancelFunc) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// Get implements pggo.ExternalCache. Redis errors are treated as cache misses.
+func (r *RedisCache) Get(key string) ([]byte, bool) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	data, err := r.Client.Get(ctx, r.Prefix+key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set implements pggo.ExternalCache. A zero ttl stores the key without expiration.
+func (r *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	r.Client.Set(ctx, r.Prefix+key, value, ttl)
+}
+
+// Delete implements pggo.ExternalCache.
+func (r *RedisCache) Delete(key string) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	r.Client.Del(ctx, r.Prefix+key)
+}
+
+// Clear implements pggo.ExternalCache by deleting every key under Prefix.
+// It does nothing if Prefix is empty, to avoid wiping unrelated keys.
+func (r *RedisCache) Clear() {
+	if r.Prefix == "" {
+		return
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+
+	iter := r.Client.Scan(ctx, 0, r.Prefix+"*", 500).Iterator()
+	var batch []string
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 500 {
+			r.Client.Del(ctx, batch...)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		r.Client.Del(ctx, batch...)
+	}
+}
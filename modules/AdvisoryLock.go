@@ -0,0 +1,220 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockRegistry tracks the connections pinned by session-level advisory locks.
+// Session locks belong to the connection that took them, so that connection is kept out of
+// the pool until the lock has been released.
+type advisoryLockRegistry struct {
+	mu    sync.Mutex
+	locks map[string]*pgxpool.Conn
+	// closed is set by Close, which released the pinned connections; locks taken meanwhile are given up.
+	closed bool
+}
+
+// advisoryLockRegistry returns the connection's advisory lock registry. ConnectDb creates it,
+// so copies of the connection share it; it is created here for pools assigned directly.
+func (conf *DatabaseConnection) advisoryLockRegistry() *advisoryLockRegistry {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	conf.initAdvisoryLocks()
+	return conf.advisoryLocks
+}
+
+// initAdvisoryLocks creates the advisory lock registry if there is none. poolMu must be held.
+func (conf *DatabaseConnection) initAdvisoryLocks() {
+	if conf.advisoryLocks == nil {
+		conf.advisoryLocks = &advisoryLockRegistry{locks: make(map[string]*pgxpool.Conn)}
+	}
+}
+
+// TryAdvisoryLock tries to take a session-level advisory lock (pg_try_advisory_lock) without waiting.
+// Returns true if the lock was acquired. The lock is held on a pinned connection until AdvisoryUnlock
+// (or Close). Locks are not reentrant: while the lock is held, TryAdvisoryLock returns false, also
+// to other goroutines of this process.
+//
+// Example (run a cron job on only one instance):
+//
+//	ok, err := db.TryAdvisoryLock(ctx, 42)
+//	if err != nil || !ok {
+//	    return err
+//	}
+//	defer db.AdvisoryUnlock(ctx, 42)
+func (conf *DatabaseConnection) TryAdvisoryLock(ctx context.Context, key int64) (bool, error) {
+	return conf.sessionLock(ctx, "pg_try_advisory_lock", true, fmt.Sprint(key), key)
+}
+
+// AdvisoryLock takes a session-level advisory lock (pg_advisory_lock), waiting until it is available
+// or ctx is cancelled. The lock is held on a pinned connection until AdvisoryUnlock (or Close).
+// Locks are not reentrant: while the lock is held, AdvisoryLock waits, also in the goroutine holding it.
+func (conf *DatabaseConnection) AdvisoryLock(ctx context.Context, key int64) error {
+	_, err := conf.sessionLock(ctx, "pg_advisory_lock", false, fmt.Sprint(key), key)
+	return err
+}
+
+// AdvisoryUnlock releases a session-level advisory lock taken with AdvisoryLock or TryAdvisoryLock (pg_advisory_unlock).
+func (conf *DatabaseConnection) AdvisoryUnlock(ctx context.Context, key int64) error {
+	return conf.sessionUnlock(ctx, fmt.Sprint(key), key)
+}
+
+// TryAdvisoryLockPair is like TryAdvisoryLock for a lock identified by two int32 keys (pg_try_advisory_lock(int4, int4)).
+func (conf *DatabaseConnection) TryAdvisoryLockPair(ctx context.Context, key1, key2 int32) (bool, error) {
+	return conf.sessionLock(ctx, "pg_try_advisory_lock", true, fmt.Sprintf("%d,%d", key1, key2), key1, key2)
+}
+
+// AdvisoryLockPair is like AdvisoryLock for a lock identified by two int32 keys (pg_advisory_lock(int4, int4)).
+func (conf *DatabaseConnection) AdvisoryLockPair(ctx context.Context, key1, key2 int32) error {
+	_, err := conf.sessionLock(ctx, "pg_advisory_lock", false, fmt.Sprintf("%d,%d", key1, key2), key1, key2)
+	return err
+}
+
+// AdvisoryUnlockPair releases a lock taken with AdvisoryLockPair or TryAdvisoryLockPair.
+func (conf *DatabaseConnection) AdvisoryUnlockPair(ctx context.Context, key1, key2 int32) error {
+	return conf.sessionUnlock(ctx, fmt.Sprintf("%d,%d", key1, key2), key1, key2)
+}
+
+// TryAdvisoryLockXact tries to take a transaction-level advisory lock (pg_try_advisory_xact_lock) without waiting.
+// The lock is released automatically when the transaction ends.
+func (conf *DatabaseConnection) TryAdvisoryLockXact(ctx context.Context, tx *Transaction, key int64) (bool, error) {
+	return xactLock(ctx, tx, "pg_try_advisory_xact_lock", true, key)
+}
+
+// AdvisoryLockXact takes a transaction-level advisory lock (pg_advisory_xact_lock), waiting until it is available.
+// The lock is released automatically when the transaction ends.
+func (conf *DatabaseConnection) AdvisoryLockXact(ctx context.Context, tx *Transaction, key int64) error {
+	_, err := xactLock(ctx, tx, "pg_advisory_xact_lock", false, key)
+	return err
+}
+
+// TryAdvisoryLockXactPair is like TryAdvisoryLockXact for a lock identified by two int32 keys.
+func (conf *DatabaseConnection) TryAdvisoryLockXactPair(ctx context.Context, tx *Transaction, key1, key2 int32) (bool, error) {
+	return xactLock(ctx, tx, "pg_try_advisory_xact_lock", true, key1, key2)
+}
+
+// AdvisoryLockXactPair is like AdvisoryLockXact for a lock identified by two int32 keys.
+func (conf *DatabaseConnection) AdvisoryLockXactPair(ctx context.Context, tx *Transaction, key1, key2 int32) error {
+	_, err := xactLock(ctx, tx, "pg_advisory_xact_lock", false, key1, key2)
+	return err
+}
+
+// lockCall renders a call to an advisory lock function with one placeholder per key.
+func lockCall(function string, keys []interface{}) string {
+	if len(keys) == 2 {
+		return fmt.Sprintf("SELECT %s($1::int4, $2::int4)", function)
+	}
+	return fmt.Sprintf("SELECT %s($1::int8)", function)
+}
+
+// sessionLock takes a session-level lock for lockID on a newly acquired connection, which is pinned
+// while the lock is held. The lock is never shared with the connection already holding it, so a lock
+// held by this process is not available to other callers until it is released.
+// For try functions it returns whether the lock was acquired; blocking functions always return true on success.
+func (conf *DatabaseConnection) sessionLock(ctx context.Context, function string, try bool, lockID string, keys ...interface{}) (bool, error) {
+	// Wait for the lock without holding the registry mutex, so other locks can be released meanwhile
+	conn, err := conf.GetConnection()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	acquired, err := runLockCall(ctx, conn, function, try, keys)
+	if err != nil || !acquired {
+		conn.Release()
+		if err != nil {
+			return false, fmt.Errorf("failed to take advisory lock %s: %w", lockID, err)
+		}
+		return false, nil
+	}
+
+	registry := conf.advisoryLockRegistry()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.closed {
+		// Close ran while the lock was taken: give it up rather than pin a connection Close waits for
+		unlockAll(conn)
+		conn.Release()
+		return false, fmt.Errorf("database connection is closed")
+	}
+	registry.locks[lockID] = conn
+	return true, nil
+}
+
+// runLockCall runs an advisory lock function on conn and reports whether the lock was acquired.
+func runLockCall(ctx context.Context, conn *pgxpool.Conn, function string, try bool, keys []interface{}) (bool, error) {
+	if !try {
+		_, err := conn.Exec(ctx, lockCall(function, keys), keys...)
+		return err == nil, err
+	}
+	var acquired bool
+	err := conn.QueryRow(ctx, lockCall(function, keys), keys...).Scan(&acquired)
+	return acquired, err
+}
+
+// sessionUnlock releases a session-level lock and returns its pinned connection to the pool.
+func (conf *DatabaseConnection) sessionUnlock(ctx context.Context, lockID string, keys ...interface{}) error {
+	registry := conf.advisoryLockRegistry()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	conn, held := registry.locks[lockID]
+	if !held {
+		return fmt.Errorf("advisory lock %s is not held", lockID)
+	}
+
+	var released bool
+	if err := conn.QueryRow(ctx, lockCall("pg_advisory_unlock", keys), keys...).Scan(&released); err != nil {
+		return fmt.Errorf("failed to release advisory lock %s: %w", lockID, err)
+	}
+
+	delete(registry.locks, lockID)
+	conn.Release()
+	if !released {
+		return fmt.Errorf("advisory lock %s was not held by this session", lockID)
+	}
+	return nil
+}
+
+// releaseAdvisoryLocks unlocks the session-level advisory locks and returns their pinned connections
+// to the pool, so that Close does not wait for them. Locks taken afterwards are given up.
+func (conf *DatabaseConnection) releaseAdvisoryLocks() {
+	registry := conf.advisoryLockRegistry()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.closed = true
+	for lockID, conn := range registry.locks {
+		unlockAll(conn)
+		conn.Release()
+		delete(registry.locks, lockID)
+	}
+}
+
+// unlockAll releases every session-level advisory lock held by conn (pg_advisory_unlock_all).
+// It is bounded by a short timeout: if it fails, the locks go away with the connection when the pool closes it.
+func unlockAll(conn *pgxpool.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, _ = conn.Exec(ctx, "SELECT pg_advisory_unlock_all()")
+}
+
+// xactLock takes a transaction-level lock inside tx.
+func xactLock(ctx context.Context, tx *Transaction, function string, try bool, keys ...interface{}) (bool, error) {
+	if tx == nil {
+		return false, fmt.Errorf("transaction-level advisory locks require a transaction")
+	}
+	acquired := true
+	var err error
+	if try {
+		err = tx.Tx.QueryRow(ctx, lockCall(function, keys), keys...).Scan(&acquired)
+	} else {
+		_, err = tx.Tx.Exec(ctx, lockCall(function, keys), keys...)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to take transaction advisory lock: %w", err)
+	}
+	return acquired, nil
+}
@@ -0,0 +1,58 @@
+package modules
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTryAdvisoryLockIsExclusiveWithinProcess(t *testing.T) {
+	conn := newTestConnection(t)
+	ctx := context.Background()
+	const key = 7351
+
+	ok, err := conn.TryAdvisoryLock(ctx, key)
+	if err != nil || !ok {
+		t.Fatalf("TryAdvisoryLock = %v, %v; want true", ok, err)
+	}
+
+	// Another goroutine of the same process must not get the held lock
+	var wg sync.WaitGroup
+	var other bool
+	var otherErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other, otherErr = conn.TryAdvisoryLock(ctx, key)
+	}()
+	wg.Wait()
+	if otherErr != nil || other {
+		t.Fatalf("TryAdvisoryLock while held = %v, %v; want false", other, otherErr)
+	}
+
+	if err := conn.AdvisoryUnlock(ctx, key); err != nil {
+		t.Fatalf("AdvisoryUnlock: %v", err)
+	}
+	ok, err = conn.TryAdvisoryLock(ctx, key)
+	if err != nil || !ok {
+		t.Fatalf("TryAdvisoryLock after unlock = %v, %v; want true", ok, err)
+	}
+	if err := conn.AdvisoryUnlock(ctx, key); err != nil {
+		t.Fatalf("AdvisoryUnlock: %v", err)
+	}
+}
+
+func TestCloseReleasesAdvisoryLocks(t *testing.T) {
+	conn := newTestConnection(t)
+	if err := conn.AdvisoryLock(context.Background(), 7352); err != nil {
+		t.Fatalf("AdvisoryLock: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := conn.Close(ctx); err != nil {
+		t.Fatalf("Close with a held advisory lock: %v (after %v)", err, time.Since(start))
+	}
+}
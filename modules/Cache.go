@@ -1,14 +1,22 @@
 package modules
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrNoCacheKey is returned when a cache operation needs the table's CacheKey (or CacheKeys) but none is defined.
+var ErrNoCacheKey = errors.New("CacheKey is not defined for this table")
+
 // EnableCache initializes the in-memory cache for the table.
 // It sets the TTL (Time-To-Live) for cached items and initializes the cache storage.
+// Rows are cached as copies of the fetched maps, not encoded, so they keep pgx's types.
 // If CacheMax is not set, it defaults to 1000 items.
 // Note: CacheKey must be defined in the Table struct before calling this method.
 func (t *Table) EnableCache(ttl time.Duration) {
@@ -19,121 +27,335 @@ func (t *Table) EnableCache(ttl time.Duration) {
 	}
 	// t.CacheKey should be set in the Table struct initialization
 	t.CacheData = NewMemoryCache(t.CacheMax)
+	t.CacheBackend = nil
+	t.cacheTasks = &cacheTaskGroup{}
+}
+
+// ExternalCache is a cache backend that can replace the built-in MemoryCache,
+// e.g. Redis or Memcached for deployments with several application instances.
+// Values are the rows encoded as JSON, with each column tagged with its Go type so cached rows
+// have the same types as fetched ones. Implementations must be safe for concurrent use.
+//
+// A Redis implementation is available in the pggo/cache/redis package (build tag "redis").
+type ExternalCache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(key string)
+	Clear()
+}
+
+// EnableExternalCache enables caching for the table using the given backend instead of a MemoryCache.
+// Items are stored with the table's CacheTTL. Since a shared backend may serve several tables,
+// the backend should namespace its keys per table (e.g. a key prefix).
+// Note: CacheKey must be defined in the Table struct before calling this method.
+//
+// Example:
+//
+//	UsersTable.CacheTTL = 10 * time.Minute
+//	UsersTable.EnableExternalCache(redis.New(client, "myapp:users:"))
+func (t *Table) EnableExternalCache(backend ExternalCache) {
+	t.Cached = true
+	t.CacheBackend = backend
+	t.cacheTasks = &cacheTaskGroup{}
+}
+
+// cacheStore returns the cache backend in use: the external backend if configured,
+// otherwise the in-memory cache. Returns nil if caching is not enabled or initialized.
+func (t *Table) cacheStore() ExternalCache {
+	if !t.Cached {
+		return nil
+	}
+	if t.CacheBackend != nil {
+		return t.CacheBackend
+	}
+	if t.CacheData != nil {
+		return t.CacheData
+	}
+	return nil
+}
+
+// cacheKeySeparator joins the values of a multi-column cache key. It is a control character
+// that is unlikely to appear in key values, so different keys do not collide.
+const cacheKeySeparator = "\x1f"
+
+// cacheKeyColumns returns the columns that make up the cache key: CacheKeys if set, otherwise CacheKey.
+func (t *Table) cacheKeyColumns() []string {
+	if len(t.CacheKeys) > 0 {
+		return t.CacheKeys
+	}
+	if t.CacheKey != "" {
+		return []string{t.CacheKey}
+	}
+	return nil
+}
+
+// joinCacheKey builds a cache key from the key column values, in CacheKeys order.
+func joinCacheKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, val := range values {
+		parts[i] = cacheKeyPart(val)
+	}
+	return strings.Join(parts, cacheKeySeparator)
 }
 
-// getCacheKey retrieves the value of the configured CacheKey from the query arguments.
-// It searches for the CacheKey in map arguments or key-value pairs.
+// cacheKeyPart formats a cache key value. Numbers are formatted by value, so the same id gives the
+// same key whether it is an int32/int64 read from the database or a float64 from JSON
+// (fmt's %v would format float64(1234567) as "1.234567e+06").
+func cacheKeyPart(val interface{}) string {
+	switch v := val.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
+	case float32:
+		return formatCacheKeyFloat(float64(v))
+	case float64:
+		return formatCacheKeyFloat(v)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+		if f, err := v.Float64(); err == nil {
+			return formatCacheKeyFloat(f)
+		}
+		return v.String()
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// formatCacheKeyFloat formats integral floats like integers and others without an exponent.
+func formatCacheKeyFloat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < math.MaxInt64 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// getCacheKey retrieves the value of the configured cache key column(s) from the query arguments
+// or a result row. It searches for the key columns in map arguments or key-value pairs.
+// With several key columns (CacheKeys), their values are joined; all of them must be present.
 //
 // Example: If CacheKey = "id"
 //   - getCacheKey(map[string]interface{}{"id": 5}) -> "5", nil
 //   - getCacheKey("id", 5) -> "5", nil
 //
-// Returns an error if caching is disabled, CacheKey is undefined, or the key is not found.
+// Returns an error if caching is disabled, no cache key is defined, or a key column is not found.
 func (t *Table) getCacheKey(whereArgs ...interface{}) (string, error) {
 	if !t.Cached {
 		return "", fmt.Errorf("caching is not enabled for this table")
 	}
-	if t.CacheKey == "" {
-		return "", fmt.Errorf("CacheKey is not defined for this table")
+	keyColumns := t.cacheKeyColumns()
+	if len(keyColumns) == 0 {
+		return "", ErrNoCacheKey
 	}
 
+	values := make([]interface{}, len(keyColumns))
+	for i, keyColumn := range keyColumns {
+		val, found := findCacheKeyValue(keyColumn, whereArgs)
+		if !found {
+			t.debugf("Cache key column '%s' not found in whereArgs: %v", keyColumn, whereArgs)
+			return "", fmt.Errorf("cache key column '%s' not found in whereArgs", keyColumn)
+		}
+		values[i] = val
+	}
+	return joinCacheKey(values), nil
+}
+
+// findCacheKeyValue looks up a key column's value in map arguments or key-value pairs.
+func findCacheKeyValue(keyColumn string, whereArgs []interface{}) (interface{}, bool) {
 	// 1. Check inside maps (Standard PgGo usage)
 	for _, arg := range whereArgs {
 		if m, ok := arg.(map[string]interface{}); ok {
-			if val, found := m[t.CacheKey]; found {
-				return fmt.Sprintf("%v", val), nil
+			if val, found := m[keyColumn]; found {
+				return val, true
 			}
 		}
 	}
 
 	// 2. Check for key-value pairs (User's requested pattern)
 	for i := 0; i < len(whereArgs)-1; i += 2 {
-		if key, ok := whereArgs[i].(string); ok && key == t.CacheKey {
-			return fmt.Sprintf("%v", whereArgs[i+1]), nil
+		if key, ok := whereArgs[i].(string); ok && key == keyColumn {
+			return whereArgs[i+1], true
 		}
 	}
+	return nil, false
+}
 
-	if t.DebugMode {
-		log.Printf("DEBUG: CacheKey '%s' not found in whereArgs: %v\n", t.CacheKey, whereArgs)
+// cacheLookupKey returns the cache key for a read whose only conditions are equality on the cache key
+// column(s), e.g. map[string]interface{}{"id": 5}. Reads with any other or additional condition,
+// including a Scope, cannot be answered from the cache, since a cached row is only known to match its own key.
+func (t *Table) cacheLookupKey(whereArgs []interface{}) (string, bool) {
+	keyColumns := t.cacheKeyColumns()
+	if len(whereArgs) != 1 || len(keyColumns) == 0 || t.isScoped() {
+		return "", false
+	}
+	m, ok := whereArgs[0].(map[string]interface{})
+	if !ok || len(m) != len(keyColumns) {
+		return "", false
+	}
+	values := make([]interface{}, len(keyColumns))
+	for i, keyColumn := range keyColumns {
+		val, found := m[keyColumn]
+		if !found || val == nil {
+			return "", false
+		}
+		if _, isCondition := val.(Condition); isCondition {
+			return "", false
+		}
+		values[i] = val
 	}
-	return "", fmt.Errorf("CacheKey '%s' not found in whereArgs", t.CacheKey)
+	return joinCacheKey(values), true
 }
 
-// setCache sets the cache for the given key and value.
-func (t *Table) setCache(key string, value interface{}) error {
-	if !t.Cached || t.CacheData == nil {
+// setCache stores a row in the cache under the given key.
+// A MemoryCache holds a copy of the row itself; other backends get it encoded with its column types
+// (see encodeCachedRow). Either way it reads back identical to a fetched row.
+func (t *Table) setCache(key string, row map[string]interface{}) error {
+	store := t.cacheStore()
+	if store == nil {
 		return nil // Cache not enabled, ignore
 	}
 
-	data, err := json.Marshal(value)
+	if values, ok := store.(valueCache); ok {
+		values.SetValue(key, copyCachedValue(row), t.CacheTTL)
+		t.debugf("Cache Set Key: %s", key)
+		return nil
+	}
+
+	data, err := encodeCachedRow(row)
 	if err != nil {
-		if t.DebugMode {
-			log.Println("DEBUG: Failed to marshal cache data:", err)
-		}
+		t.debugf("Failed to marshal cache data: %v", err)
 		return fmt.Errorf("failed to marshal cache data: %w", err)
 	}
 
-	t.CacheData.Set(key, data, t.CacheTTL)
-	if t.DebugMode {
-		log.Printf("DEBUG: Cache Set Key: %s\n", key)
-	}
+	store.Set(key, data, t.CacheTTL)
+	t.debugf("Cache Set Key: %s", key)
 	return nil
 }
 
-// getCacheValue retrieves a value from the cache and unmarshals it into the target.
+// getCacheValue retrieves a row from the cache and decodes it into the target.
 // Returns true if found, false otherwise.
-// returns error if unmarshaling fails.
+// returns error if decoding fails.
 // example usage:
 //
 //	var user map[string]interface{}
 //	found, err := UsersTable.getCacheValue("5", &user)
 //	if
-func (t *Table) getCacheValue(key string, target interface{}) (bool, error) {
-	if !t.Cached || t.CacheData == nil {
+func (t *Table) getCacheValue(key string, target *map[string]interface{}) (bool, error) {
+	store := t.cacheStore()
+	if store == nil {
 		return false, nil
 	}
 
-	data, found := t.CacheData.Get(key)
-	if !found {
-		if t.DebugMode {
-			log.Printf("DEBUG: Cache Miss Key: %s\n", key)
+	var data []byte
+	found := false
+	if values, ok := store.(valueCache); ok {
+		var value interface{}
+		if value, found = values.GetValue(key); found {
+			if row, isRow := value.(map[string]interface{}); isRow {
+				*target = copyCachedValue(row).(map[string]interface{})
+				t.debugf("Cache Hit Key: %s", key)
+				return true, nil
+			}
+			data, found = value.([]byte)
 		}
+	} else {
+		data, found = store.Get(key)
+	}
+	if !found {
+		t.debugf("Cache Miss Key: %s", key)
 		return false, nil
 	}
 
-	err := json.Unmarshal(data, target) // unmarshal into provided target
+	row, err := decodeCachedRow(data)
 	if err != nil {
-		if t.DebugMode {
-			log.Println("DEBUG: Failed to unmarshal cache data:", err)
-		}
+		t.debugf("Failed to unmarshal cache data: %v", err)
 		return false, fmt.Errorf("failed to unmarshal cache data: %w", err)
 	}
+	*target = row
 
-	if t.DebugMode {
-		log.Printf("DEBUG: Cache Hit Key: %s\n", key)
-	}
+	t.debugf("Cache Hit Key: %s", key)
 	return true, nil
 }
 
 func (t *Table) deleteCache(key string) error {
-	if !t.Cached || t.CacheData == nil {
+	store := t.cacheStore()
+	if store == nil {
 		return nil // Cache not enabled, ignore
 	}
 
-	if t.DebugMode {
-		log.Printf("DEBUG: Deleting Cache Key: %s\n", key)
-	}
-	t.CacheData.Delete(key)
+	t.debugf("Deleting Cache Key: %s", key)
+	store.Delete(key)
 	return nil
 }
 
 func (t *Table) invalidateCache() error {
-	if !t.Cached || t.CacheData == nil {
+	store := t.cacheStore()
+	if store == nil {
 		return nil // Cache not enabled, ignore
 	}
-	if t.DebugMode {
-		log.Println("DEBUG: Invalidating (Clearing) Cache")
+	t.debugf("Invalidating (Clearing) Cache")
+	store.Clear()
+	return nil
+}
+
+// WarmCache preloads rows into the cache, so the first requests after startup
+// do not all hit the database.
+//
+// It selects the rows matching whereArgs (same format as FetchMany), or WarmCacheWhere if no
+// whereArgs are given, up to CacheMax rows, and caches each one under its cache key value.
+// It is safe to call while the table is in use.
+//
+// Returns ErrNoCacheKey if neither CacheKey nor CacheKeys is set, or an error if caching is not enabled or the query fails.
+//
+// Example:
+//
+//	UsersTable.EnableCache(10 * time.Minute)
+//	if err := UsersTable.WarmCache(ctx, map[string]interface{}{"active": true}); err != nil {
+//	    log.Println("Error warming cache:", err)
+//	}
+func (t *Table) WarmCache(ctx context.Context, whereArgs ...interface{}) error {
+	if len(whereArgs) == 0 {
+		whereArgs = t.WarmCacheWhere
+	}
+	return t.warmCache(ctx, whereArgs)
+}
+
+// WarmCacheAll preloads the whole table into the cache, up to CacheMax rows, ignoring WarmCacheWhere.
+func (t *Table) WarmCacheAll(ctx context.Context) error {
+	return t.warmCache(ctx, nil)
+}
+
+// warmCache selects the rows matching whereArgs, up to CacheMax, and caches them.
+func (t *Table) warmCache(ctx context.Context, whereArgs []interface{}) error {
+	if t.cacheStore() == nil {
+		return fmt.Errorf("caching is not enabled for this table")
+	}
+	if len(t.cacheKeyColumns()) == 0 {
+		return ErrNoCacheKey
 	}
-	t.CacheData.Clear()
+
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return fmt.Errorf("failed to build where clause: %w", err)
+	}
+	query := fmt.Sprintf("SELECT * FROM %s%s", QuoteIdentifier(t.Name), whereClause)
+	if t.CacheMax > 0 {
+		// Rows beyond CacheMax would only evict earlier ones
+		query += fmt.Sprintf(" LIMIT %d", t.CacheMax)
+	}
+
+	t.debugf("Warming cache for table %s", t.Name)
+	rows, err := t.queryRows(ctx, "WarmCache", query, params)
+	if err != nil {
+		return fmt.Errorf("failed to warm cache: %w", err)
+	}
+
+	cached := 0
+	t.forEachCacheKey(rows, nil, func(key string, row map[string]interface{}) {
+		if err := t.setCache(key, row); err == nil {
+			cached++
+		}
+	})
+	t.debugf("Warmed cache for table %s with %d of %d rows", t.Name, cached, len(rows))
 	return nil
 }
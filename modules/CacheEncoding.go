@@ -0,0 +1,232 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// valueCache is implemented by caches that hold Go values, such as MemoryCache.
+// Rows are stored in them as copies, without encoding.
+type valueCache interface {
+	SetValue(key string, value interface{}, ttl time.Duration)
+	GetValue(key string) (interface{}, bool)
+}
+
+// copyCachedValue returns a deep copy of a row or column value, so that neither the caller nor the cache
+// sees the other's later changes. Maps, slices and []byte are copied; other values are copied by value.
+func copyCachedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			copied[key] = copyCachedValue(val)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, val := range v {
+			copied[i] = copyCachedValue(val)
+		}
+		return copied
+	case []byte:
+		return append([]byte(nil), v...)
+	}
+	return value
+}
+
+// cacheFormatVersion identifies the encoding of cached rows. Entries without it were written
+// as plain JSON by earlier versions and are decoded with decodeCachedJSON.
+const cacheFormatVersion = 1
+
+// cachedRow is the encoding of a row in the cache: each column value tagged with its Go type.
+type cachedRow struct {
+	Version int                    `json:"pggo"`
+	Columns map[string]cachedValue `json:"row"`
+}
+
+// cachedValue is a column value in the cache. Type names the Go type the value is decoded back to:
+// one of cacheValueTypes, "float32"/"float64" (stored as strings, so NaN and Inf survive),
+// "array" for a []interface{} of cachedValues, or "" for NULL and plain JSON values.
+type cachedValue struct {
+	Type  string          `json:"t,omitempty"`
+	Value json.RawMessage `json:"v"`
+}
+
+// cacheValueTypes are the types, by tag, that pgx returns for the common column types
+// and that round-trip through encoding/json.
+var cacheValueTypes = map[string]reflect.Type{
+	"bool":     reflect.TypeOf(false),
+	"int":      reflect.TypeOf(int(0)),
+	"int16":    reflect.TypeOf(int16(0)),
+	"int32":    reflect.TypeOf(int32(0)),
+	"int64":    reflect.TypeOf(int64(0)),
+	"uint32":   reflect.TypeOf(uint32(0)),
+	"string":   reflect.TypeOf(""),
+	"bytes":    reflect.TypeOf([]byte(nil)),
+	"time":     reflect.TypeOf(time.Time{}),
+	"uuid":     reflect.TypeOf([16]byte{}),
+	"numeric":  reflect.TypeOf(pgtype.Numeric{}),
+	"interval": reflect.TypeOf(pgtype.Interval{}),
+	"inet":     reflect.TypeOf(netip.Prefix{}),
+}
+
+// cacheValueTags maps the types of cacheValueTypes back to their tag.
+var cacheValueTags = func() map[reflect.Type]string {
+	tags := make(map[reflect.Type]string, len(cacheValueTypes))
+	for tag, typ := range cacheValueTypes {
+		tags[typ] = tag
+	}
+	return tags
+}()
+
+// encodeCachedRow encodes a row for the cache, keeping the type of every column value.
+func encodeCachedRow(row map[string]interface{}) ([]byte, error) {
+	columns := make(map[string]cachedValue, len(row))
+	for name, value := range row {
+		encoded, err := encodeCachedValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column '%s': %w", name, err)
+		}
+		columns[name] = encoded
+	}
+	return json.Marshal(cachedRow{Version: cacheFormatVersion, Columns: columns})
+}
+
+// decodeCachedRow decodes a row encoded by encodeCachedRow, or written as plain JSON by earlier versions.
+func decodeCachedRow(data []byte) (map[string]interface{}, error) {
+	var encoded cachedRow
+	if err := json.Unmarshal(data, &encoded); err != nil || encoded.Version != cacheFormatVersion {
+		return decodeCachedJSON(data)
+	}
+
+	row := make(map[string]interface{}, len(encoded.Columns))
+	for name, value := range encoded.Columns {
+		decoded, err := decodeCachedValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode column '%s': %w", name, err)
+		}
+		row[name] = decoded
+	}
+	return row, nil
+}
+
+// encodeCachedValue tags a value with its type. Values of other types are stored as plain JSON.
+func encodeCachedValue(value interface{}) (cachedValue, error) {
+	var raw []byte
+	var err error
+	tag := ""
+	switch v := value.(type) {
+	case nil:
+		raw = []byte("null")
+	case float32:
+		tag = "float32"
+		raw, err = json.Marshal(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case float64:
+		tag = "float64"
+		raw, err = json.Marshal(strconv.FormatFloat(v, 'g', -1, 64))
+	case []interface{}:
+		tag = "array"
+		elements := make([]cachedValue, len(v))
+		for i, element := range v {
+			if elements[i], err = encodeCachedValue(element); err != nil {
+				return cachedValue{}, err
+			}
+		}
+		raw, err = json.Marshal(elements)
+	default:
+		tag = cacheValueTags[reflect.TypeOf(value)]
+		raw, err = json.Marshal(value)
+	}
+	if err != nil {
+		return cachedValue{}, err
+	}
+	return cachedValue{Type: tag, Value: raw}, nil
+}
+
+// decodeCachedValue decodes a value encoded by encodeCachedValue into its original type.
+func decodeCachedValue(encoded cachedValue) (interface{}, error) {
+	switch encoded.Type {
+	case "":
+		var value interface{}
+		err := json.Unmarshal(encoded.Value, &value)
+		return value, err
+	case "float32", "float64":
+		var text string
+		if err := json.Unmarshal(encoded.Value, &text); err != nil {
+			return nil, err
+		}
+		if encoded.Type == "float32" {
+			f, err := strconv.ParseFloat(text, 32)
+			return float32(f), err
+		}
+		return strconv.ParseFloat(text, 64)
+	case "array":
+		var elements []cachedValue
+		if err := json.Unmarshal(encoded.Value, &elements); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(elements))
+		for i, element := range elements {
+			value, err := decodeCachedValue(element)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+
+	typ, found := cacheValueTypes[encoded.Type]
+	if !found {
+		return nil, fmt.Errorf("unknown cached value type '%s'", encoded.Type)
+	}
+	target := reflect.New(typ)
+	if err := json.Unmarshal(encoded.Value, target.Interface()); err != nil {
+		return nil, err
+	}
+	return target.Elem().Interface(), nil
+}
+
+// decodeCachedJSON decodes a row cached as plain JSON. Integral numbers are decoded as int64
+// rather than float64, so ids read from the cache keep their value when used again.
+func decodeCachedJSON(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var row map[string]interface{}
+	if err := decoder.Decode(&row); err != nil {
+		return nil, err
+	}
+	normalizeJSONNumbers(row)
+	return row, nil
+}
+
+// normalizeJSONNumbers replaces the json.Number values in a decoded JSON value, in place for maps
+// and slices, with int64 if they are integers and float64 otherwise.
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = normalizeJSONNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeJSONNumbers(val)
+		}
+	}
+	return value
+}
@@ -0,0 +1,176 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CaseExpr is a searched CASE expression (CASE WHEN condition THEN result ... ELSE result END)
+// for a QueryBuilder SELECT list, or a WHERE clause through Condition.
+type CaseExpr struct {
+	// Operand is the expression compared by a simple CASE (CaseValue); empty for a searched CASE.
+	Operand string
+	// Whens are the WHEN branches, in order.
+	Whens []CaseWhen
+	// ElseResult is the ELSE result; nil means no ELSE (NULL).
+	ElseResult interface{}
+	Alias      string
+}
+
+// CaseWhen is a WHEN branch of a CaseExpr. Condition is a raw SQL condition in a searched CASE
+// and a value compared to the operand (passed as a parameter) in a simple CASE.
+type CaseWhen struct {
+	Condition interface{}
+	Result    interface{}
+}
+
+// CaseValueExpr is a simple CASE expression (CASE expr WHEN match THEN result ... END), created by CaseValue.
+type CaseValueExpr struct {
+	CaseExpr
+}
+
+// Case starts a searched CASE expression. Conditions are raw SQL; results are passed as parameters.
+// Usage:
+//
+//	// (CASE WHEN "age" < 18 THEN $1 WHEN "age" < 65 THEN $2 ELSE $3 END) AS "category"
+//	UsersTable.Query().Select("id", Case().When(`"age" < 18`, "minor").When(`"age" < 65`, "adult").Else("senior").As("category"))
+func Case() *CaseExpr {
+	return &CaseExpr{}
+}
+
+// CaseValue starts a simple CASE expression comparing expr, inserted verbatim, to each WHEN value.
+// Match values and results are passed as parameters.
+// Usage:
+//
+//	// (CASE "priority" WHEN $1 THEN $2 WHEN $3 THEN $4 ELSE $5 END) AS "priority_rank"
+//	CaseValue(`"priority"`).When("high", 1).When("normal", 2).Else(3).As("priority_rank")
+func CaseValue(expr string) *CaseValueExpr {
+	return &CaseValueExpr{CaseExpr{Operand: expr}}
+}
+
+// When adds a WHEN condition THEN result branch. The condition is raw SQL without parameters
+// and must not contain untrusted input.
+func (c *CaseExpr) When(condition string, result interface{}) *CaseExpr {
+	c.Whens = append(c.Whens, CaseWhen{Condition: condition, Result: result})
+	return c
+}
+
+// Else sets the result of rows that match no WHEN branch.
+func (c *CaseExpr) Else(result interface{}) *CaseExpr {
+	c.ElseResult = result
+	return c
+}
+
+// As sets the alias of the expression.
+func (c *CaseExpr) As(alias string) *CaseExpr {
+	c.Alias = alias
+	return c
+}
+
+// Condition compares the CASE expression to value with op (=, !=, <>, <, <=, > or >=), for use in whereArgs.
+// Usage: OrdersTable.FetchMany(Case().When(`"total" > 1000`, "large").Else("small").Condition("=", "large"))
+func (c *CaseExpr) Condition(op string, value interface{}) Condition {
+	return Condition{Type: ConditionCase, Values: []interface{}{c, op, value}}
+}
+
+// When adds a WHEN match THEN result branch.
+func (c *CaseValueExpr) When(match, result interface{}) *CaseValueExpr {
+	c.Whens = append(c.Whens, CaseWhen{Condition: match, Result: result})
+	return c
+}
+
+// Else sets the result of rows that match no WHEN branch.
+func (c *CaseValueExpr) Else(result interface{}) *CaseValueExpr {
+	c.ElseResult = result
+	return c
+}
+
+// As sets the alias of the expression.
+func (c *CaseValueExpr) As(alias string) *CaseValueExpr {
+	c.Alias = alias
+	return c
+}
+
+// Condition compares the CASE expression to value with op (=, !=, <>, <, <=, > or >=), for use in whereArgs.
+func (c *CaseValueExpr) Condition(op string, value interface{}) Condition {
+	return c.CaseExpr.Condition(op, value)
+}
+
+// SelectSQL implements SelectExpr.
+// Results are cast to the PostgreSQL type of their Go type, so the column has a consistent type.
+func (c *CaseExpr) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	if len(c.Whens) == 0 {
+		return "", nil, fmt.Errorf("CASE expression needs at least one WHEN branch")
+	}
+	var sb strings.Builder
+	var args []interface{}
+	sb.WriteString("(CASE")
+	if c.Operand != "" {
+		sb.WriteString(" " + c.Operand)
+	}
+	for _, when := range c.Whens {
+		if c.Operand != "" {
+			sb.WriteString(fmt.Sprintf(" WHEN $%d", *argIndex))
+			args = append(args, when.Condition)
+			*argIndex++
+		} else {
+			condition, _ := when.Condition.(string)
+			if strings.TrimSpace(condition) == "" {
+				return "", nil, fmt.Errorf("CASE WHEN condition is empty")
+			}
+			sb.WriteString(" WHEN " + condition)
+		}
+		sb.WriteString(" THEN " + caseResultSQL(when.Result, argIndex, &args))
+	}
+	if c.ElseResult != nil {
+		sb.WriteString(" ELSE " + caseResultSQL(c.ElseResult, argIndex, &args))
+	}
+	sb.WriteString(" END)")
+
+	sql, _, err := withAlias(sb.String(), c.Alias)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, args, nil
+}
+
+// caseConditionOperators are the comparison operators accepted by CaseExpr.Condition.
+var caseConditionOperators = map[string]bool{"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// conditionSQL renders the expression, without its alias, compared to value with op.
+func (c *CaseExpr) conditionSQL(op string, value interface{}, argIndex *int) (string, []interface{}, error) {
+	unaliased := *c
+	unaliased.Alias = ""
+	sql, args, err := unaliased.SelectSQL(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	sql = fmt.Sprintf("%s %s $%d", sql, op, *argIndex)
+	*argIndex++
+	return sql, append(args, value), nil
+}
+
+// caseResultSQL returns the parameter placeholder of a CASE result, cast to the PostgreSQL type of
+// its Go type (a parameter in THEN would otherwise be text), and appends the result to args.
+func caseResultSQL(result interface{}, argIndex *int, args *[]interface{}) string {
+	if result == nil {
+		return "NULL"
+	}
+	placeholder := fmt.Sprintf("$%d", *argIndex)
+	*args = append(*args, result)
+	*argIndex++
+	switch result.(type) {
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		return placeholder + "::bigint"
+	case float32, float64:
+		return placeholder + "::double precision"
+	case bool:
+		return placeholder + "::boolean"
+	case string:
+		return placeholder + "::text"
+	case time.Time:
+		return placeholder + "::timestamptz"
+	}
+	return placeholder
+}
@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCaseExpressions(t *testing.T) {
+	users := &Table{Name: "users"}
+	runQueryTests(t, []queryTest{
+		{
+			name: "searched case",
+			query: users.Query().Select("id",
+				Case().When(`"age" < 18`, "minor").When(`"age" < 65`, "adult").Else("senior").As("category")).
+				Where(map[string]interface{}{"active": true}),
+			want:     `SELECT "id", (CASE WHEN "age" < 18 THEN $1::text WHEN "age" < 65 THEN $2::text ELSE $3::text END) AS "category" FROM "users" WHERE "active" = $4`,
+			wantArgs: []interface{}{"minor", "adult", "senior", true},
+		},
+		{
+			name:     "simple case without else",
+			query:    users.Query().Select(CaseValue(`"priority"`).When("high", 1).When("normal", 2.5).As("rank")),
+			want:     `SELECT (CASE "priority" WHEN $1 THEN $2::bigint WHEN $3 THEN $4::double precision END) AS "rank" FROM "users"`,
+			wantArgs: []interface{}{"high", 1, "normal", 2.5},
+		},
+	})
+
+	runWhereTests(t, []whereTest{
+		{
+			name: "case condition",
+			whereArgs: []interface{}{
+				map[string]interface{}{"active": true},
+				Case().When(`"total" > 1000`, "large").Else("small").As("ignored").Condition("=", "large"),
+			},
+			want:     ` WHERE "active" = $1 AND (CASE WHEN "total" > 1000 THEN $2::text ELSE $3::text END) = $4`,
+			wantArgs: []interface{}{true, "large", "small", "large"},
+		},
+	})
+
+	for name, whereArg := range map[string]interface{}{
+		"no branches":      Case().Condition("=", 1),
+		"invalid operator": Case().When("TRUE", 1).Condition("; DROP TABLE users; --", 1),
+		"empty condition":  Case().When(" ", 1).Condition("=", 1),
+	} {
+		if _, _, err := BuildWhere(whereArg); err == nil {
+			t.Errorf("%s: BuildWhere succeeded, want an error", name)
+		}
+	}
+	if _, _, err := users.Query().Select(Case().When("TRUE", 1).As("bad alias")).ToSQL(); err == nil {
+		t.Error("Case with an invalid alias succeeded, want an error")
+	}
+}
+
+func TestCaseCategoryLabel(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "score", DataType: *DataType{}.Integer()})
+	for _, score := range []int{5, 50, 95} {
+		if _, err := table.Insert(map[string]interface{}{"score": score}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	label := Case().When(`"score" < 10`, "low").When(`"score" < 90`, "medium").Else("high")
+	rows, err := table.Query().Select("score", label.As("label")).OrderBy(OrderBySpec{Column: "score"}).FetchMany(context.Background())
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	want := []string{"low", "medium", "high"}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row["label"] != want[i] {
+			t.Errorf("score %v labelled %v, want %s", row["score"], row["label"], want[i])
+		}
+	}
+
+	filtered, err := table.FetchMany(Case().When(`"score" < 10`, "low").When(`"score" < 90`, "medium").Else("high").Condition("=", "medium"))
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0]["score"] != int32(50) {
+		t.Errorf("rows labelled medium = %v, want the score 50", filtered)
+	}
+}
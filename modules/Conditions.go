@@ -1,8 +1,11 @@
 package modules
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -20,6 +23,39 @@ const (
 	ConditionGte       ConditionType = ">="
 	ConditionLte       ConditionType = "<="
 	ConditionNeq       ConditionType = "!="
+
+	ConditionRegex     ConditionType = "~"
+	ConditionIRegex    ConditionType = "~*"
+	ConditionNotRegex  ConditionType = "!~"
+	ConditionNotIRegex ConditionType = "!~*"
+
+	ConditionJsonbContains  ConditionType = "@>"
+	ConditionJsonbPathMatch ConditionType = "#>>"
+	ConditionJsonbHasKey    ConditionType = "?"
+	ConditionJsonPath       ConditionType = "JSON PATH"
+
+	ConditionArrayContains    ConditionType = "ARRAY @>"
+	ConditionArrayContainedBy ConditionType = "ARRAY <@"
+	ConditionArrayOverlap     ConditionType = "ARRAY &&"
+	ConditionArrayLength      ConditionType = "ARRAY_LENGTH"
+
+	ConditionGroup ConditionType = "AND GROUP"
+	ConditionOr    ConditionType = "OR GROUP"
+	ConditionRaw   ConditionType = "RAW"
+
+	ConditionNamedRaw ConditionType = "NAMED RAW"
+
+	ConditionExists    ConditionType = "EXISTS"
+	ConditionNotExists ConditionType = "NOT EXISTS"
+
+	ConditionNot ConditionType = "NOT"
+
+	ConditionCase ConditionType = "CASE"
+
+	ConditionFullText      ConditionType = "FULL TEXT"
+	ConditionTsvectorMatch ConditionType = "@@"
+	ConditionTsMatch       ConditionType = "@@ TSQUERY"
+	ConditionTsMatchPhrase ConditionType = "@@ PHRASE"
 )
 
 // Condition represents a complex SQL condition used in WHERE clauses.
@@ -28,12 +64,130 @@ type Condition struct {
 	Values []interface{}
 }
 
+// isStandalone reports whether the condition can be used without a column,
+// i.e. directly in the whereArgs list rather than as a map value.
+func (c Condition) isStandalone() bool {
+	switch c.Type {
+	case ConditionGroup, ConditionOr, ConditionRaw, ConditionNamedRaw, ConditionExists, ConditionNotExists, ConditionCase:
+		return true
+	case ConditionNot:
+		if len(c.Values) != 1 {
+			return false
+		}
+		inner, _ := c.Values[0].(Condition)
+		return inner.isStandalone()
+	}
+	return false
+}
+
+// validate checks that the condition has the number and kind of values its type requires.
+// An empty Condition (e.g. Between(nil, nil)) is valid and renders as no filter.
+func (c Condition) validate() error {
+	expected := -1
+	switch c.Type {
+	case "":
+		return nil
+	case ConditionIsNull, ConditionIsNotNull:
+		expected = 0
+	case ConditionIn, ConditionLike, ConditionGt, ConditionLt, ConditionGte, ConditionLte, ConditionNeq,
+		ConditionRegex, ConditionIRegex, ConditionNotRegex, ConditionNotIRegex,
+		ConditionFullText, ConditionTsvectorMatch,
+		ConditionJsonbHasKey, ConditionArrayContains, ConditionArrayContainedBy, ConditionArrayOverlap, ConditionArrayLength:
+		expected = 1
+	case ConditionBetween, ConditionJsonbContains:
+		expected = 2
+	case ConditionJsonbPathMatch:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("%s condition requires a path and a value, got %d values", c.Type, len(c.Values))
+		}
+		_, err := jsonbPathText(c.Values[1])
+		return err
+	case ConditionGroup, ConditionOr:
+		return nil
+	case ConditionTsMatch, ConditionTsMatchPhrase:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("%s condition requires a query and a config, got %d values", c.Type, len(c.Values))
+		}
+		config, ok := c.Values[1].(string)
+		if !ok || !isValidIdentifier(config) {
+			return fmt.Errorf("invalid text search config: '%v'", c.Values[1])
+		}
+		return nil
+	case ConditionJsonPath:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("%s condition requires a path and a condition, got %d values", c.Type, len(c.Values))
+		}
+		if _, ok := c.Values[0].(string); !ok {
+			return fmt.Errorf("%s condition requires a string path, got %T", c.Type, c.Values[0])
+		}
+		inner, ok := c.Values[1].(Condition)
+		if !ok {
+			return fmt.Errorf("%s condition requires a Condition, got %T", c.Type, c.Values[1])
+		}
+		if inner.isStandalone() {
+			return fmt.Errorf("%s condition cannot wrap standalone condition %s", c.Type, inner.Type)
+		}
+		return inner.validate()
+	case ConditionNot:
+		if len(c.Values) != 1 {
+			return fmt.Errorf("%s condition requires exactly one condition, got %d values", c.Type, len(c.Values))
+		}
+		inner, ok := c.Values[0].(Condition)
+		if !ok {
+			return fmt.Errorf("%s condition requires a Condition, got %T", c.Type, c.Values[0])
+		}
+		return inner.validate()
+	case ConditionNamedRaw:
+		if len(c.Values) != 2 {
+			return fmt.Errorf("%s condition requires a SQL string and named arguments, got %d values", c.Type, len(c.Values))
+		}
+		if _, ok := c.Values[0].(string); !ok {
+			return fmt.Errorf("%s condition requires a SQL string, got %T", c.Type, c.Values[0])
+		}
+		if _, ok := c.Values[1].(NamedArgs); !ok {
+			return fmt.Errorf("%s condition requires NamedArgs, got %T", c.Type, c.Values[1])
+		}
+		return nil
+	case ConditionCase:
+		if len(c.Values) != 3 {
+			return fmt.Errorf("%s condition requires an expression, an operator and a value, got %d values", c.Type, len(c.Values))
+		}
+		if expr, ok := c.Values[0].(*CaseExpr); !ok || expr == nil {
+			return fmt.Errorf("%s condition requires a *CaseExpr, got %T", c.Type, c.Values[0])
+		}
+		if op, _ := c.Values[1].(string); !caseConditionOperators[op] {
+			return fmt.Errorf("invalid %s comparison operator: '%v'", c.Type, c.Values[1])
+		}
+		return nil
+	case ConditionRaw, ConditionExists, ConditionNotExists:
+		if len(c.Values) == 0 {
+			return fmt.Errorf("%s condition requires a SQL string", c.Type)
+		}
+		if _, ok := c.Values[0].(string); !ok {
+			return fmt.Errorf("%s condition requires a SQL string, got %T", c.Type, c.Values[0])
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown condition type '%s'", c.Type)
+	}
+	if len(c.Values) != expected {
+		return fmt.Errorf("%s condition requires %d value(s), got %d", c.Type, expected, len(c.Values))
+	}
+	return nil
+}
+
 // ToSQL generates the SQL fragment and arguments for the condition.
 // It expects the column name to be already quoted if necessary.
-func (c Condition) ToSQL(col string, argIndex *int) (string, []interface{}) {
+// Standalone conditions (e.g. WhereGroup, Or) accept an empty column name.
+// Returns an error if the condition's values cannot be converted to SQL parameters.
+func (c Condition) ToSQL(col string, argIndex *int) (string, []interface{}, error) {
 	var args []interface{}
 	var sql string
 
+	if err := c.validate(); err != nil {
+		return "", nil, err
+	}
+
 	switch c.Type {
 	case ConditionIn:
 		inArgs := []string{}
@@ -41,7 +195,7 @@ func (c Condition) ToSQL(col string, argIndex *int) (string, []interface{}) {
 		rv := reflect.ValueOf(valSlice)
 		if rv.Kind() == reflect.Slice {
 			if rv.Len() == 0 {
-				return "1=0", nil
+				return "1=0", nil, nil
 			}
 			for i := 0; i < rv.Len(); i++ {
 				inArgs = append(inArgs, fmt.Sprintf("$%d", *argIndex))
@@ -96,9 +250,361 @@ func (c Condition) ToSQL(col string, argIndex *int) (string, []interface{}) {
 		sql = fmt.Sprintf("%s != $%d", col, *argIndex)
 		args = append(args, c.Values[0])
 		*argIndex++
+
+	case ConditionRegex, ConditionIRegex, ConditionNotRegex, ConditionNotIRegex:
+		sql = fmt.Sprintf("%s %s $%d", col, c.Type, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionFullText:
+		sql = fmt.Sprintf("to_tsvector(%s) @@ plainto_tsquery($%d)", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionTsvectorMatch:
+		sql = fmt.Sprintf("%s @@ plainto_tsquery($%d)", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionTsMatch, ConditionTsMatchPhrase:
+		function := "to_tsquery"
+		if c.Type == ConditionTsMatchPhrase {
+			function = "phraseto_tsquery"
+		}
+		sql = fmt.Sprintf("%s @@ %s($%d::regconfig, $%d)", col, function, *argIndex, *argIndex+1)
+		args = append(args, c.Values[1], c.Values[0])
+		*argIndex += 2
+
+	case ConditionJsonbContains:
+		path, _ := c.Values[0].(string)
+		data, err := json.Marshal(c.Values[1])
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal jsonb value for %s: %w", col, err)
+		}
+		if path == "" {
+			sql = fmt.Sprintf("%s @> $%d::jsonb", col, *argIndex)
+		} else {
+			sql = fmt.Sprintf("%s #> $%d @> $%d::jsonb", col, *argIndex, *argIndex+1)
+			args = append(args, jsonPathElements(path))
+			*argIndex++
+		}
+		args = append(args, string(data))
+		*argIndex++
+
+	case ConditionJsonbPathMatch:
+		path, _ := c.Values[0].(string)
+		text, err := jsonbPathText(c.Values[1])
+		if err != nil {
+			return "", nil, err
+		}
+		sql = fmt.Sprintf("%s #>> $%d = $%d", col, *argIndex, *argIndex+1)
+		args = append(args, jsonPathElements(path), text)
+		*argIndex += 2
+
+	case ConditionJsonbHasKey:
+		sql = fmt.Sprintf("%s ? $%d", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionJsonPath:
+		path, _ := c.Values[0].(string)
+		inner, _ := c.Values[1].(Condition)
+		// The extracted text value becomes the "column" the inner condition is applied to
+		target := fmt.Sprintf("(%s #>> $%d)", col, *argIndex)
+		*argIndex++
+		innerSQL, innerArgs, err := inner.ToSQL(target, argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		if innerSQL == "" {
+			*argIndex-- // No filter, so the path parameter is not used either
+			return "", nil, nil
+		}
+		return innerSQL, append([]interface{}{jsonPathElements(path)}, innerArgs...), nil
+
+	case ConditionArrayContains:
+		sql = fmt.Sprintf("%s @> $%d", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionArrayContainedBy:
+		sql = fmt.Sprintf("%s <@ $%d", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionArrayOverlap:
+		sql = fmt.Sprintf("%s && $%d", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionArrayLength:
+		sql = fmt.Sprintf("array_length(%s, 1) = $%d", col, *argIndex)
+		args = append(args, c.Values[0])
+		*argIndex++
+
+	case ConditionGroup:
+		return c.groupSQL(col, " AND ", "TRUE", argIndex)
+
+	case ConditionOr:
+		return c.groupSQL(col, " OR ", "FALSE", argIndex)
+
+	case ConditionRaw:
+		rawSQL, _ := c.Values[0].(string)
+		rawArgs := c.Values[1:]
+		rendered, err := renumberPlaceholders(rawSQL, len(rawArgs), argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		// Parenthesise so an OR inside the fragment cannot escape the surrounding AND.
+		return "(" + rendered + ")", rawArgs, nil
+
+	case ConditionNamedRaw:
+		rawSQL, _ := c.Values[0].(string)
+		named, _ := c.Values[1].(NamedArgs)
+		numbered, rawArgs, err := bindNamedPlaceholders(rawSQL, named)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(rawArgs) == 0 {
+			return "(" + rawSQL + ")", nil, nil
+		}
+		rendered, err := renumberPlaceholders(numbered, len(rawArgs), argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		return "(" + rendered + ")", rawArgs, nil
+
+	case ConditionExists, ConditionNotExists:
+		subquery, _ := c.Values[0].(string)
+		subArgs := c.Values[1:]
+		rendered, err := renumberPlaceholders(subquery, len(subArgs), argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s (%s)", c.Type, rendered), subArgs, nil
+
+	case ConditionCase:
+		expr, _ := c.Values[0].(*CaseExpr)
+		op, _ := c.Values[1].(string)
+		return expr.conditionSQL(op, c.Values[2], argIndex)
+
+	case ConditionNot:
+		inner, _ := c.Values[0].(Condition)
+		innerSQL, innerArgs, err := inner.ToSQL(col, argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		if innerSQL == "" {
+			return "", nil, nil // Negating no filter is still no filter
+		}
+		return "NOT (" + innerSQL + ")", innerArgs, nil
 	}
 
-	return sql, args
+	return sql, args, nil
+}
+
+// groupSQL renders the condition's Values as a parenthesised group joined by sep.
+//
+// Each value may be anything accepted in whereArgs (maps, raw strings and their positional values, nested groups).
+// A map that produces several conditions is ANDed into a single parenthesised part.
+// When the group is used as a map value, column conditions (e.g. Gt(5)) are applied to col.
+// An empty group renders as the identity value for its operator (TRUE for AND, FALSE for OR).
+func (c Condition) groupSQL(col, sep, empty string, argIndex *int) (string, []interface{}, error) {
+	var parts []string
+	var args []interface{}
+	// As in buildWhereClause, positional values belong to the group's raw fragments and follow its other parameters
+	var rawParts []int
+	var positional []interface{}
+
+	for _, item := range c.Values {
+		if cond, ok := item.(Condition); ok && col != "" {
+			sql, condArgs, err := cond.ToSQL(col, argIndex)
+			if err != nil {
+				return "", nil, err
+			}
+			if sql == "" {
+				continue
+			}
+			parts = append(parts, sql)
+			args = append(args, condArgs...)
+			continue
+		}
+		switch v := item.(type) {
+		case string:
+			rawParts = append(rawParts, len(parts))
+			parts = append(parts, v)
+			continue
+		case map[string]interface{}, Condition:
+		default:
+			positional = append(positional, v)
+			continue
+		}
+
+		itemConditions, itemArgs, err := buildConditions([]interface{}{item}, argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, itemArgs...)
+		switch len(itemConditions) {
+		case 0:
+		case 1:
+			parts = append(parts, itemConditions[0])
+		default:
+			parts = append(parts, "("+strings.Join(itemConditions, " AND ")+")")
+		}
+	}
+
+	if err := bindPositional(parts, rawParts, positional, argIndex); err != nil {
+		return "", nil, err
+	}
+	args = append(args, positional...)
+
+	if len(parts) == 0 {
+		return empty, args, nil
+	}
+	return "(" + strings.Join(parts, sep) + ")", args, nil
+}
+
+// renumberPlaceholders rewrites the placeholders in a raw SQL fragment so they start at argIndex,
+// and advances argIndex by argCount.
+//
+// Numbered placeholders ($1, $2, ...) are relative to the fragment and are shifted by the current offset.
+// If the fragment has no numbered placeholders but has arguments, each ? is replaced with the next $n in order.
+// A fragment without arguments is left as is, and ?| and ?& are never placeholders, so the jsonb
+// ?, ?| and ?& operators can be used; with arguments, combine the ? operator with $n placeholders.
+// Placeholders inside single-quoted string literals are left untouched.
+//
+// Returns an error if the fragment references more parameters than argCount,
+// or if argCount is non-zero but the fragment contains no placeholders.
+func renumberPlaceholders(fragment string, argCount int, argIndex *int) (string, error) {
+	numbered := false
+	inQuote := false
+	for i := 0; i < len(fragment); i++ {
+		switch {
+		case fragment[i] == '\'':
+			inQuote = !inQuote
+		case !inQuote && fragment[i] == '$' && i+1 < len(fragment) && isDigit(fragment[i+1]):
+			numbered = true
+		}
+	}
+
+	var sb strings.Builder
+	base := *argIndex
+	used := 0
+	inQuote = false
+	for i := 0; i < len(fragment); i++ {
+		ch := fragment[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+			sb.WriteByte(ch)
+		case !inQuote && numbered && ch == '$' && i+1 < len(fragment) && isDigit(fragment[i+1]):
+			j := i + 1
+			n := 0
+			for j < len(fragment) && isDigit(fragment[j]) {
+				n = n*10 + int(fragment[j]-'0')
+				j++
+			}
+			if n < 1 || n > argCount {
+				return "", fmt.Errorf("placeholder $%d in %q has no matching argument (%d given)", n, fragment, argCount)
+			}
+			sb.WriteString(fmt.Sprintf("$%d", base+n-1))
+			used = max(used, n)
+			i = j - 1
+		case !inQuote && ch == '?' && i+1 < len(fragment) && (fragment[i+1] == '|' || fragment[i+1] == '&'):
+			sb.WriteString(fragment[i : i+2])
+			i++
+		case !inQuote && !numbered && argCount > 0 && ch == '?':
+			used++
+			if used > argCount {
+				return "", fmt.Errorf("placeholder ? #%d in %q has no matching argument (%d given)", used, fragment, argCount)
+			}
+			sb.WriteString(fmt.Sprintf("$%d", base+used-1))
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+
+	if argCount > 0 && used == 0 {
+		return "", fmt.Errorf("raw condition %q has %d arguments but no placeholders", fragment, argCount)
+	}
+	*argIndex += argCount
+	return sb.String(), nil
+}
+
+// bindNamedPlaceholders rewrites the :name placeholders of a WhereRaw fragment to $1, $2, ...
+// relative to the fragment, in order of first use, and returns the matching arguments.
+// A name used several times gets a single parameter. Casts (::type) and text inside single-quoted
+// string literals are left untouched.
+//
+// Returns an error if a placeholder has no argument, an argument is not used, or the fragment
+// contains positional placeholders ($1), which cannot be mixed with named ones.
+func bindNamedPlaceholders(fragment string, named NamedArgs) (string, []interface{}, error) {
+	var sb strings.Builder
+	var args []interface{}
+	positions := map[string]int{}
+	inQuote := false
+	for i := 0; i < len(fragment); i++ {
+		ch := fragment[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+			sb.WriteByte(ch)
+		case !inQuote && ch == ':' && i+1 < len(fragment) && fragment[i+1] == ':':
+			sb.WriteString("::")
+			i++
+		case !inQuote && ch == ':' && i+1 < len(fragment) && isIdentifierStart(fragment[i+1]):
+			j := i + 1
+			for j < len(fragment) && (isIdentifierStart(fragment[j]) || isDigit(fragment[j])) {
+				j++
+			}
+			name := fragment[i+1 : j]
+			pos, seen := positions[name]
+			if !seen {
+				val, found := named[name]
+				if !found {
+					return "", nil, fmt.Errorf("placeholder :%s in %q has no matching argument", name, fragment)
+				}
+				args = append(args, val)
+				pos = len(args)
+				positions[name] = pos
+			}
+			sb.WriteString(fmt.Sprintf("$%d", pos))
+			i = j - 1
+		case !inQuote && ch == '$' && i+1 < len(fragment) && isDigit(fragment[i+1]):
+			return "", nil, fmt.Errorf("positional placeholder in %q; WhereRaw uses :name placeholders", fragment)
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+
+	for name := range named {
+		if _, used := positions[name]; !used {
+			return "", nil, fmt.Errorf("argument %q is not used in %q", name, fragment)
+		}
+	}
+	return sb.String(), args, nil
+}
+
+// isIdentifierStart reports whether ch can start a placeholder name (an ASCII letter or underscore).
+func isIdentifierStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// isDigit reports whether ch is an ASCII digit.
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// jsonPathElements splits a JSON path into its elements for use with the #> and #>> operators.
+// It accepts both dotted paths ("address.city") and PostgreSQL array literals ("{address,city}").
+func jsonPathElements(path string) []string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		path = strings.Trim(path, "{}")
+		return strings.Split(path, ",")
+	}
+	return strings.Split(path, ".")
 }
 
 // In returns a Condition checking if a column's value is within a set of values.
@@ -171,3 +677,262 @@ func Lte(value interface{}) Condition {
 func Neq(value interface{}) Condition {
 	return Condition{Type: ConditionNeq, Values: []interface{}{value}}
 }
+
+// Regex returns a Condition checking if a column's value matches a POSIX regular expression (case-sensitive ~).
+// Usage: Regex("^[A-Z]{3}-[0-9]+$")
+func Regex(pattern string) Condition {
+	return Condition{Type: ConditionRegex, Values: []interface{}{pattern}}
+}
+
+// IRegex returns a Condition checking if a column's value matches a POSIX regular expression (case-insensitive ~*).
+// Usage: IRegex("@example\\.com$")
+func IRegex(pattern string) Condition {
+	return Condition{Type: ConditionIRegex, Values: []interface{}{pattern}}
+}
+
+// NotRegex returns a Condition checking if a column's value does not match a regular expression (case-sensitive !~).
+// Usage: NotRegex("^test_")
+func NotRegex(pattern string) Condition {
+	return Condition{Type: ConditionNotRegex, Values: []interface{}{pattern}}
+}
+
+// NotIRegex returns a Condition checking if a column's value does not match a regular expression (case-insensitive !~*).
+// Usage: NotIRegex("spam")
+func NotIRegex(pattern string) Condition {
+	return Condition{Type: ConditionNotIRegex, Values: []interface{}{pattern}}
+}
+
+// FullText returns a Condition for full-text search on a text column.
+// The column is converted with to_tsvector and matched against plainto_tsquery(query),
+// so the query is plain words rather than tsquery syntax.
+// Usage: FullText("fast database driver")
+func FullText(query string) Condition {
+	return Condition{Type: ConditionFullText, Values: []interface{}{query}}
+}
+
+// TsvectorMatch returns a Condition for full-text search on an existing tsvector column
+// (col @@ plainto_tsquery(query)). It avoids recomputing to_tsvector and can use a GIN index.
+// Usage: TsvectorMatch("fast database driver")
+func TsvectorMatch(query string) Condition {
+	return Condition{Type: ConditionTsvectorMatch, Values: []interface{}{query}}
+}
+
+// TsMatch returns a Condition matching a tsvector column against a tsquery expression
+// (col @@ to_tsquery(config, query)). The query uses tsquery syntax, e.g. "postgres & (pool | pooling)".
+// config is the text search configuration and defaults to "english" when empty.
+// Usage: TsMatch("postgres & pool", "english")
+func TsMatch(query, config string) Condition {
+	if config == "" {
+		config = "english"
+	}
+	return Condition{Type: ConditionTsMatch, Values: []interface{}{query, config}}
+}
+
+// TsMatchPhrase returns a Condition matching a tsvector column against a phrase, with the words
+// in order (col @@ phraseto_tsquery(config, phrase)). config defaults to "english" when empty.
+// Usage: TsMatchPhrase("connection pool", "")
+func TsMatchPhrase(phrase, config string) Condition {
+	if config == "" {
+		config = "english"
+	}
+	return Condition{Type: ConditionTsMatchPhrase, Values: []interface{}{phrase, config}}
+}
+
+// JsonbContains returns a Condition checking if a JSONB column contains the given value (@> operator).
+// The value is marshalled to JSON. If path is not empty, the containment check is applied
+// to the sub-document at that path instead of the whole column.
+// Usage: JsonbContains("", map[string]interface{}{"role": "admin"})
+// Usage: JsonbContains("address", map[string]interface{}{"city": "Dhaka"})
+func JsonbContains(path string, value interface{}) Condition {
+	return Condition{Type: ConditionJsonbContains, Values: []interface{}{path, value}}
+}
+
+// JsonbPathMatch returns a Condition checking if the text value at a JSON path equals the target (#>> operator).
+// The path can be dotted ("address.city") or a PostgreSQL array literal ("{address,city}").
+// The value must be a string, bool or number; use JSONPath(path, IsNull()) to match a missing or null value.
+// Usage: JsonbPathMatch("address.city", "Dhaka")
+func JsonbPathMatch(path string, value interface{}) Condition {
+	return Condition{Type: ConditionJsonbPathMatch, Values: []interface{}{path, value}}
+}
+
+// jsonbPathText returns the text #>> yields for a JsonbPathMatch value.
+func jsonbPathText(value interface{}) (string, error) {
+	if value == nil {
+		return "", fmt.Errorf("%s condition cannot match nil, use JSONPath(path, IsNull()) instead", ConditionJsonbPathMatch)
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("%s condition cannot match %v, JSON has no such number", ConditionJsonbPathMatch, f)
+		}
+		return strconv.FormatFloat(f, 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("%s condition requires a string, bool or number, got %T", ConditionJsonbPathMatch, value)
+}
+
+// JsonbHasKey returns a Condition checking if a JSONB column has the given top-level key (? operator).
+// Usage: JsonbHasKey("email")
+func JsonbHasKey(key string) Condition {
+	return Condition{Type: ConditionJsonbHasKey, Values: []interface{}{key}}
+}
+
+// JSONContains returns a Condition checking if a JSONB column contains the given value (col @> $n).
+// It is shorthand for JsonbContains("", value).
+// Usage: JSONContains(map[string]interface{}{"role": "admin"})
+func JSONContains(value interface{}) Condition {
+	return JsonbContains("", value)
+}
+
+// JSONHasKey returns a Condition checking if a JSONB column has the given top-level key (col ? $n).
+// It is shorthand for JsonbHasKey(key).
+// Usage: JSONHasKey("email")
+func JSONHasKey(key string) Condition {
+	return JsonbHasKey(key)
+}
+
+// JSONPath returns a Condition that applies another condition to the text value at a path
+// within a JSON/JSONB column (col #>> path). The path can be dotted ("address.city")
+// or a PostgreSQL array literal ("{address,city}"). Values at the path are compared as text.
+// Usage:
+//
+//	FetchMany(map[string]interface{}{"profile": JSONPath("address.city", In([]string{"Dhaka", "Chittagong"}))})
+//	FetchMany(map[string]interface{}{"profile": JSONPath("name", Like("ali%"))})
+func JSONPath(path string, cond Condition) Condition {
+	return Condition{Type: ConditionJsonPath, Values: []interface{}{path, cond}}
+}
+
+// ArrayContains returns a Condition checking if an array column contains all of the given elements (@> operator).
+// The value should be a Go slice; pgx encodes it as a PostgreSQL array.
+// Usage: ArrayContains([]string{"go", "sql"})
+func ArrayContains(value interface{}) Condition {
+	return Condition{Type: ConditionArrayContains, Values: []interface{}{value}}
+}
+
+// ArrayContainedBy returns a Condition checking if all elements of an array column are in the given slice (<@ operator).
+// Usage: ArrayContainedBy([]int{1, 2, 3})
+func ArrayContainedBy(value interface{}) Condition {
+	return Condition{Type: ConditionArrayContainedBy, Values: []interface{}{value}}
+}
+
+// ArrayOverlap returns a Condition checking if an array column shares any element with the given slice (&& operator).
+// Usage: ArrayOverlap([]string{"admin", "editor"})
+func ArrayOverlap(value interface{}) Condition {
+	return Condition{Type: ConditionArrayOverlap, Values: []interface{}{value}}
+}
+
+// ArrayOverlaps is an alias of ArrayOverlap (col && $n).
+// Usage: ArrayOverlaps([]string{"admin", "editor"})
+func ArrayOverlaps(value interface{}) Condition {
+	return ArrayOverlap(value)
+}
+
+// ArrayLength returns a Condition checking the length of the first dimension of an array column.
+// Note that array_length returns NULL for empty arrays, so ArrayLength(0) never matches.
+// Usage: ArrayLength(3)
+func ArrayLength(length int) Condition {
+	return Condition{Type: ConditionArrayLength, Values: []interface{}{length}}
+}
+
+// WhereGroup returns a Condition that ANDs its arguments together inside parentheses.
+// Arguments can be anything accepted in whereArgs: maps, raw SQL strings, or other groups.
+// Combine it with Or to express mixed AND/OR logic.
+// Usage:
+//
+//	// WHERE ("role" = $1 AND "active" = $2) OR ("role" = $3)
+//	FetchMany(Or(
+//	    WhereGroup(map[string]interface{}{"role": "admin"}, map[string]interface{}{"active": true}),
+//	    WhereGroup(map[string]interface{}{"role": "owner"}),
+//	))
+func WhereGroup(conditions ...interface{}) Condition {
+	return Condition{Type: ConditionGroup, Values: conditions}
+}
+
+// Or returns a Condition that ORs its arguments together inside parentheses.
+// Each argument is rendered as a single term; a map with several keys is ANDed within its term.
+// It can also be used as a map value to OR several conditions on the same column.
+// Usage:
+//
+//	FetchMany(Or(map[string]interface{}{"age": Lt(18)}, map[string]interface{}{"age": Gt(65)}))
+//	FetchMany(map[string]interface{}{"age": Or(Lt(18), Gt(65))})
+func Or(conditions ...interface{}) Condition {
+	return Condition{Type: ConditionOr, Values: conditions}
+}
+
+// RawCondition returns a Condition that injects a raw SQL fragment into the WHERE clause.
+// It is the escape hatch for expressions the Condition API cannot express
+// (PostGIS functions, custom operators, function calls).
+//
+// Parameters can be written as ? or as $1, $2, ... relative to the fragment; they are renumbered
+// to fit the surrounding query, so the fragment can be safely combined with map conditions.
+// Values are always passed as parameters. The SQL text itself must not contain untrusted input.
+// Usage:
+//
+//	RawCondition("ST_DWithin(location, ST_MakePoint(?, ?)::geography, ?)", lng, lat, 1000)
+//	RawCondition("lower(email) = $1", email)
+func RawCondition(sql string, args ...interface{}) Condition {
+	return Condition{Type: ConditionRaw, Values: append([]interface{}{sql}, args...)}
+}
+
+// NamedArgs holds the values of the :name placeholders of a WhereRaw fragment.
+type NamedArgs map[string]interface{}
+
+// WhereRaw returns a Condition that injects a raw SQL fragment with named placeholders (:name)
+// into the WHERE clause. The placeholders are rewritten to the right $n for the surrounding query,
+// so the fragment never collides with the parameters of map conditions or of the rest of the statement.
+// A name can be used several times; casts such as ::date are not placeholders.
+//
+// Several NamedArgs are merged. It is an error for a placeholder to have no value or for a value
+// to be unused. Values are always passed as parameters; the SQL text itself must not contain untrusted input.
+// Usage:
+//
+//	UsersTable.FetchMany(
+//	    map[string]interface{}{"active": true},
+//	    WhereRaw("age BETWEEN :min AND :max OR created_at > :since::date", NamedArgs{"min": 18, "max": 65, "since": "2024-01-01"}),
+//	)
+func WhereRaw(fragment string, args ...NamedArgs) Condition {
+	merged := NamedArgs{}
+	for _, a := range args {
+		for name, val := range a {
+			merged[name] = val
+		}
+	}
+	return Condition{Type: ConditionNamedRaw, Values: []interface{}{fragment, merged}}
+}
+
+// WhereExists returns a Condition checking that a subquery returns at least one row (EXISTS).
+// The subquery is used as-is (identifiers are not quoted) so it can reference the outer table.
+// Its parameters ($1, $2, ... or ?) are renumbered to fit the surrounding query.
+// Usage:
+//
+//	// Users with at least one order over a given total
+//	UsersTable.FetchMany(WhereExists("SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total > $1", 100))
+func WhereExists(subquery string, args ...interface{}) Condition {
+	return Condition{Type: ConditionExists, Values: append([]interface{}{subquery}, args...)}
+}
+
+// WhereNotExists returns a Condition checking that a subquery returns no rows (NOT EXISTS).
+// It follows the same rules as WhereExists.
+// Usage: WhereNotExists("SELECT 1 FROM orders WHERE orders.user_id = users.id")
+func WhereNotExists(subquery string, args ...interface{}) Condition {
+	return Condition{Type: ConditionNotExists, Values: append([]interface{}{subquery}, args...)}
+}
+
+// Not returns a Condition that negates another condition (NOT (...)).
+// It can wrap column conditions (used as a map value) as well as groups and other standalone conditions.
+// Usage:
+//
+//	FetchMany(map[string]interface{}{"id": Not(In([]int{1, 2}))})  // NOT ("id" IN ($1, $2))
+//	FetchMany(Not(Or(map[string]interface{}{"role": "admin"}, map[string]interface{}{"role": "owner"})))
+func Not(cond Condition) Condition {
+	return Condition{Type: ConditionNot, Values: []interface{}{cond}}
+}
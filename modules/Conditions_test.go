@@ -0,0 +1,236 @@
+package modules
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// whereTest is a BuildWhere case: the whereArgs and the clause and arguments they must produce.
+type whereTest struct {
+	name      string
+	whereArgs []interface{}
+	want      string
+	wantArgs  []interface{}
+}
+
+func runWhereTests(t *testing.T, tests []whereTest) {
+	t.Helper()
+	for _, test := range tests {
+		where, args, err := BuildWhere(test.whereArgs...)
+		if err != nil {
+			t.Errorf("%s: BuildWhere: %v", test.name, err)
+			continue
+		}
+		if where != test.want {
+			t.Errorf("%s:\n got  %s\n want %s", test.name, where, test.want)
+		}
+		if !reflect.DeepEqual(args, test.wantArgs) {
+			t.Errorf("%s: args = %v, want %v", test.name, args, test.wantArgs)
+		}
+	}
+}
+
+func TestWhereGroupAndOr(t *testing.T) {
+	runWhereTests(t, []whereTest{
+		{
+			name: "two groups in an or",
+			whereArgs: []interface{}{Or(
+				WhereGroup(map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}),
+				WhereGroup(map[string]interface{}{"c": 3}, map[string]interface{}{"d": 4}),
+			)},
+			want:     ` WHERE (("a" = $1 AND "b" = $2) OR ("c" = $3 AND "d" = $4))`,
+			wantArgs: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name:      "group anded with a map",
+			whereArgs: []interface{}{map[string]interface{}{"a": 1}, WhereGroup(map[string]interface{}{"b": 2, "c": 3})},
+			want:      ` WHERE "a" = $1 AND (("b" = $2 AND "c" = $3))`,
+			wantArgs:  []interface{}{1, 2, 3},
+		},
+		{
+			name:      "or on one column",
+			whereArgs: []interface{}{map[string]interface{}{"age": Or(Lt(18), Gt(65))}},
+			want:      ` WHERE ("age" < $1 OR "age" > $2)`,
+			wantArgs:  []interface{}{18, 65},
+		},
+		{
+			name: "three levels",
+			whereArgs: []interface{}{
+				map[string]interface{}{"tenant": 7},
+				Or(
+					WhereGroup(
+						map[string]interface{}{"role": "admin"},
+						Or(map[string]interface{}{"team": "a"}, WhereGroup(map[string]interface{}{"team": "b"}, "level > $1", 3)),
+					),
+					map[string]interface{}{"role": "owner"},
+				),
+				map[string]interface{}{"active": true},
+			},
+			want:     ` WHERE "tenant" = $1 AND (("role" = $2 AND ("team" = $3 OR ("team" = $4 AND level > $5))) OR "role" = $6) AND "active" = $7`,
+			wantArgs: []interface{}{7, "admin", "a", "b", 3, "owner", true},
+		},
+		{
+			name:      "empty groups",
+			whereArgs: []interface{}{WhereGroup(), Or()},
+			want:      ` WHERE TRUE AND FALSE`,
+			wantArgs:  []interface{}{},
+		},
+		{
+			name:      "raw fragment after a group",
+			whereArgs: []interface{}{Or(map[string]interface{}{"a": 1}, map[string]interface{}{"b": 2}), "c = $1", 3},
+			want:      ` WHERE ("a" = $1 OR "b" = $2) AND c = $3`,
+			wantArgs:  []interface{}{1, 2, 3},
+		},
+	})
+}
+
+func TestWhereExists(t *testing.T) {
+	runWhereTests(t, []whereTest{
+		{
+			name: "renumbered after map conditions",
+			whereArgs: []interface{}{
+				map[string]interface{}{"active": true},
+				WhereExists("SELECT 1 FROM orders o WHERE o.user_id = users.id AND o.total > $1 AND o.status = $2", 100, "paid"),
+			},
+			want:     ` WHERE "active" = $1 AND EXISTS (SELECT 1 FROM orders o WHERE o.user_id = users.id AND o.total > $2 AND o.status = $3)`,
+			wantArgs: []interface{}{true, 100, "paid"},
+		},
+		{
+			name:      "question mark placeholders",
+			whereArgs: []interface{}{WhereNotExists("SELECT 1 FROM bans WHERE bans.user_id = users.id AND bans.until > ?", "now")},
+			want:      ` WHERE NOT EXISTS (SELECT 1 FROM bans WHERE bans.user_id = users.id AND bans.until > $1)`,
+			wantArgs:  []interface{}{"now"},
+		},
+		{
+			name: "inside or and groups",
+			whereArgs: []interface{}{Or(
+				map[string]interface{}{"role": "admin"},
+				WhereGroup(WhereExists("SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total > $1", 5), map[string]interface{}{"active": true}),
+			)},
+			want:     ` WHERE ("role" = $1 OR (EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total > $2) AND "active" = $3))`,
+			wantArgs: []interface{}{"admin", 5, true},
+		},
+	})
+
+	if _, _, err := BuildWhere(WhereExists("SELECT 1 FROM orders WHERE total > $2", 1)); err == nil {
+		t.Error("WhereExists referencing a missing argument succeeded, want an error")
+	}
+}
+
+func TestWhereExistsCorrelated(t *testing.T) {
+	conn := newTestConnection(t)
+	users := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+	orders := newTestTable(t, conn, nil,
+		Column{Name: "user_id", DataType: *DataType{}.Integer()},
+		Column{Name: "total", DataType: *DataType{}.Integer()})
+
+	ids := map[string]interface{}{}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		row, err := users.Insert(map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		ids[name] = row["id"]
+	}
+	for _, order := range []map[string]interface{}{
+		{"user_id": ids["alice"], "total": 50},
+		{"user_id": ids["alice"], "total": 500},
+		{"user_id": ids["bob"], "total": 20},
+	} {
+		if _, err := orders.Insert(order); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	subquery := fmt.Sprintf("SELECT 1 FROM %s o WHERE o.user_id = %s.id AND o.total > $1", QuoteIdentifier(orders.Name), QuoteIdentifier(users.Name))
+	names := func(whereArgs ...interface{}) []string {
+		t.Helper()
+		rows, err := users.FetchMany(whereArgs...)
+		if err != nil {
+			t.Fatalf("FetchMany: %v", err)
+		}
+		var names []string
+		for _, row := range rows {
+			names = append(names, row["name"].(string))
+		}
+		sort.Strings(names)
+		return names
+	}
+	if got := names(WhereExists(subquery, 10)); !reflect.DeepEqual(got, []string{"alice", "bob"}) {
+		t.Errorf("users with an order = %v, want [alice bob]", got)
+	}
+	if got := names(WhereExists(subquery, 100)); !reflect.DeepEqual(got, []string{"alice"}) {
+		t.Errorf("users with an order over 100 = %v, want [alice]", got)
+	}
+	if got := names(map[string]interface{}{"name": Neq("alice")}, WhereNotExists(subquery, 10)); !reflect.DeepEqual(got, []string{"carol"}) {
+		t.Errorf("users without orders = %v, want [carol]", got)
+	}
+}
+
+func TestJsonbPathMatch(t *testing.T) {
+	type status string
+	city := []string{"address", "city"}
+	var tests []whereTest
+	for value, want := range map[interface{}]string{
+		"Dhaka":          "Dhaka",
+		status("active"): "active",
+		true:             "true",
+		42:               "42",
+		int64(-7):        "-7",
+		uint8(200):       "200",
+		1.5:              "1.5",
+		float32(0.1):     "0.1",
+		1e21:             "1000000000000000000000",
+	} {
+		tests = append(tests, whereTest{
+			name:      fmt.Sprintf("%T %v", value, value),
+			whereArgs: []interface{}{map[string]interface{}{"profile": JsonbPathMatch("address.city", value)}},
+			want:      ` WHERE "profile" #>> $1 = $2`,
+			wantArgs:  []interface{}{city, want},
+		})
+	}
+	runWhereTests(t, tests)
+
+	for _, value := range []interface{}{nil, []string{"Dhaka"}, map[string]interface{}{"a": 1}, struct{}{}, math.NaN(), math.Inf(1)} {
+		if _, _, err := BuildWhere(map[string]interface{}{"profile": JsonbPathMatch("address.city", value)}); err == nil {
+			t.Errorf("JsonbPathMatch with %#v succeeded, want an error", value)
+		}
+	}
+	if _, _, err := BuildWhere(map[string]interface{}{"profile": JsonbPathMatch("deleted_at", nil)}); err == nil ||
+		!strings.Contains(err.Error(), "JSONPath(path, IsNull())") {
+		t.Errorf("JsonbPathMatch with nil: err = %v, want it to point to JSONPath(path, IsNull())", err)
+	}
+}
+
+func TestRawConditionJsonbOperators(t *testing.T) {
+	runWhereTests(t, []whereTest{
+		{
+			name:      "? without arguments",
+			whereArgs: []interface{}{map[string]interface{}{"active": true}, RawCondition("data ? 'tags'")},
+			want:      ` WHERE "active" = $1 AND (data ? 'tags')`,
+			wantArgs:  []interface{}{true},
+		},
+		{
+			name:      "?| with a ? placeholder",
+			whereArgs: []interface{}{RawCondition("data ?| ?", []string{"a", "b"})},
+			want:      ` WHERE (data ?| $1)`,
+			wantArgs:  []interface{}{[]string{"a", "b"}},
+		},
+		{
+			name:      "? with numbered placeholders",
+			whereArgs: []interface{}{map[string]interface{}{"active": true}, RawCondition("data ? 'tags' AND data ?& $1", []string{"a"})},
+			want:      ` WHERE "active" = $1 AND (data ? 'tags' AND data ?& $2)`,
+			wantArgs:  []interface{}{true, []string{"a"}},
+		},
+		{
+			name:      "exists with ?",
+			whereArgs: []interface{}{WhereExists("SELECT 1 FROM events e WHERE e.payload ? 'user_id'")},
+			want:      ` WHERE EXISTS (SELECT 1 FROM events e WHERE e.payload ? 'user_id')`,
+			wantArgs:  []interface{}{},
+		},
+	})
+}
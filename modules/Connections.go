@@ -3,77 +3,293 @@ package modules
 import (
 	"context"
 	"fmt"
-	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // DatabaseConnection manages the connection pool to the PostgreSQL database.
-// It handles connection configuration, initialization, and automatic reconnection monitoring.
+// It handles connection configuration, initialization, and health monitoring with optional reconnection.
 type DatabaseConnection struct {
 	// DB_URL is the connection string for the database.
 	DB_URL string
 	// MAX_CONNECTIONS is the maximum number of connections allowed in the pool.
 	MAX_CONNECTIONS int
-	// RECONNECT enables or disables the background reconnection monitor.
+	// MinConns is the minimum number of connections kept open. Defaults to MAX_CONNECTIONS / 4 when zero.
+	MinConns int
+	// MaxConnLifetime is how long a connection may live before it is closed and replaced. Zero uses the pgx default (1 hour).
+	MaxConnLifetime time.Duration
+	// MaxConnIdleTime is how long an idle connection is kept before it is closed. Zero uses the pgx default (30 minutes).
+	MaxConnIdleTime time.Duration
+	// HealthCheckPeriod is how often idle connections are health checked. Zero uses the pgx default (1 minute).
+	HealthCheckPeriod time.Duration
+	// HealthCheckInterval is how often StartDbConnectionChecker pings the database. Defaults to 30 seconds when zero.
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout bounds each health check ping. Defaults to 3 seconds when zero.
+	HealthCheckTimeout time.Duration
+	// ReplicaURL is an optional connection string for a read replica. Tables with UseReplica set
+	// send their reads to it, falling back to the primary if it is unavailable.
+	ReplicaURL string
+	// ReplicaMaxConnections is the maximum number of replica connections. Defaults to MAX_CONNECTIONS when zero.
+	ReplicaMaxConnections int
+	// ReconnectBaseDelay is the delay before the second health check after a failed one; it doubles on each failure.
+	// Defaults to 1 second when zero.
+	ReconnectBaseDelay time.Duration
+	// ReconnectMaxDelay caps the delay between health checks while the database is unreachable. Defaults to 1 minute when zero.
+	ReconnectMaxDelay time.Duration
+	// RECONNECT makes StartDbConnectionChecker replace the primary pool when a health check fails, once a
+	// new pool reaches the database. Without it the checker only reports health (see State).
+	// NewDatabaseConnection starts the checker when it is set.
 	RECONNECT bool
 	// SavedPoolDbConnection holds the active pgx connection pool.
 	SavedPoolDbConnection *pgxpool.Pool
+	// ReplicaPool holds the read replica pool, if ReplicaURL is set and the replica was reachable.
+	ReplicaPool *pgxpool.Pool
 	// ReconnectionCheckRunning indicates if the reconnection monitor is currently active.
 	ReconnectionCheckRunning bool
+	// Logger receives connection diagnostics. Defaults to a no-op logger when nil.
+	Logger Logger
+	// QueryHooks run before and after every statement executed by tables using this connection.
+	QueryHooks []QueryHook
+
+	// stopChecker signals the reconnection monitor started by StartDbConnectionChecker to exit.
+	stopChecker chan struct{}
+	// state is the connection state reported by State, shared with the reconnection monitor.
+	state *atomic.Value
+	// closed is set by Close and prevents the pool from being lazily re-created. Guarded by poolMu.
+	closed bool
+	// listeners tracks the goroutines started by Listen.
+	listeners *listenerRegistry
+	// advisoryLocks tracks the connections pinned by session-level advisory locks.
+	advisoryLocks *advisoryLockRegistry
 }
 
+// poolMu guards the pool fields (SavedPoolDbConnection, ReplicaPool and closed) of every DatabaseConnection,
+// which request goroutines, the health checker and Close access concurrently.
+// DatabaseConnection is copied by value (e.g. into Table), so it cannot hold the mutex itself.
+var poolMu sync.RWMutex
+
 // ConnectDb initializes the database connection pool using the configured settings.
 // It parses the DB_URL, sets the maximum and minimum connections, and establishes the pool.
+// If the pool is already open it is returned unchanged; after Close, ConnectDb opens a new one.
+// The pool connects lazily, so an unreachable database is only reported by the first query (or Healthy).
 // Returns the created pgxpool.Pool or an error if connection fails.
 func (conf *DatabaseConnection) ConnectDb() (*pgxpool.Pool, error) {
+	return conf.connect(true)
+}
+
+// connect is ConnectDb. If reopen is false, it fails instead of opening a pool after Close.
+func (conf *DatabaseConnection) connect(reopen bool) (*pgxpool.Pool, error) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if conf.closed && !reopen {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+	if conf.SavedPoolDbConnection != nil && !conf.closed {
+		return conf.SavedPoolDbConnection, nil
+	}
+
 	ctx := context.Background()
+	poolConnection, err := conf.newPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conf.SavedPoolDbConnection = poolConnection
+	conf.closed = false
+	conf.initListeners()
+	conf.listeners.mu.Lock()
+	conf.listeners.closed = false
+	conf.listeners.mu.Unlock()
+	conf.initAdvisoryLocks()
+	conf.advisoryLocks.mu.Lock()
+	conf.advisoryLocks.closed = false
+	conf.advisoryLocks.mu.Unlock()
+
+	if conf.ReplicaURL != "" {
+		// A missing replica is not fatal: reads fall back to the primary
+		if err := conf.connectReplica(ctx); err != nil {
+			conf.logger().Warnf("Failed to connect to read replica, reads will use the primary: %v", err)
+		}
+	}
+	return poolConnection, nil
+}
+
+// newPool opens a primary pool from DB_URL and the pool settings, tracking its connections for Close.
+func (conf *DatabaseConnection) newPool(ctx context.Context) (*pgxpool.Pool, error) {
 	// Use pgxpool instead of pgx.Connect
 	poolConfig, err := pgxpool.ParseConfig(conf.DB_URL)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("Connecting to database at %s with max %d connections...\n", conf.DB_URL, conf.MAX_CONNECTIONS)
+	conf.logger().Infof("Connecting to database %s with max %d connections...", poolConfig.ConnConfig.Database, conf.MAX_CONNECTIONS)
 
 	poolConfig.MaxConns = int32(conf.MAX_CONNECTIONS)
 	poolConfig.MinConns = int32(conf.MAX_CONNECTIONS / 4)
+	if conf.MinConns > 0 {
+		poolConfig.MinConns = int32(conf.MinConns)
+	}
+	if conf.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = conf.MaxConnLifetime
+	}
+	if conf.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = conf.MaxConnIdleTime
+	}
+	if conf.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = conf.HealthCheckPeriod
+	}
 
-	poolConnection, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	conns := trackConns(poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, err
 	}
-	conf.SavedPoolDbConnection = poolConnection
-	return poolConnection, nil
+	poolConns.Store(pool, conns)
+	return pool, nil
+}
+
+// reconnect replaces the primary pool with a new one if the new pool reaches the database within
+// HealthCheckTimeout. The old pool is closed in the background once its in-flight queries release their
+// connections. If the new pool cannot reach the database, or Close was called, the old pool is kept.
+func (conf *DatabaseConnection) reconnect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), conf.healthCheckTimeout())
+	defer cancel()
+	pool, err := conf.newPool(ctx)
+	if err != nil {
+		return err
+	}
+	if err := pingPool(ctx, pool); err != nil {
+		closePool(pool)
+		return err
+	}
+
+	poolMu.Lock()
+	if conf.closed {
+		poolMu.Unlock()
+		closePool(pool)
+		return fmt.Errorf("database connection is closed")
+	}
+	old := conf.SavedPoolDbConnection
+	conf.SavedPoolDbConnection = pool
+	poolMu.Unlock()
+
+	if old != nil {
+		go closePool(old)
+	}
+	return nil
+}
+
+// closePool closes a pool opened by newPool, waiting for its acquired connections to be released.
+func closePool(pool *pgxpool.Pool) {
+	pool.Close()
+	poolConns.Delete(pool)
 }
 
-// reconnectDb attempts to re-establish the database connection.
-// It calls ConnectDb internally.
-func (conf *DatabaseConnection) reconnectDb() (bool, error) {
-	var err error
-	success, err := conf.ConnectDb()
+// connectReplica initializes the read replica pool from ReplicaURL.
+func (conf *DatabaseConnection) connectReplica(ctx context.Context) error {
+	poolConfig, err := pgxpool.ParseConfig(conf.ReplicaURL)
+	if err != nil {
+		return err
+	}
+	maxConns := conf.ReplicaMaxConnections
+	if maxConns <= 0 {
+		maxConns = conf.MAX_CONNECTIONS
+	}
+	conf.logger().Infof("Connecting to read replica %s with max %d connections...", poolConfig.ConnConfig.Database, maxConns)
+	poolConfig.MaxConns = int32(maxConns)
+
+	conns := trackConns(poolConfig)
+	replicaPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		return false, err
+		return err
+	}
+	poolConns.Store(replicaPool, conns)
+	if conf.ReplicaPool != nil {
+		conf.ReplicaPool.Close()
+	}
+	conf.ReplicaPool = replicaPool
+	return nil
+}
+
+// connSet is the set of open connections of a pool, kept so Close can close them forcibly.
+type connSet struct {
+	mu    sync.Mutex
+	conns map[*pgx.Conn]struct{}
+}
+
+// poolConns maps each pool opened by ConnectDb to its connSet.
+var poolConns sync.Map // *pgxpool.Pool -> *connSet
+
+// trackConns installs pool hooks that record the pool's open connections, and returns their set.
+func trackConns(poolConfig *pgxpool.Config) *connSet {
+	set := &connSet{conns: make(map[*pgx.Conn]struct{})}
+	afterConnect := poolConfig.AfterConnect
+	poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		if afterConnect != nil {
+			if err := afterConnect(ctx, conn); err != nil {
+				return err
+			}
+		}
+		set.mu.Lock()
+		set.conns[conn] = struct{}{}
+		set.mu.Unlock()
+		return nil
+	}
+	beforeClose := poolConfig.BeforeClose
+	poolConfig.BeforeClose = func(conn *pgx.Conn) {
+		set.mu.Lock()
+		delete(set.conns, conn)
+		set.mu.Unlock()
+		if beforeClose != nil {
+			beforeClose(conn)
+		}
+	}
+	return set
+}
+
+// closeNetConns closes the network connections of the pool's open connections. Queries running on them
+// fail at once, so their callers release them and pgxpool.Close can finish.
+func closeNetConns(pool *pgxpool.Pool) int {
+	value, ok := poolConns.Load(pool)
+	if !ok {
+		return 0
 	}
-	return success != nil, nil
+	set := value.(*connSet)
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for conn := range set.conns {
+		_ = conn.PgConn().Conn().Close()
+	}
+	return len(set.conns)
 }
 
 // isAlive checks if the current database connection is active and responsive.
-// It executes a simple ping to verify connectivity.
+// It executes a simple ping, bounded by HealthCheckTimeout, to verify connectivity.
 func (conf *DatabaseConnection) isAlive() bool {
-	return conf.SavedPoolDbConnection != nil
+	ctx, cancel := context.WithTimeout(context.Background(), conf.healthCheckTimeout())
+	defer cancel()
+	return conf.ping(ctx) == nil
 }
 
 func (conf *DatabaseConnection) getPool() (*pgxpool.Pool, error) {
-	if conf.SavedPoolDbConnection == nil {
-		poolConnection, err := conf.ConnectDb()
+	poolMu.RLock()
+	pool, closed := conf.SavedPoolDbConnection, conf.closed
+	poolMu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+	if pool == nil {
+		poolConnection, err := conf.connect(false)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to database: %v", err)
 		}
 		return poolConnection, nil
 	}
-	return conf.SavedPoolDbConnection, nil
+	return pool, nil
 }
 
 func (conf *DatabaseConnection) GetConnection() (*pgxpool.Conn, error) {
@@ -84,46 +300,393 @@ func (conf *DatabaseConnection) GetConnection() (*pgxpool.Conn, error) {
 	return pool.Acquire(context.Background())
 }
 
+// PoolStats is a snapshot of the connection pool's usage.
+type PoolStats struct {
+	// TotalConns is the number of connections currently open (idle, acquired or being established).
+	TotalConns int32
+	// IdleConns is the number of open connections not in use.
+	IdleConns int32
+	// AcquiredConns is the number of connections currently in use.
+	AcquiredConns int32
+	// ConstructingConns is the number of connections currently being established.
+	ConstructingConns int32
+	// MaxConns is the maximum size of the pool.
+	MaxConns int32
+	// NewConnsCount is the cumulative number of connections opened.
+	NewConnsCount int64
+	// MaxLifetimeCancelCount is the cumulative number of connections closed for exceeding MaxConnLifetime.
+	MaxLifetimeCancelCount int64
+	// MaxIdleDestroyCount is the cumulative number of connections closed for exceeding MaxConnIdleTime.
+	MaxIdleDestroyCount int64
+	// AcquireCount is the cumulative number of successful acquires.
+	AcquireCount int64
+	// CanceledAcquireCount is the cumulative number of acquires canceled by their context, e.g. timeouts
+	// while the pool was exhausted.
+	CanceledAcquireCount int64
+	// EmptyAcquireCount is the cumulative number of acquires that had to wait for a connection.
+	EmptyAcquireCount int64
+	// AcquireDuration is the total time spent waiting to acquire connections.
+	AcquireDuration time.Duration
+	// EmptyAcquireWaitTime is the total time spent by acquires that had to wait for a connection.
+	// Divided by EmptyAcquireCount it gives the average wait when the pool is saturated.
+	EmptyAcquireWaitTime time.Duration
+}
+
+// PoolStats returns the current usage of the connection pool, e.g. for exporting metrics.
+// It is a copy of all the pgxpool.Stat counters, so callers do not depend on pgxpool.
+// All fields are zero if the pool has not been initialized.
+//
+// Example:
+//
+//	stats := db.PoolStats()
+//	log.Printf("pool: %d/%d in use, %d idle", stats.AcquiredConns, stats.MaxConns, stats.IdleConns)
+func (conf *DatabaseConnection) PoolStats() PoolStats {
+	poolMu.RLock()
+	pool := conf.SavedPoolDbConnection
+	poolMu.RUnlock()
+	if pool == nil {
+		return PoolStats{}
+	}
+	stat := pool.Stat()
+	return PoolStats{
+		TotalConns:             stat.TotalConns(),
+		IdleConns:              stat.IdleConns(),
+		AcquiredConns:          stat.AcquiredConns(),
+		ConstructingConns:      stat.ConstructingConns(),
+		MaxConns:               stat.MaxConns(),
+		NewConnsCount:          stat.NewConnsCount(),
+		MaxLifetimeCancelCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:    stat.MaxIdleDestroyCount(),
+		AcquireCount:           stat.AcquireCount(),
+		CanceledAcquireCount:   stat.CanceledAcquireCount(),
+		EmptyAcquireCount:      stat.EmptyAcquireCount(),
+		AcquireDuration:        stat.AcquireDuration(),
+		EmptyAcquireWaitTime:   stat.EmptyAcquireWaitTime(),
+	}
+}
+
+// IsHealthy reports whether the pool has open connections and is not exhausted.
+func (conf *DatabaseConnection) IsHealthy() bool {
+	stats := conf.PoolStats()
+	return stats.AcquiredConns < stats.MaxConns && stats.TotalConns > 0
+}
+
+// GetReplicaConnection acquires a connection from the read replica pool.
+// Returns an error if no replica is configured or the connection cannot be acquired.
+func (conf *DatabaseConnection) GetReplicaConnection() (*pgxpool.Conn, error) {
+	poolMu.RLock()
+	replica, closed := conf.ReplicaPool, conf.closed
+	poolMu.RUnlock()
+	if closed {
+		return nil, fmt.Errorf("database connection is closed")
+	}
+	if replica == nil {
+		return nil, fmt.Errorf("no read replica configured")
+	}
+	return replica.Acquire(context.Background())
+}
+
 func (conf *DatabaseConnection) showStats() {
-	if conf.SavedPoolDbConnection == nil {
-		log.Println("ERROR: Connection pool is not initialized.")
+	stats := conf.PoolStats()
+	if stats.MaxConns == 0 {
+		conf.logger().Errorf("Connection pool is not initialized.")
 		return
 	}
-	totalConnections := conf.SavedPoolDbConnection.Stat().TotalConns()
-	activeConnections := conf.SavedPoolDbConnection.Stat().TotalConns() - conf.SavedPoolDbConnection.Stat().IdleConns()
-	idleConnections := conf.SavedPoolDbConnection.Stat().IdleConns()
 
-	log.Printf("DEBUG: Total connections: %d, Active connections: %d, Idle connections: %d\n", totalConnections, activeConnections, idleConnections)
+	conf.logger().Debugf("Total connections: %d, Active connections: %d, Idle connections: %d", stats.TotalConns, stats.AcquiredConns, stats.IdleConns)
+}
+
+// HealthResult is the outcome of a single database health check.
+type HealthResult struct {
+	// Latency is how long it took to acquire a connection and ping the database.
+	Latency time.Duration
+	// Error is nil if the database responded in time.
+	Error error
+	// LastCheck is when the check started.
+	LastCheck time.Time
 }
 
-func (conf *DatabaseConnection) CheckDbConnection() (bool, error) {
-	if !conf.isAlive() {
-		return false, fmt.Errorf("connection pool is not alive")
+// CheckDbConnection pings the database using a pooled connection, bounded by HealthCheckTimeout,
+// and reports the latency. It only reports the result; reconnection is handled by StartDbConnectionChecker
+// (see RECONNECT).
+//
+// Example:
+//
+//	result := db.CheckDbConnection()
+//	if result.Error != nil {
+//	    log.Println("database unhealthy:", result.Error)
+//	}
+//	log.Printf("database ping took %v", result.Latency)
+func (conf *DatabaseConnection) CheckDbConnection() HealthResult {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), conf.healthCheckTimeout())
+	defer cancel()
+
+	err := conf.ping(ctx)
+	return HealthResult{Latency: time.Since(start), Error: err, LastCheck: start}
+}
+
+// Healthy checks that the database is reachable by acquiring a connection and pinging it.
+// If ctx has no deadline, the check is bounded by HealthCheckTimeout.
+// Returns nil if the database is healthy, or an error describing the failure.
+//
+// Example (Kubernetes readiness probe):
+//
+//	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+//	    if err := db.Healthy(r.Context()); err != nil {
+//	        http.Error(w, err.Error(), http.StatusServiceUnavailable)
+//	        return
+//	    }
+//	    w.WriteHeader(http.StatusOK)
+//	})
+func (conf *DatabaseConnection) Healthy(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.healthCheckTimeout())
+		defer cancel()
+	}
+	if err := conf.ping(ctx); err != nil {
+		return fmt.Errorf("database is not healthy: %w", err)
+	}
+	return nil
+}
+
+// ping acquires a connection from the pool and pings the database within ctx.
+func (conf *DatabaseConnection) ping(ctx context.Context) error {
+	poolMu.RLock()
+	pool, closed := conf.SavedPoolDbConnection, conf.closed
+	poolMu.RUnlock()
+	if closed {
+		return fmt.Errorf("database connection is closed")
+	}
+	if pool == nil {
+		return fmt.Errorf("connection pool is not initialized")
 	}
-	// Removed debug print for performance
+	return pingPool(ctx, pool)
+}
 
+// pingPool acquires a connection from pool and pings the database within ctx.
+func pingPool(ctx context.Context, pool *pgxpool.Pool) error {
 	// Get a connection from the pool to test it
-	conn, err := conf.GetConnection()
+	conn, err := pool.Acquire(ctx)
 	if err != nil {
-		return false, err
+		return fmt.Errorf("failed to acquire connection: %w", err)
 	}
 	defer conn.Release()
 
-	err = conn.Ping(context.Background())
-	if err != nil {
-		conf.reconnectDb()
-		return false, err
+	if err := conn.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// healthCheckTimeout returns HealthCheckTimeout, defaulting to 3 seconds.
+func (conf *DatabaseConnection) healthCheckTimeout() time.Duration {
+	if conf.HealthCheckTimeout > 0 {
+		return conf.HealthCheckTimeout
+	}
+	return 3 * time.Second
+}
+
+// healthCheckInterval returns HealthCheckInterval, defaulting to 30 seconds.
+func (conf *DatabaseConnection) healthCheckInterval() time.Duration {
+	if conf.HealthCheckInterval > 0 {
+		return conf.HealthCheckInterval
 	}
-	return true, nil
+	return 30 * time.Second
 }
 
-// StartDbConnectionChecker starts a goroutine that checks the DB connection every 5 seconds.
+// ConnectionState describes the health of a DatabaseConnection as seen by the reconnection monitor.
+type ConnectionState string
+
+const (
+	// ConnectionStateDisconnected means the pool has not been initialized.
+	ConnectionStateDisconnected ConnectionState = "disconnected"
+	// ConnectionStateConnected means the pool is initialized and the last health check succeeded.
+	ConnectionStateConnected ConnectionState = "connected"
+	// ConnectionStateReconnecting means the last health check failed and the monitor is checking again with backoff.
+	ConnectionStateReconnecting ConnectionState = "reconnecting"
+	// ConnectionStateClosed means Close has been called.
+	ConnectionStateClosed ConnectionState = "closed"
+)
+
+// State returns the current connection state.
+// While the reconnection monitor is running it reflects the last health check;
+// otherwise it only reports whether the pool is initialized or closed.
+func (conf *DatabaseConnection) State() ConnectionState {
+	poolMu.RLock()
+	pool, closed := conf.SavedPoolDbConnection, conf.closed
+	poolMu.RUnlock()
+	if closed {
+		return ConnectionStateClosed
+	}
+	if conf.state != nil {
+		if state, ok := conf.state.Load().(ConnectionState); ok {
+			return state
+		}
+	}
+	if pool == nil {
+		return ConnectionStateDisconnected
+	}
+	return ConnectionStateConnected
+}
+
+// reconnectDelay returns how long to wait after the given number of consecutive failed attempts:
+// exponential backoff from ReconnectBaseDelay, capped at ReconnectMaxDelay, with jitter
+// so that many instances do not reconnect in lockstep.
+func (conf *DatabaseConnection) reconnectDelay(attempt int) time.Duration {
+	base := conf.ReconnectBaseDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	maxDelay := conf.ReconnectMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+
+	delay := maxDelay
+	if attempt < 32 && base<<attempt > 0 && base<<attempt < maxDelay {
+		delay = base << attempt
+	}
+	// Equal jitter: wait between half and the full delay
+	half := delay / 2
+	return half + time.Duration(rand.Int64N(int64(half)+1))
+}
+
+// StartDbConnectionChecker starts a goroutine that checks the DB connection every HealthCheckInterval.
+// When a check fails it checks again with exponential backoff (see ReconnectBaseDelay and ReconnectMaxDelay)
+// until a check succeeds. The current state is available from State.
+//
+// If RECONNECT is set, a failed check also opens a new primary pool and, once that pool reaches the database,
+// swaps it in: later queries use the new pool while the old one closes after its in-flight queries.
+// This recovers from failures the pool does not recover from on its own, such as a failover to a new host.
+// Without RECONNECT the checker only reports health; the pool still replaces broken connections when they
+// are next acquired. The read replica pool is never replaced. The goroutine runs until Close is called.
 func (conf *DatabaseConnection) StartDbConnectionChecker() {
+	if conf.ReconnectionCheckRunning {
+		return
+	}
+	stop := make(chan struct{})
+	conf.stopChecker = stop
+	state := &atomic.Value{}
+	state.Store(conf.State())
+	conf.state = state
+
 	go func() {
+		attempt := 0
 		for {
-			conf.CheckDbConnection()
-			time.Sleep(5 * time.Second)
+			wait := conf.healthCheckInterval()
+			err := conf.CheckDbConnection().Error
+			if err != nil && conf.RECONNECT {
+				if reconnectErr := conf.reconnect(); reconnectErr == nil {
+					conf.logger().Infof("Database connection check failed, replaced the connection pool: %v", err)
+					err = nil
+				} else {
+					err = fmt.Errorf("%w (reconnecting failed: %v)", err, reconnectErr)
+				}
+			}
+			if err == nil {
+				if attempt > 0 {
+					conf.logger().Infof("Database connection restored after %d attempt(s)", attempt)
+				}
+				attempt = 0
+				state.Store(ConnectionStateConnected)
+			} else {
+				state.Store(ConnectionStateReconnecting)
+				wait = conf.reconnectDelay(attempt)
+				attempt++
+				conf.logger().Warnf("Database connection check failed (attempt %d), next check in %v: %v", attempt, wait, err)
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
 		}
 	}()
 	conf.ReconnectionCheckRunning = true
 }
+
+// Close shuts down the connection gracefully: it stops the reconnection monitor and any listeners, releases
+// the session-level advisory locks, rejects new queries and closes the pools once in-flight queries have
+// released their connections.
+// The connection is unusable afterwards; call ConnectDb to open it again. Calling Close more than once is safe.
+//
+// If ctx expires before the pools drain, the remaining connections are closed forcibly: the queries
+// still running on them fail, and Close returns ctx.Err() (e.g. context.DeadlineExceeded).
+// The pools finish closing in the background as soon as those queries have released their connections;
+// a connection held by code that never releases it (e.g. a transaction left open) keeps them open.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := db.Close(ctx); err != nil {
+//	    log.Println("database did not drain in time:", err)
+//	}
+func (conf *DatabaseConnection) Close(ctx context.Context) error {
+	if conf.stopChecker != nil {
+		close(conf.stopChecker)
+		conf.stopChecker = nil
+	}
+	conf.ReconnectionCheckRunning = false
+	if conf.state != nil {
+		conf.state.Store(ConnectionStateClosed)
+	}
+	conf.stopListeners()
+	conf.releaseAdvisoryLocks()
+
+	var pools []*pgxpool.Pool
+	poolMu.Lock()
+	if conf.SavedPoolDbConnection != nil {
+		pools = append(pools, conf.SavedPoolDbConnection)
+		conf.SavedPoolDbConnection = nil
+	}
+	if conf.ReplicaPool != nil {
+		pools = append(pools, conf.ReplicaPool)
+		conf.ReplicaPool = nil
+	}
+	conf.closed = true
+	poolMu.Unlock()
+	if len(pools) == 0 {
+		return nil
+	}
+
+	conf.logger().Infof("Closing database connection pool...")
+	drained := make(chan struct{})
+	go func() {
+		// pgxpool.Close rejects new acquires and blocks until acquired connections are released
+		for _, pool := range pools {
+			pool.Close()
+			poolConns.Delete(pool)
+		}
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		closedConns := 0
+		for _, pool := range pools {
+			closedConns += closeNetConns(pool)
+		}
+		conf.logger().Warnf("Timed out waiting for in-flight queries before closing the pool, closed %d connection(s) forcibly: %v", closedConns, ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// Shutdown closes the connection like Close, waiting at most timeout for in-flight queries to finish.
+//
+// Example:
+//
+//	db := pggo.NewDatabaseConnection(dbURL, 20, true)
+//	defer db.Shutdown(10 * time.Second)
+func (conf *DatabaseConnection) Shutdown(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return conf.Close(ctx)
+}
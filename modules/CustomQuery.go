@@ -3,7 +3,9 @@ package modules
 import (
 	"context"
 	"fmt"
-	"log"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
 // Queue executes a custom raw SQL query against the database.
@@ -27,29 +29,215 @@ import (
 //	    log.Println("Error executing custom query:", err)
 //	}
 func (t *Table) Queue(query string, params ...interface{}) ([]map[string]interface{}, error) {
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	return t.queryRows(t.context(), "Queue", query, params)
+}
+
+// ExecRaw executes a raw SQL statement that returns no rows, such as an UPDATE or DELETE without
+// RETURNING, DDL or a maintenance command (VACUUM, REINDEX), and returns the number of rows affected.
+//
+// Safety Note: as with Queue, pass user-provided values as parameters ($1, $2, etc.),
+// never concatenated into the statement.
+//
+// The cache is not updated; call it on tables whose rows the statement does not change,
+// or clear the cache afterwards.
+//
+// Example:
+//
+//	affected, err := UsersTable.ExecRaw(ctx, "UPDATE users SET active = false WHERE last_login < $1", cutoff)
+func (t *Table) ExecRaw(ctx context.Context, query string, params ...interface{}) (int64, error) {
+	affected, err := t.execSQL(ctx, "ExecRaw", query, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute ExecRaw: %w", err)
+	}
+	t.debugf("ExecRaw affected %d rows", affected)
+	return affected, nil
+}
+
+// Exec executes a SQL statement that returns no rows and is not tied to a table, such as
+// CREATE EXTENSION or a schema change, and returns the number of rows affected.
+// Values must be passed as parameters ($1, $2, etc.). The connection's QueryHooks run around it
+// and the statement is logged at debug level to the connection's Logger.
+//
+// Example:
+//
+//	_, err := db.Exec(ctx, "CREATE EXTENSION IF NOT EXISTS pg_trgm")
+func (conf *DatabaseConnection) Exec(ctx context.Context, query string, params ...interface{}) (int64, error) {
+	pool, err := conf.getPool()
+	if err != nil {
+		return 0, err
+	}
+	conf.logger().Debugf("Executing Exec with SQL: %s Params: %v", query, params)
+
+	ctx, event := conf.beforeQuery(ctx, "Exec", query, params)
+	tag, err := pool.Exec(ctx, query, params...)
+	conf.afterQuery(ctx, event, tag.RowsAffected(), err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	conf.logger().Debugf("Exec affected %d rows", tag.RowsAffected())
+	return tag.RowsAffected(), nil
+}
+
+// fromKeywordPattern matches the FROM keyword.
+var fromKeywordPattern = regexp.MustCompile(`(?i)\bFROM\b`)
+
+// FetchScalar evaluates a SQL expression over the table and returns the first column of the first row,
+// e.g. an aggregate:
+//
+//	maxPrice, err := ProductsTable.FetchScalar(ctx, "MAX(price)")             // SELECT MAX(price) FROM "products"
+//	count, err := ProductsTable.FetchScalar(ctx, "COUNT(*) FROM products WHERE price > $1", 10)
+//	version, err := ProductsTable.FetchScalar(ctx, "SELECT version()")
+//
+// The expression is selected FROM the table, unless it has its own FROM clause or is a complete
+// SELECT or WITH statement, in which case it is run as is. A NULL result is returned as nil.
+// Returns an error if the query returns no rows.
+//
+// Safety Note: the expression is raw SQL. Pass user-provided values as parameters ($1, $2, etc.).
+func (t *Table) FetchScalar(ctx context.Context, expression string, params ...interface{}) (interface{}, error) {
+	expression = strings.TrimSpace(expression)
+	query := "SELECT " + expression
+	if isCompleteQuery(expression) {
+		query = expression
+	} else if !hasFromClause(expression) {
+		query += " FROM " + QuoteIdentifier(t.Name)
+	}
+
+	conn, err := t.acquireConn(false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to acquire connection: %w", err)
 	}
-	defer conn.Release() // Release connection back to pool when done
+	defer conn.Release()
 
-	if t.DebugMode {
-		log.Println("DEBUG: Executing Custom Query:", query, "Params:", params)
+	t.debugf("Executing FetchScalar with SQL: %s Params: %v", query, params)
+	ctx, event := t.beforeQuery(ctx, "FetchScalar", query, params)
+	value, err := scanScalar(ctx, conn, query, params)
+	t.afterQuery(ctx, event, scalarRowCount(err), err)
+	return value, err
+}
+
+// FetchScalar runs a query that is not tied to a table and returns the first column of the first row.
+// A NULL result is returned as nil. Returns an error if the query returns no rows.
+//
+// Example:
+//
+//	version, err := db.FetchScalar(ctx, "SELECT version()")
+func (conf *DatabaseConnection) FetchScalar(ctx context.Context, query string, params ...interface{}) (interface{}, error) {
+	pool, err := conf.getPool()
+	if err != nil {
+		return nil, err
+	}
+	conf.logger().Debugf("Executing FetchScalar with SQL: %s Params: %v", query, params)
+
+	ctx, event := conf.beforeQuery(ctx, "FetchScalar", query, params)
+	value, err := scanScalar(ctx, pool, query, params)
+	conf.afterQuery(ctx, event, scalarRowCount(err), err)
+	return value, err
+}
+
+// hasFromClause reports whether a FetchScalar expression has its own FROM clause, ignoring FROM inside
+// parentheses (e.g. extract(year FROM created_at)) and string literals.
+func hasFromClause(expression string) bool {
+	var topLevel strings.Builder
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(expression); i++ {
+		ch := expression[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0:
+			topLevel.WriteByte(ch)
+			continue
+		}
+		topLevel.WriteByte(' ')
 	}
+	return fromKeywordPattern.MatchString(topLevel.String())
+}
 
-	// Execute Query
-	rows, err := conn.Query(context.Background(), query, params...)
+// completeQueryPattern matches an expression starting with the SELECT or WITH keyword, followed by any whitespace.
+var completeQueryPattern = regexp.MustCompile(`(?i)^\s*(SELECT|WITH)\b`)
+
+// isCompleteQuery reports whether a FetchScalar expression is a complete statement.
+func isCompleteQuery(expression string) bool {
+	return completeQueryPattern.MatchString(expression)
+}
+
+// scanScalar runs a query on q and returns the first column of its first row.
+func scanScalar(ctx context.Context, q rowQuerier, query string, params []interface{}) (interface{}, error) {
+	rows, err := q.Query(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute FetchScalar: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to execute FetchScalar: %w", err)
+		}
+		return nil, fmt.Errorf("no rows found")
+	}
+	values, err := rows.Values()
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute custom query: %w", err)
+		return nil, fmt.Errorf("failed to read returned values: %w", err)
 	}
-	defer rows.Close() // Also close the rows when done
+	if len(values) == 0 {
+		return nil, fmt.Errorf("query returned no columns")
+	}
+	return values[0], nil
+}
 
-	// Fetch results
-	results, err := t.fetchRowsResult(rows)
+// scalarRowCount returns the row count reported to QueryHooks for a scalar query.
+func scalarRowCount(err error) int64 {
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rows: %w", err)
+		return 0
 	}
+	return 1
+}
+
+// ScalarTypeError is returned by FetchScalarAs when the value is not of the requested type.
+type ScalarTypeError struct {
+	// Value is the value returned by the query.
+	Value interface{}
+	// Type is the requested type.
+	Type reflect.Type
+}
+
+func (e *ScalarTypeError) Error() string {
+	return fmt.Sprintf("scalar value %v of type %T is not a %s", e.Value, e.Value, e.Type)
+}
 
-	return results, nil
+// FetchScalarAs is like Table.FetchScalar but returns the value as a T.
+//
+// T must match the type pgx decodes the column to, e.g. int64 for COUNT(*) and bigint columns,
+// int32 for integer, float64 for double precision, string for text and time.Time for timestamptz.
+// A NULL result returns the zero value of T; use a pointer type (e.g. *int32) to tell NULL apart.
+// Returns a *ScalarTypeError if the value has another type.
+//
+// Example:
+//
+//	total, err := pggo.FetchScalarAs[int64](ctx, OrdersTable, "COUNT(*)")
+func FetchScalarAs[T any](ctx context.Context, t *Table, expression string, params ...interface{}) (T, error) {
+	var zero T
+	value, err := t.FetchScalar(ctx, expression, params...)
+	if err != nil || value == nil {
+		return zero, err
+	}
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	target := reflect.TypeOf((*T)(nil)).Elem()
+	rv := reflect.ValueOf(value)
+	if target.Kind() == reflect.Ptr && rv.Type().AssignableTo(target.Elem()) {
+		ptr := reflect.New(target.Elem())
+		ptr.Elem().Set(rv)
+		return ptr.Interface().(T), nil
+	}
+	return zero, &ScalarTypeError{Value: value, Type: target}
 }
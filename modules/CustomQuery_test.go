@@ -0,0 +1,36 @@
+package modules
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsCompleteQuery(t *testing.T) {
+	for expression, want := range map[string]bool{
+		"SELECT version()":                       true,
+		"SELECT\nversion()":                      true,
+		"select\t1":                              true,
+		"  with x AS (SELECT 1) SELECT * FROM x": true,
+		"SELECT(1)":                              true,
+		"MAX(price)":                             false,
+		"selected_total":                         false,
+		"with_tax * 2":                           false,
+		"COUNT(*) FILTER (WHERE active)":         false,
+	} {
+		if got := isCompleteQuery(expression); got != want {
+			t.Errorf("isCompleteQuery(%q) = %v, want %v", expression, got, want)
+		}
+	}
+}
+
+func TestFetchScalarMultilineQuery(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil)
+	value, err := table.FetchScalar(context.Background(), "SELECT\n1 + 1")
+	if err != nil {
+		t.Fatalf("FetchScalar: %v", err)
+	}
+	if value != int32(2) {
+		t.Errorf("FetchScalar = %v (%T), want 2", value, value)
+	}
+}
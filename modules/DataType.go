@@ -17,6 +17,11 @@ type ColumnDef struct {
 	isPrimaryKey bool
 	Default      *string
 	Check        *string // CHECK constraint like exam
+
+	isIdentity      bool
+	generatedExpr   *string
+	generatedStored bool
+	comment         string
 }
 
 // String returns the complete SQL representation of the column definition,
@@ -25,14 +30,17 @@ func (cd *ColumnDef) String() string {
 	var parts []string
 
 	// Add the base type
-	if cd.Length != nil {
-		parts = append(parts, fmt.Sprintf("%s(%d)", cd.Type, *cd.Length))
-	} else if cd.Precision != nil && cd.Scale != nil {
-		parts = append(parts, fmt.Sprintf("%s(%d,%d)", cd.Type, *cd.Precision, *cd.Scale))
-	} else if cd.Precision != nil {
-		parts = append(parts, fmt.Sprintf("%s(%d)", cd.Type, *cd.Precision))
-	} else {
-		parts = append(parts, cd.Type)
+	parts = append(parts, cd.Type+cd.typeModifier())
+
+	// Add generation clause
+	if cd.isIdentity {
+		parts = append(parts, "GENERATED ALWAYS AS IDENTITY")
+	} else if cd.generatedExpr != nil {
+		generated := fmt.Sprintf("GENERATED ALWAYS AS (%s)", *cd.generatedExpr)
+		if cd.generatedStored {
+			generated += " STORED"
+		}
+		parts = append(parts, generated)
 	}
 
 	// Add constraints
@@ -55,6 +63,68 @@ func (cd *ColumnDef) String() string {
 	return strings.Join(parts, " ")
 }
 
+// typeModifier returns the length or precision/scale suffix of the type, e.g. "(255)" or "(10,2)".
+func (cd *ColumnDef) typeModifier() string {
+	if cd.Length != nil {
+		return fmt.Sprintf("(%d)", *cd.Length)
+	} else if cd.Precision != nil && cd.Scale != nil {
+		return fmt.Sprintf("(%d,%d)", *cd.Precision, *cd.Scale)
+	} else if cd.Precision != nil {
+		return fmt.Sprintf("(%d)", *cd.Precision)
+	}
+	return ""
+}
+
+// typeAliases maps type names accepted by PostgreSQL to the canonical names reported by format_type().
+var typeAliases = map[string]string{
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"int":         "integer",
+	"int4":        "integer",
+	"int2":        "smallint",
+	"int8":        "bigint",
+	"decimal":     "numeric",
+	"float4":      "real",
+	"float8":      "double precision",
+	"bool":        "boolean",
+	"varbit":      "bit varying",
+	"timestamp":   "timestamp without time zone",
+	"timestamptz": "timestamp with time zone",
+	"time":        "time without time zone",
+	"timetz":      "time with time zone",
+}
+
+// normalizedType returns the column type the way PostgreSQL's format_type() reports it,
+// so the declared type can be compared with the type of an existing column.
+// Examples: varchar(255) -> "character varying(255)", serial -> "integer", timestamptz -> "timestamp with time zone".
+func (cd *ColumnDef) normalizedType() string {
+	base := strings.ToLower(strings.TrimSpace(cd.castType()))
+	arraySuffix := ""
+	for strings.HasSuffix(base, "[]") {
+		base = strings.TrimSuffix(base, "[]")
+		arraySuffix += "[]"
+	}
+	if name, ok := typeAliases[base]; ok {
+		base = name
+	}
+	return base + cd.typeModifier() + arraySuffix
+}
+
+// castType returns the type name to use when casting a parameter to this column's type
+// (e.g. "$1::integer"). Pseudo-types like serial are mapped to their underlying integer type.
+func (cd *ColumnDef) castType() string {
+	switch strings.ToLower(cd.Type) {
+	case "serial":
+		return "integer"
+	case "bigserial":
+		return "bigint"
+	case "smallserial":
+		return "smallint"
+	}
+	return cd.Type
+}
+
 // NotNull adds the NOT NULL constraint to the column.
 func (cd *ColumnDef) NotNull() *ColumnDef {
 	cd.isNotNull = true
@@ -73,6 +143,42 @@ func (cd *ColumnDef) PrimaryKey() *ColumnDef {
 	return cd
 }
 
+// GeneratedIdentity makes the column an identity column (GENERATED ALWAYS AS IDENTITY),
+// the standard replacement for serial. The type defaults to integer if none is set.
+// Examples:
+//
+//	DataType.Integer().GeneratedIdentity().PrimaryKey() -> integer GENERATED ALWAYS AS IDENTITY PRIMARY KEY
+//	DataType.Bigint().GeneratedIdentity()               -> bigint GENERATED ALWAYS AS IDENTITY
+func (cd *ColumnDef) GeneratedIdentity() *ColumnDef {
+	if cd.Type == "" {
+		cd.Type = "integer"
+	}
+	cd.isIdentity = true
+	return cd
+}
+
+// GeneratedAs makes the column a generated (computed) column: GENERATED ALWAYS AS (expr).
+// If stored is true the value is computed on write and stored (STORED), which PostgreSQL
+// requires before version 18; otherwise it is computed on read.
+// The expression is emitted verbatim and must not contain untrusted input.
+// Example:
+//
+//	DataType.Numeric(10, 2).GeneratedAs("price * quantity", true) -> numeric(10,2) GENERATED ALWAYS AS (price * quantity) STORED
+func (cd *ColumnDef) GeneratedAs(expr string, stored bool) *ColumnDef {
+	cd.generatedExpr = &expr
+	cd.generatedStored = stored
+	return cd
+}
+
+// Comment sets a description for the column, stored with COMMENT ON COLUMN when the table is created.
+// Example:
+//
+//	DataType.Varchar(255).NotNull().Comment("Primary contact address")
+func (cd *ColumnDef) Comment(text string) *ColumnDef {
+	cd.comment = text
+	return cd
+}
+
 // DefaultValue sets the default value for the column.
 // It supports various types:
 // - string: Auto-quoted if it's a text type and not a keyword (NULL, CURRENT_TIMESTAMP) or function call.
@@ -82,6 +188,9 @@ func (cd *ColumnDef) PrimaryKey() *ColumnDef {
 //	.DefaultValue("user") -> DEFAULT 'user'
 //	.DefaultValue(true)   -> DEFAULT true
 //	.DefaultValue("CURRENT_TIMESTAMP") -> DEFAULT CURRENT_TIMESTAMP
+//
+// For SQL expressions (now(), gen_random_uuid(), CURRENT_DATE, ...) prefer DefaultRaw,
+// which never quotes, instead of relying on the auto-quoting rules.
 func (cd *ColumnDef) DefaultValue(value interface{}) *ColumnDef {
 	var strVal string
 	if v, ok := value.(string); ok {
@@ -96,7 +205,7 @@ func (cd *ColumnDef) DefaultValue(value interface{}) *ColumnDef {
 		if isQuotedType {
 			upperVal := strings.ToUpper(strVal)
 			if !strings.HasPrefix(strVal, "'") && upperVal != "NULL" && !strings.Contains(strVal, "(") && upperVal != "CURRENT_TIMESTAMP" {
-				strVal = fmt.Sprintf("'%s'", strVal)
+				strVal = quoteLiteral(strVal)
 			}
 		}
 	} else {
@@ -106,6 +215,19 @@ func (cd *ColumnDef) DefaultValue(value interface{}) *ColumnDef {
 	return cd
 }
 
+// DefaultRaw sets the default to a SQL expression, emitted verbatim without any quoting.
+// The expression must not contain untrusted input.
+// Examples:
+//
+//	.DefaultRaw("now()")             -> DEFAULT now()
+//	.DefaultRaw("gen_random_uuid()") -> DEFAULT gen_random_uuid()
+//	.DefaultRaw("CURRENT_DATE")      -> DEFAULT CURRENT_DATE
+//	.DefaultRaw("'{}'::jsonb")       -> DEFAULT '{}'::jsonb
+func (cd *ColumnDef) DefaultRaw(expr string) *ColumnDef {
+	cd.Default = &expr
+	return cd
+}
+
 func (cd *ColumnDef) CheckConstraint(constraint string) *ColumnDef {
 	// Set the CHECK constraint
 	cd.Check = &constraint
@@ -231,7 +353,9 @@ func (dt DataType) Bytea() *ColumnDef {
 	return &ColumnDef{Type: "bytea"}
 }
 
-// Array creates an ARRAY column of the specified base type.
+// Array creates an ARRAY column of the specified base type, e.g. Array("text") for text[].
+// Insert and update its values as Go slices ([]string, []int64, ...), and filter it with
+// ArrayContains, ArrayContainedBy, ArrayOverlap and ArrayLength.
 func (dt DataType) Array(baseType string) *ColumnDef {
 	return &ColumnDef{Type: baseType + "[]"}
 }
@@ -351,12 +475,12 @@ func (dt DataType) Daterange() *ColumnDef {
 	return &ColumnDef{Type: "daterange"}
 }
 
-// Enum creates a column with a custom ENUM type.
+// Enum creates a column with a custom ENUM type. The type must exist; see DatabaseConnection.CreateEnum.
 func (dt DataType) Enum(typeName string) *ColumnDef {
 	return &ColumnDef{Type: typeName}
 }
 
-// Domain creates a column with a custom DOMAIN type.
+// Domain creates a column with a custom DOMAIN type. The type must exist; see DatabaseConnection.CreateDomain.
 func (dt DataType) Domain(domainName string) *ColumnDef {
 	return &ColumnDef{Type: domainName}
 }
@@ -0,0 +1,56 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// domainBaseTypePattern matches a base type name with optional modifiers and array brackets,
+// e.g. text, varchar(255), numeric(10, 2), double precision, integer[].
+var domainBaseTypePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_ ]*(\(\s*\d+\s*(,\s*\d+\s*)?\))?(\[\])*$`)
+
+// CreateDomain creates a DOMAIN type: baseType restricted by a CHECK constraint, for use with DataType.Domain.
+// The constraint refers to the checked value as VALUE; leave it empty for a domain without a CHECK.
+// Nothing is done if a type with that name already exists.
+//
+// PostgreSQL has no CREATE DOMAIN ... IF NOT EXISTS, so the statement is wrapped in a DO block
+// that ignores the duplicate_object error. The constraint is raw SQL and must not contain untrusted input.
+//
+// Example:
+//
+//	err := db.CreateDomain(ctx, "email_address", "text", "VALUE ~ '^[^@]+@[^@]+$'")
+//	UsersTable := pggo.Table{
+//	    Name: "users",
+//	    Columns: []pggo.Column{
+//	        {Name: "email", DataType: *pggo.DataType{}.Domain("email_address").NotNull()},
+//	    },
+//	}
+func (conf *DatabaseConnection) CreateDomain(ctx context.Context, name, baseType, constraint string) error {
+	createSQL, err := buildCreateDomainSQL(name, baseType, constraint)
+	if err != nil {
+		return err
+	}
+	if _, err := conf.Exec(ctx, createSQL); err != nil {
+		return fmt.Errorf("failed to create domain '%s': %w", name, err)
+	}
+	return nil
+}
+
+// buildCreateDomainSQL builds the DO block that creates a DOMAIN type unless it already exists.
+func buildCreateDomainSQL(name, baseType, constraint string) (string, error) {
+	if !isValidIdentifier(name) {
+		return "", fmt.Errorf("invalid domain name: '%s'", name)
+	}
+	baseType = strings.TrimSpace(baseType)
+	if !domainBaseTypePattern.MatchString(baseType) {
+		return "", fmt.Errorf("invalid domain base type: '%s'", baseType)
+	}
+
+	createSQL := fmt.Sprintf("CREATE DOMAIN %s AS %s", QuoteIdentifier(name), baseType)
+	if constraint = strings.TrimSpace(constraint); constraint != "" {
+		createSQL += fmt.Sprintf(" CHECK (%s)", constraint)
+	}
+	return ignoreDuplicateObject(createSQL)
+}
@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// typeBlockTag is the dollar-quote tag of the DO block CreateEnum and CreateDomain run.
+const typeBlockTag = "$pggo_type$"
+
+// CreateEnum creates an ENUM type with the given labels, for use with DataType.Enum.
+// Nothing is done if a type with that name already exists; its labels are not compared,
+// use AddEnumValue to extend it.
+//
+// PostgreSQL has no CREATE TYPE ... IF NOT EXISTS, so the statement is wrapped in a DO block
+// that ignores the duplicate_object error. Labels are quoted as string literals.
+//
+// Example:
+//
+//	err := db.CreateEnum(ctx, "order_status", []string{"pending", "paid", "shipped"})
+//	OrdersTable := pggo.Table{
+//	    Name: "orders",
+//	    Columns: []pggo.Column{
+//	        {Name: "status", DataType: *pggo.DataType{}.Enum("order_status").NotNull().DefaultRaw("'pending'")},
+//	    },
+//	}
+func (conf *DatabaseConnection) CreateEnum(ctx context.Context, name string, values []string) error {
+	createSQL, err := buildCreateEnumSQL(name, values)
+	if err != nil {
+		return err
+	}
+	if _, err := conf.Exec(ctx, createSQL); err != nil {
+		return fmt.Errorf("failed to create enum '%s': %w", name, err)
+	}
+	return nil
+}
+
+// AddEnumValue appends a label to an existing ENUM type (ALTER TYPE ... ADD VALUE IF NOT EXISTS).
+// Nothing is done if the label already exists. Labels cannot be removed or reordered this way.
+//
+// Example:
+//
+//	err := db.AddEnumValue(ctx, "order_status", "refunded")
+func (conf *DatabaseConnection) AddEnumValue(ctx context.Context, name, value string) error {
+	if !isValidIdentifier(name) {
+		return fmt.Errorf("invalid enum type name: '%s'", name)
+	}
+	if err := validateEnumLabel(value); err != nil {
+		return err
+	}
+	alterSQL := fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s", QuoteIdentifier(name), quoteLiteral(value))
+	if _, err := conf.Exec(ctx, alterSQL); err != nil {
+		return fmt.Errorf("failed to add value to enum '%s': %w", name, err)
+	}
+	return nil
+}
+
+// buildCreateEnumSQL builds the DO block that creates an ENUM type unless it already exists.
+func buildCreateEnumSQL(name string, values []string) (string, error) {
+	if !isValidIdentifier(name) {
+		return "", fmt.Errorf("invalid enum type name: '%s'", name)
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("enum '%s' needs at least one value", name)
+	}
+
+	seen := make(map[string]bool, len(values))
+	labels := make([]string, len(values))
+	for i, value := range values {
+		if err := validateEnumLabel(value); err != nil {
+			return "", err
+		}
+		if seen[value] {
+			return "", fmt.Errorf("duplicate enum value: '%s'", value)
+		}
+		seen[value] = true
+		labels[i] = quoteLiteral(value)
+	}
+
+	createSQL := fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", QuoteIdentifier(name), strings.Join(labels, ", "))
+	return ignoreDuplicateObject(createSQL)
+}
+
+// ignoreDuplicateObject wraps a CREATE statement that has no IF NOT EXISTS form in a DO block
+// that ignores the duplicate_object error.
+func ignoreDuplicateObject(createSQL string) (string, error) {
+	if strings.Contains(createSQL, typeBlockTag) {
+		// It would end the DO block early
+		return "", fmt.Errorf("statement cannot contain '%s'", typeBlockTag)
+	}
+	return fmt.Sprintf("DO %s BEGIN %s; EXCEPTION WHEN duplicate_object THEN NULL; END %s",
+		typeBlockTag, createSQL, typeBlockTag), nil
+}
+
+// validateEnumLabel checks a label against PostgreSQL's limits (non-empty, at most 63 bytes).
+func validateEnumLabel(value string) error {
+	if value == "" || len(value) > 63 {
+		return fmt.Errorf("invalid enum value: '%s' (must be 1 to 63 bytes)", value)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package modules
+
+import (
+	"sync"
+)
+
+// Event is a kind of table mutation that subscribers registered with Table.On are notified of.
+type Event int
+
+const (
+	// EventInsert follows Insert, InsertIgnore, InsertMany and their variants, and InsertFromSelectReturning.
+	EventInsert Event = iota
+	// EventUpdate follows Update, UpdateAll and UpdateLimit.
+	EventUpdate
+	// EventDelete follows Delete, DeleteAll and DeleteLimit.
+	EventDelete
+	// EventBulkUpdate follows BulkUpdate.
+	EventBulkUpdate
+	// EventTruncate follows Truncate.
+	EventTruncate
+)
+
+// EventPayload describes a mutation passed to the handlers registered with Table.On.
+type EventPayload struct {
+	// Event is the kind of mutation.
+	Event Event
+	// Table is the name of the mutated table.
+	Table string
+	// Rows are the rows as returned by the statement: inserted, updated or deleted.
+	// They are the rows returned to the caller, so handlers must not modify them.
+	// Empty for EventTruncate, and when Returning was called without columns.
+	Rows []map[string]interface{}
+	// WhereArgs are the conditions passed to Delete or Update; empty for the other events.
+	WhereArgs []interface{}
+}
+
+// eventEmitter holds the handlers registered with Table.On. It is shared by copies of the table (e.g. from WithContext).
+type eventEmitter struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[Event][]eventHandler
+}
+
+// eventHandler is a handler registered with Table.On and the id used to unsubscribe it.
+type eventHandler struct {
+	id int
+	fn func(EventPayload)
+}
+
+// eventEmitterInit guards a table's events field, which is created on first use by On or by the first copy of the table.
+var eventEmitterInit sync.RWMutex
+
+// emitter returns the table's event emitter, creating it if needed. Once set, events never changes.
+func (t *Table) emitter() *eventEmitter {
+	eventEmitterInit.Lock()
+	defer eventEmitterInit.Unlock()
+	if t.events == nil {
+		t.events = &eventEmitter{handlers: make(map[Event][]eventHandler)}
+	}
+	return t.events
+}
+
+// clone returns a shallow copy of the table that shares its event handlers, including those registered
+// with On after the copy was made. The methods returning modified copies of a table all use it.
+func (t *Table) clone() Table {
+	t.emitter()
+	return *t
+}
+
+// On registers handler to be called after every mutation of the table of the given kind, and returns a function
+// that unsubscribes it. Any number of handlers may subscribe to an event; they are called in the order
+// they subscribed, synchronously after the statement succeeded, so a slow handler delays the write's caller.
+// Handlers are not called for failed statements. The cache is updated in the background, so a handler
+// may still read the previous cached row.
+//
+// Handlers are shared with the copies of the table made by WithContext, Scope, Returning and the like,
+// whether the copy was made before or after On was called. Copying the Table struct itself (e.g. *UsersTable)
+// does not share them. On and the unsubscribe function are safe for concurrent use, also from within a handler.
+//
+// Example:
+//
+//	unsubscribe := UsersTable.On(pggo.EventInsert, func(p pggo.EventPayload) {
+//	    for _, row := range p.Rows {
+//	        log.Printf("new user %v", row["id"])
+//	    }
+//	})
+//	defer unsubscribe()
+func (t *Table) On(event Event, handler func(EventPayload)) func() {
+	emitter := t.emitter()
+
+	emitter.mu.Lock()
+	emitter.nextID++
+	id := emitter.nextID
+	emitter.handlers[event] = append(emitter.handlers[event], eventHandler{id: id, fn: handler})
+	emitter.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { emitter.remove(event, id) })
+	}
+}
+
+// remove unsubscribes the handler with the given id.
+func (e *eventEmitter) remove(event Event, id int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	handlers := e.handlers[event]
+	for i, h := range handlers {
+		if h.id == id {
+			// Copy rather than shift in place, since emit may be iterating over the old slice
+			remaining := make([]eventHandler, 0, len(handlers)-1)
+			remaining = append(remaining, handlers[:i]...)
+			e.handlers[event] = append(remaining, handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// emit calls the handlers subscribed to event. The lock is not held while they run,
+// so handlers may subscribe and unsubscribe.
+func (t *Table) emit(event Event, rows []map[string]interface{}, whereArgs []interface{}) {
+	eventEmitterInit.RLock()
+	emitter := t.events
+	eventEmitterInit.RUnlock()
+	if emitter == nil {
+		return
+	}
+	emitter.mu.RLock()
+	handlers := emitter.handlers[event]
+	emitter.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		return
+	}
+	payload := EventPayload{Event: event, Table: t.Name, Rows: rows, WhereArgs: whereArgs}
+	for _, h := range handlers {
+		h.fn(payload)
+	}
+}
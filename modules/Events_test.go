@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnSharedWithEarlierCopies(t *testing.T) {
+	table := &Table{Name: "items"}
+	copies := []*Table{table.WithContext(context.Background()), table.Scope(), table.Returning("id")}
+
+	calls := 0
+	unsubscribe := table.On(EventDelete, func(EventPayload) { calls++ })
+	for _, c := range copies {
+		c.emit(EventDelete, nil, nil)
+	}
+	if calls != len(copies) {
+		t.Fatalf("handler called %d times for %d copies made before On", calls, len(copies))
+	}
+
+	unsubscribe()
+	table.WithContext(context.Background()).emit(EventDelete, nil, nil)
+	if calls != len(copies) {
+		t.Fatalf("handler called after unsubscribing")
+	}
+}
+
+func TestOnInsert(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+	scoped := table.WithContext(context.Background())
+
+	var payloads []EventPayload
+	defer table.On(EventInsert, func(p EventPayload) { payloads = append(payloads, p) })()
+
+	row, err := scoped.Insert(map[string]interface{}{"name": "a"})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if _, err := table.InsertMany([]map[string]interface{}{{"name": "b"}, {"name": "c"}}); err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	if len(payloads) != 2 {
+		t.Fatalf("got %d insert events, want 2", len(payloads))
+	}
+	first := payloads[0]
+	if first.Event != EventInsert || first.Table != table.Name || len(first.Rows) != 1 || first.Rows[0]["id"] != row["id"] || first.Rows[0]["name"] != "a" {
+		t.Errorf("Insert event = %+v, want the inserted row %v", first, row)
+	}
+	if rows := payloads[1].Rows; len(rows) != 2 || rows[0]["name"] != "b" || rows[1]["name"] != "c" {
+		t.Errorf("InsertMany event rows = %v, want rows b and c", rows)
+	}
+}
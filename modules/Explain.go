@@ -0,0 +1,147 @@
+package modules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ExplainResult is the parsed output of EXPLAIN (FORMAT JSON).
+type ExplainResult struct {
+	// PlanningTime is the time spent planning the query, in milliseconds (ANALYZE only).
+	PlanningTime float64
+	// ExecutionTime is the time spent executing the query, in milliseconds (ANALYZE only).
+	ExecutionTime float64
+	// TotalCost is the planner's estimated total cost of the top plan node.
+	TotalCost float64
+	// Rows is the number of rows returned by the top plan node: the actual count with ANALYZE,
+	// otherwise the planner's estimate.
+	Rows int64
+	// Plan is the raw plan tree as decoded from JSON (nested maps with a "Plans" list per node).
+	Plan interface{}
+}
+
+// SeqScans returns the number of sequential scan nodes in the plan tree.
+// A sequential scan on a large table often indicates a missing index.
+func (r ExplainResult) SeqScans() int {
+	return countPlanNodes(r.Plan, "Seq Scan")
+}
+
+// countPlanNodes counts the nodes of the given type in a plan tree, recursing into "Plans".
+func countPlanNodes(plan interface{}, nodeType string) int {
+	node, ok := plan.(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	count := 0
+	if node["Node Type"] == nodeType {
+		count++
+	}
+	if children, ok := node["Plans"].([]interface{}); ok {
+		for _, child := range children {
+			count += countPlanNodes(child, nodeType)
+		}
+	}
+	return count
+}
+
+// Explain returns the planner's execution plan for a QueryBuilder query (EXPLAIN (FORMAT JSON)),
+// without running the query.
+//
+// Example:
+//
+//	plan, err := UsersTable.Explain(ctx, UsersTable.Query().Where(map[string]interface{}{"email": "a@b.c"}))
+//	if plan.SeqScans() > 0 {
+//	    log.Println("query does not use an index")
+//	}
+func (t *Table) Explain(ctx context.Context, qb *QueryBuilder) (ExplainResult, error) {
+	query, params, err := qb.ToSQL()
+	if err != nil {
+		return ExplainResult{}, err
+	}
+	return t.explain(ctx, "Explain", "EXPLAIN (FORMAT JSON) "+query, params, false)
+}
+
+// ExplainAnalyze runs a QueryBuilder query with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) and returns
+// the actual execution plan, including planning and execution times.
+// The query is executed, but its rows are discarded.
+func (t *Table) ExplainAnalyze(ctx context.Context, qb *QueryBuilder) (ExplainResult, error) {
+	query, params, err := qb.ToSQL()
+	if err != nil {
+		return ExplainResult{}, err
+	}
+	return t.explain(ctx, "ExplainAnalyze", "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+query, params, true)
+}
+
+// ExplainQueue runs a raw SQL query, as passed to Queue, with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON)
+// and returns the actual execution plan.
+//
+// Note: ANALYZE executes the statement. For INSERT, UPDATE or DELETE this modifies data;
+// run it inside a transaction that is rolled back if that is not intended.
+//
+// Example:
+//
+//	plan, err := UsersTable.ExplainQueue(ctx, "SELECT * FROM users WHERE age > $1", 20)
+//	log.Printf("took %.2fms, %d seq scans", plan.ExecutionTime, plan.SeqScans())
+func (t *Table) ExplainQueue(ctx context.Context, query string, params ...interface{}) (ExplainResult, error) {
+	return t.explain(ctx, "ExplainQueue", "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+query, params, true)
+}
+
+// explain runs an EXPLAIN (FORMAT JSON) statement and parses its output.
+func (t *Table) explain(ctx context.Context, operation, query string, params []interface{}, analyzed bool) (ExplainResult, error) {
+	rows, err := t.queryRows(ctx, operation, query, params)
+	if err != nil {
+		return ExplainResult{}, fmt.Errorf("failed to explain query: %w", err)
+	}
+	if len(rows) == 0 {
+		return ExplainResult{}, fmt.Errorf("failed to explain query: no plan returned")
+	}
+	return parseExplainOutput(rows[0]["QUERY PLAN"], analyzed)
+}
+
+// parseExplainOutput converts the "QUERY PLAN" value of EXPLAIN (FORMAT JSON) into an ExplainResult.
+// The value is a JSON array with a single object holding the plan and the timings.
+func parseExplainOutput(value interface{}, analyzed bool) (ExplainResult, error) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		// pgx already decoded the json column; re-encode it to parse it uniformly
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return ExplainResult{}, fmt.Errorf("failed to read query plan: %w", err)
+		}
+		data = encoded
+	}
+
+	var output []struct {
+		Plan          map[string]interface{} `json:"Plan"`
+		PlanningTime  float64                `json:"Planning Time"`
+		ExecutionTime float64                `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return ExplainResult{}, fmt.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(output) == 0 || output[0].Plan == nil {
+		return ExplainResult{}, fmt.Errorf("failed to parse query plan: empty plan")
+	}
+
+	plan := output[0].Plan
+	result := ExplainResult{
+		PlanningTime:  output[0].PlanningTime,
+		ExecutionTime: output[0].ExecutionTime,
+		Plan:          plan,
+	}
+	result.TotalCost, _ = plan["Total Cost"].(float64)
+	rowsKey := "Plan Rows"
+	if analyzed {
+		rowsKey = "Actual Rows"
+	}
+	if rows, ok := plan[rowsKey].(float64); ok {
+		result.Rows = int64(rows)
+	}
+	return result, nil
+}
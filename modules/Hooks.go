@@ -0,0 +1,133 @@
+package modules
+
+import (
+	"context"
+	"time"
+)
+
+// QueryEvent describes a single SQL statement executed by PgGo.
+// It is passed to QueryHooks before and after the statement runs.
+type QueryEvent struct {
+	// Table is the name of the table the statement was issued for.
+	Table string
+	// Operation is the PgGo method that issued the statement (e.g. "FetchOne", "Insert").
+	Operation string
+	// SQL is the statement text.
+	SQL string
+	// Params are the positional parameters bound to the statement.
+	Params []interface{}
+	// StartTime is when the statement started executing.
+	StartTime time.Time
+	// Duration is how long the statement took. Only set in AfterQuery.
+	Duration time.Duration
+	// RowCount is the number of rows returned or affected. Only set in AfterQuery.
+	RowCount int64
+	// Err is the error returned by the statement, if any. Only set in AfterQuery.
+	Err error
+}
+
+// QueryHook receives callbacks around every statement executed by a Table.
+// BeforeQuery may return a derived context (e.g. carrying a tracing span), which is
+// used to execute the statement and is passed to AfterQuery.
+type QueryHook interface {
+	BeforeQuery(ctx context.Context, event *QueryEvent) context.Context
+	AfterQuery(ctx context.Context, event *QueryEvent)
+}
+
+// QueryHookFunc adapts a plain function to a QueryHook that fires after each statement.
+//
+// Example (slow query log):
+//
+//	table.AddQueryHook(QueryHookFunc(func(e QueryEvent) {
+//	    if e.Duration > 100*time.Millisecond {
+//	        log.Printf("slow query (%v): %s", e.Duration, e.SQL)
+//	    }
+//	}))
+type QueryHookFunc func(event QueryEvent)
+
+// BeforeQuery implements QueryHook. It does nothing.
+func (f QueryHookFunc) BeforeQuery(ctx context.Context, event *QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery implements QueryHook by calling f with the completed event.
+func (f QueryHookFunc) AfterQuery(ctx context.Context, event *QueryEvent) {
+	f(*event)
+}
+
+// AddQueryHook registers a hook that runs around every statement executed by the table.
+func (t *Table) AddQueryHook(hook QueryHook) {
+	t.QueryHooks = append(t.QueryHooks, hook)
+}
+
+// OnQuery registers a function that is called after every statement executed by the table.
+func (t *Table) OnQuery(fn func(event QueryEvent)) {
+	t.AddQueryHook(QueryHookFunc(fn))
+}
+
+// AddQueryHook registers a hook that runs around every statement executed by tables using this connection.
+// Hooks must be added before the connection is assigned to a Table, since Table holds a copy of it.
+func (conf *DatabaseConnection) AddQueryHook(hook QueryHook) {
+	conf.QueryHooks = append(conf.QueryHooks, hook)
+}
+
+// queryHooks returns the connection-level hooks followed by the table-level hooks.
+func (t *Table) queryHooks() []QueryHook {
+	if len(t.Connection.QueryHooks) == 0 {
+		return t.QueryHooks
+	}
+	hooks := make([]QueryHook, 0, len(t.Connection.QueryHooks)+len(t.QueryHooks))
+	hooks = append(hooks, t.Connection.QueryHooks...)
+	return append(hooks, t.QueryHooks...)
+}
+
+// beforeQuery creates the QueryEvent for a statement and runs the BeforeQuery hooks.
+func (t *Table) beforeQuery(ctx context.Context, operation, query string, params []interface{}) (context.Context, *QueryEvent) {
+	event := &QueryEvent{
+		Table:     t.Name,
+		Operation: operation,
+		SQL:       query,
+		Params:    params,
+		StartTime: time.Now(),
+	}
+	for _, hook := range t.queryHooks() {
+		ctx = hook.BeforeQuery(ctx, event)
+	}
+	return ctx, event
+}
+
+// afterQuery completes the QueryEvent and runs the AfterQuery hooks in reverse order.
+func (t *Table) afterQuery(ctx context.Context, event *QueryEvent, rowCount int64, err error) {
+	event.Duration = time.Since(event.StartTime)
+	event.RowCount = rowCount
+	event.Err = err
+	hooks := t.queryHooks()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterQuery(ctx, event)
+	}
+}
+
+// beforeQuery is like Table.beforeQuery for statements issued directly on the connection,
+// running only the connection-level hooks.
+func (conf *DatabaseConnection) beforeQuery(ctx context.Context, operation, query string, params []interface{}) (context.Context, *QueryEvent) {
+	event := &QueryEvent{
+		Operation: operation,
+		SQL:       query,
+		Params:    params,
+		StartTime: time.Now(),
+	}
+	for _, hook := range conf.QueryHooks {
+		ctx = hook.BeforeQuery(ctx, event)
+	}
+	return ctx, event
+}
+
+// afterQuery is like Table.afterQuery for statements issued directly on the connection.
+func (conf *DatabaseConnection) afterQuery(ctx context.Context, event *QueryEvent, rowCount int64, err error) {
+	event.Duration = time.Since(event.StartTime)
+	event.RowCount = rowCount
+	event.Err = err
+	for i := len(conf.QueryHooks) - 1; i >= 0; i-- {
+		conf.QueryHooks[i].AfterQuery(ctx, event)
+	}
+}
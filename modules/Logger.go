@@ -0,0 +1,102 @@
+package modules
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// Logger is the interface PgGo uses for all diagnostic output.
+// Implement it to route PgGo's logs to a structured or leveled logger (slog, zap, zerolog, etc.).
+//
+// Example (zap):
+//
+//	type zapLogger struct{ l *zap.SugaredLogger }
+//
+//	func (z zapLogger) Debugf(format string, args ...interface{}) { z.l.Debugf(format, args...) }
+//	func (z zapLogger) Infof(format string, args ...interface{})  { z.l.Infof(format, args...) }
+//	func (z zapLogger) Warnf(format string, args ...interface{})  { z.l.Warnf(format, args...) }
+//	func (z zapLogger) Errorf(format string, args ...interface{}) { z.l.Errorf(format, args...) }
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NoopLogger discards all log output. It is the default when no Logger is configured.
+type NoopLogger struct{}
+
+func (NoopLogger) Debugf(format string, args ...interface{}) {}
+func (NoopLogger) Infof(format string, args ...interface{})  {}
+func (NoopLogger) Warnf(format string, args ...interface{})  {}
+func (NoopLogger) Errorf(format string, args ...interface{}) {}
+
+// StdLogger writes to the standard library log package, prefixing each line with its level.
+// It is used when a Table has DebugMode enabled but no Logger configured.
+type StdLogger struct{}
+
+func (StdLogger) Debugf(format string, args ...interface{}) { log.Printf("DEBUG: "+format, args...) }
+func (StdLogger) Infof(format string, args ...interface{})  { log.Printf("INFO: "+format, args...) }
+func (StdLogger) Warnf(format string, args ...interface{})  { log.Printf("WARN: "+format, args...) }
+func (StdLogger) Errorf(format string, args ...interface{}) { log.Printf("ERROR: "+format, args...) }
+
+// SlogLogger adapts a *slog.Logger to the Logger interface.
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+// NewSlogLogger wraps a *slog.Logger so it can be used as a PgGo Logger.
+//
+// Example:
+//
+//	table.Logger = NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: l}
+}
+
+func (s *SlogLogger) Debugf(format string, args ...interface{}) {
+	s.Logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Infof(format string, args ...interface{}) {
+	s.Logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Warnf(format string, args ...interface{}) {
+	s.Logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Errorf(format string, args ...interface{}) {
+	s.Logger.Error(fmt.Sprintf(format, args...))
+}
+
+// logger returns the Logger configured for the table.
+// It falls back to the connection's Logger, then to StdLogger in DebugMode, and finally to NoopLogger.
+func (t *Table) logger() Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	if t.Connection.Logger != nil {
+		return t.Connection.Logger
+	}
+	if t.DebugMode {
+		return StdLogger{}
+	}
+	return NoopLogger{}
+}
+
+// debugf logs a debug message if DebugMode is enabled for the table.
+func (t *Table) debugf(format string, args ...interface{}) {
+	if t.DebugMode {
+		t.logger().Debugf(format, args...)
+	}
+}
+
+// logger returns the Logger configured for the connection, or NoopLogger if none is set.
+func (conf *DatabaseConnection) logger() Logger {
+	if conf.Logger != nil {
+		return conf.Logger
+	}
+	return NoopLogger{}
+}
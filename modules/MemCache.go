@@ -3,6 +3,7 @@ package modules
 import (
 	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,6 +12,9 @@ type CacheItem struct {
 	Key        string
 	Value      []byte
 	Expiration int64
+
+	// value holds a Go value stored with SetValue. Value is nil for such items.
+	value interface{}
 }
 
 // MemoryCache is a simple in-memory cache implementation with LRU eviction.
@@ -19,6 +23,26 @@ type MemoryCache struct {
 	evictList *list.List
 	mu        sync.RWMutex
 	maxSize   int
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
+}
+
+// CacheStats is a snapshot of a MemoryCache's usage counters.
+type CacheStats struct {
+	// Hits is the number of Get calls that found a live item.
+	Hits int64
+	// Misses is the number of Get calls that found nothing or an expired item.
+	Misses int64
+	// Evictions is the number of items removed to stay within MaxSize.
+	Evictions int64
+	// Size is the current number of items in the cache.
+	Size int
+	// MaxSize is the maximum number of items the cache holds (0 means unlimited).
+	MaxSize int
+	// HitRate is Hits / (Hits + Misses), or 0 if there were no lookups.
+	HitRate float64
 }
 
 // NewMemoryCache creates a new instance of MemoryCache.
@@ -32,6 +56,23 @@ func NewMemoryCache(maxSize int) *MemoryCache {
 
 // Set adds an item to the cache with a TTL.
 func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.set(key, value, nil, ttl)
+}
+
+// SetValue adds a Go value to the cache with a TTL, without encoding it.
+// Tables store their rows this way, so cached rows keep the types pgx decoded them to.
+// The value is shared with the caller, who must not modify it afterwards.
+// A []byte value is stored as with Set.
+func (c *MemoryCache) SetValue(key string, value interface{}, ttl time.Duration) {
+	if data, ok := value.([]byte); ok {
+		c.set(key, data, nil, ttl)
+		return
+	}
+	c.set(key, nil, value, ttl)
+}
+
+// set stores either data or a Go value under the key.
+func (c *MemoryCache) set(key string, data []byte, value interface{}, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -40,13 +81,15 @@ func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
 	// Check if item exists
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
-		ent.Value.(*CacheItem).Value = value
-		ent.Value.(*CacheItem).Expiration = expiration
+		item := ent.Value.(*CacheItem)
+		item.Value = data
+		item.value = value
+		item.Expiration = expiration
 		return
 	}
 
 	// Add new item
-	ent := &CacheItem{Key: key, Value: value, Expiration: expiration}
+	ent := &CacheItem{Key: key, Value: data, Expiration: expiration, value: value}
 	entry := c.evictList.PushFront(ent)
 	c.items[key] = entry
 
@@ -61,6 +104,7 @@ func (c *MemoryCache) removeOldest() {
 	ent := c.evictList.Back()
 	if ent != nil {
 		c.removeElement(ent)
+		c.evictions.Add(1)
 	}
 }
 
@@ -71,20 +115,45 @@ func (c *MemoryCache) removeElement(e *list.Element) {
 	delete(c.items, kv.Key)
 }
 
-// Get retrieves an item from the cache.
+// Get retrieves an item stored with Set from the cache.
+// Items stored with SetValue are not found; use GetValue for them.
 func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	item, found := c.lookup(key)
+	if !found || item.value != nil {
+		return nil, false
+	}
+	return item.Value, true
+}
+
+// GetValue retrieves an item from the cache, as stored with SetValue (or Set).
+func (c *MemoryCache) GetValue(key string) (interface{}, bool) {
+	item, found := c.lookup(key)
+	if !found {
+		return nil, false
+	}
+	if item.value != nil {
+		return item.value, true
+	}
+	return item.Value, true
+}
+
+// lookup returns a copy of the live item under the key, counting the hit or miss.
+func (c *MemoryCache) lookup(key string) (CacheItem, bool) {
 	c.mu.Lock() // Lock instead of RLock because we might move element to front
 	defer c.mu.Unlock()
 
 	if ent, ok := c.items[key]; ok {
 		if time.Now().UnixNano() > ent.Value.(*CacheItem).Expiration {
 			c.removeElement(ent)
-			return nil, false
+			c.misses.Add(1)
+			return CacheItem{}, false
 		}
 		c.evictList.MoveToFront(ent)
-		return ent.Value.(*CacheItem).Value, true
+		c.hits.Add(1)
+		return *ent.Value.(*CacheItem), true
 	}
-	return nil, false
+	c.misses.Add(1)
+	return CacheItem{}, false
 }
 
 // Delete removes an item from the cache.
@@ -103,3 +172,22 @@ func (c *MemoryCache) Clear() {
 	c.items = make(map[string]*list.Element)
 	c.evictList.Init()
 }
+
+// Stats returns a snapshot of the cache's hit, miss and eviction counters and its current size.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.RLock()
+	size := c.evictList.Len()
+	c.mu.RUnlock()
+
+	stats := CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+		MaxSize:   c.maxSize,
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,97 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheStats(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"), time.Minute)
+	cache.Set("b", []byte("2"), time.Minute)
+	cache.Get("a")                            // hit
+	cache.Get("a")                            // hit
+	cache.Get("x")                            // miss
+	cache.Set("c", []byte("3"), time.Minute)  // evicts b, the least recently used
+	cache.Get("b")                            // miss
+	cache.Set("d", []byte("4"), -time.Second) // evicts a
+	cache.Get("d")                            // expired: miss, and removed
+
+	want := CacheStats{Hits: 2, Misses: 3, Evictions: 2, Size: 1, MaxSize: 2, HitRate: 0.4}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	if got := NewMemoryCache(0).Stats(); got != (CacheStats{}) {
+		t.Errorf("Stats() of an unused cache = %+v, want zero", got)
+	}
+}
+
+func TestTableCacheStats(t *testing.T) {
+	if stats := (&Table{Name: "items"}).CacheStats(); stats != nil {
+		t.Errorf("CacheStats() without caching = %+v, want nil", stats)
+	}
+
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) {
+		table.CacheKey = "id"
+		table.EnableCache(time.Minute)
+	}, Column{Name: "name", DataType: *DataType{}.Text()})
+
+	row, err := table.Insert(map[string]interface{}{"name": "a"})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	table.cacheTasks.wg.Wait() // Insert caches the row in the background
+
+	if _, err := table.FetchOne(map[string]interface{}{"id": row["id"]}); err != nil {
+		t.Fatalf("FetchOne: %v", err)
+	}
+	if _, err := table.FetchOne(map[string]interface{}{"id": -1}); err == nil {
+		t.Fatalf("FetchOne of a missing row succeeded")
+	}
+	if _, err := table.FetchOne(map[string]interface{}{"id": row["id"]}); err != nil {
+		t.Fatalf("FetchOne: %v", err)
+	}
+
+	stats := table.CacheStats()
+	if stats == nil {
+		t.Fatal("CacheStats() = nil with caching enabled")
+	}
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 || stats.MaxSize != table.CacheMax {
+		t.Errorf("CacheStats() = %+v, want 2 hits, 1 miss and 1 item", *stats)
+	}
+}
+
+func TestMemoryCacheValues(t *testing.T) {
+	cache := NewMemoryCache(0)
+	row := map[string]interface{}{"id": int64(1)}
+	cache.SetValue("row", row, time.Minute)
+	cache.Set("bytes", []byte("data"), time.Minute)
+	cache.SetValue("value bytes", []byte("more"), time.Minute)
+
+	if value, found := cache.GetValue("row"); !found || value.(map[string]interface{})["id"] != int64(1) {
+		t.Errorf("GetValue(row) = %v, %v; want the row", value, found)
+	}
+	if data, found := cache.Get("row"); found || data != nil {
+		t.Errorf("Get(row) = %q, %v; want no []byte for a value stored with SetValue", data, found)
+	}
+	for key, want := range map[string]string{"bytes": "data", "value bytes": "more"} {
+		if data, found := cache.Get(key); !found || string(data) != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", key, data, found, want)
+		}
+		if value, found := cache.GetValue(key); !found || string(value.([]byte)) != want {
+			t.Errorf("GetValue(%s) = %v, %v; want %q", key, value, found, want)
+		}
+	}
+
+	// Replacing a value with bytes, and bytes with a value, keeps only the latest
+	cache.Set("row", []byte("replaced"), time.Minute)
+	if data, found := cache.Get("row"); !found || string(data) != "replaced" {
+		t.Errorf("Get(row) after Set = %q, %v; want \"replaced\"", data, found)
+	}
+	cache.SetValue("bytes", 42, time.Minute)
+	if value, found := cache.GetValue("bytes"); !found || value != 42 {
+		t.Errorf("GetValue(bytes) after SetValue = %v, %v; want 42", value, found)
+	}
+}
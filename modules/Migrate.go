@@ -0,0 +1,175 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+)
+
+// dbColumn is a column of the table as it exists in the database, with its type as reported by format_type().
+type dbColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+}
+
+// getColumnTypesFromDB returns the table's columns in the database, in column order.
+// An empty result means the table does not exist.
+func (t *Table) getColumnTypesFromDB() ([]dbColumn, error) {
+	const QueryString = `SELECT a.attname::text AS name, format_type(a.atttypid, a.atttypmod) AS type, a.attnotnull AS notnull
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`
+	rows, err := t.queryRows(t.context(), "getColumnTypesFromDB", QueryString, []interface{}{QuoteIdentifier(t.Name)})
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]dbColumn, 0, len(rows))
+	for _, row := range rows {
+		col := dbColumn{}
+		col.Name, _ = row["name"].(string)
+		col.Type, _ = row["type"].(string)
+		col.NotNull, _ = row["notnull"].(bool)
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// Migrate compares the Table definition with the table in the database and returns the DDL statements
+// needed to bring the database in line with it:
+//   - CREATE TABLE if the table does not exist
+//   - ADD COLUMN / DROP COLUMN for added and removed columns
+//   - ALTER COLUMN ... TYPE for columns whose type changed (converted with USING "col"::type)
+//   - SET / DROP NOT NULL for columns whose nullability changed
+//   - missing CHECK constraints (Checks) and indexes (Indexes)
+//
+// If dryRun is true nothing is executed, so the plan can be reviewed (e.g. printed in CI) before it
+// runs against production. Otherwise the statements are applied in a single transaction.
+// Unlike CreateTable, Migrate also changes column types, which can fail depending on the existing data.
+//
+// Example:
+//
+//	statements, err := UsersTable.Migrate(ctx, true)
+//	for _, stmt := range statements {
+//	    fmt.Println(stmt)
+//	}
+func (t *Table) Migrate(ctx context.Context, dryRun bool) ([]string, error) {
+	t.addTimestampColumns()
+	t.addVersionColumn()
+	mt := t.WithContext(ctx)
+	statements, err := mt.planMigration()
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan migration: %w", err)
+	}
+	if dryRun || len(statements) == 0 {
+		return statements, nil
+	}
+
+	if err := t.applyStatements(ctx, "Migrate", statements); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// applyStatements executes DDL statements in a single transaction and clears the cache afterwards,
+// since cached rows may still hold dropped or retyped columns.
+func (t *Table) applyStatements(ctx context.Context, operation string, statements []string) error {
+	if len(statements) == 0 {
+		return nil
+	}
+	tx, err := t.Connection.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, stmt := range statements {
+		t.debugf("Executing %s with SQL: %s", operation, stmt)
+		hookCtx, event := t.beforeQuery(ctx, operation, stmt, nil)
+		tag, err := tx.Exec(hookCtx, stmt)
+		t.afterQuery(hookCtx, event, tag.RowsAffected(), err)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration statement %q: %w", stmt, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	t.invalidateCache()
+	return nil
+}
+
+// planMigration builds the DDL statements that Migrate applies.
+func (t *Table) planMigration() ([]string, error) {
+	if err := t.validateChecks(); err != nil {
+		return nil, err
+	}
+
+	dbColumns, err := t.getColumnTypesFromDB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	diff := t.computeSchemaDiff(dbColumns)
+	statements := diff.statements()
+	if diff.create {
+		// The table was just created with its checks; only its indexes remain
+		for _, def := range t.Indexes {
+			indexSQL, err := t.buildCreateIndexSQL(def)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, indexSQL)
+		}
+		return statements, nil
+	}
+
+	tableName := QuoteIdentifier(t.Name)
+	existing := make(map[string]dbColumn, len(dbColumns))
+	for _, col := range dbColumns {
+		existing[col.Name] = col
+	}
+	for _, col := range t.Columns {
+		dbCol, found := existing[col.Name]
+		if !found || col.DataType.Type == "" {
+			continue
+		}
+		colName := QuoteIdentifier(col.Name)
+		wantNotNull := col.DataType.isNotNull || col.DataType.isPrimaryKey || col.DataType.isIdentity
+		if wantNotNull && !dbCol.NotNull {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", tableName, colName))
+		} else if !wantNotNull && dbCol.NotNull {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", tableName, colName))
+		}
+	}
+
+	if len(t.Checks) > 0 {
+		existingChecks, err := t.getCheckNamesFromDB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read check constraints: %w", err)
+		}
+		for _, check := range t.Checks {
+			if !existingChecks[check.Name] {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s", tableName, check.toSQL()))
+			}
+		}
+	}
+
+	if len(t.Indexes) > 0 {
+		existingIndexes, err := t.getIndexNamesFromDB()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read indexes: %w", err)
+		}
+		for _, def := range t.Indexes {
+			if existingIndexes[t.indexName(def)] {
+				continue
+			}
+			indexSQL, err := t.buildCreateIndexSQL(def)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, indexSQL)
+		}
+	}
+
+	return statements, nil
+}
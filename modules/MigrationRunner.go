@@ -0,0 +1,365 @@
+package modules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNoDownMigration is returned by MigrationRunner.Down when a migration to roll back has no .down.sql file.
+var ErrNoDownMigration = errors.New("migration has no down file")
+
+// ErrMigrationChanged is returned by MigrationRunner.Up when the .up.sql file of an applied migration
+// was modified after it ran, so the database no longer matches the migrations on disk.
+var ErrMigrationChanged = errors.New("applied migration was modified")
+
+// migrationsTable records the applied migrations.
+const migrationsTable = "schema_migrations"
+
+// migrationFilePattern matches migration file names such as 0001_create_users.up.sql.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_-]+)\.(up|down)\.sql$`)
+
+// MigrationRecord describes a migration file and whether it has been applied.
+type MigrationRecord struct {
+	// Version is the number the file name starts with.
+	Version int64
+	// Name is the rest of the file name, e.g. "create_users".
+	Name string
+	// Applied reports whether the migration has been applied.
+	Applied bool
+	// AppliedAt is when the migration was applied, or nil if it is pending.
+	AppliedAt *time.Time
+	// Checksum is the SHA-256 of the .up.sql file.
+	Checksum string
+	// Modified reports whether the .up.sql file changed since the migration was applied.
+	Modified bool
+}
+
+// MigrationRunner applies numbered SQL migration files and tracks them in the schema_migrations table
+// (version bigint, applied_at timestamptz, checksum text), which it creates on first use.
+//
+// Migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql, e.g.
+// 0001_create_users.up.sql, and run in version order. A file may hold several statements.
+// Each migration runs in its own transaction together with its schema_migrations update, so a failing
+// migration leaves no trace; statements that cannot run in a transaction (CREATE INDEX CONCURRENTLY)
+// are not supported. Concurrent runners wait for each other instead of applying a migration twice.
+type MigrationRunner struct {
+	conn *DatabaseConnection
+	dir  string
+}
+
+// migrationFile is a migration found in the migrations directory.
+type migrationFile struct {
+	version  int64
+	name     string
+	upPath   string
+	downPath string
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	appliedAt time.Time
+	checksum  string
+}
+
+// NewMigrationRunner returns a MigrationRunner for the migration files in migrationsDir.
+//
+// Example:
+//
+//	runner := pggo.NewMigrationRunner(connection, "migrations")
+//	if err := runner.Up(ctx, 0); err != nil {
+//	    log.Fatal(err)
+//	}
+func NewMigrationRunner(conn *DatabaseConnection, migrationsDir string) *MigrationRunner {
+	return &MigrationRunner{conn: conn, dir: migrationsDir}
+}
+
+// Up applies the next steps pending migrations in version order, or all of them if steps is 0.
+//
+// It refuses to run, returning ErrMigrationChanged, if an applied migration's .up.sql file
+// was modified since it ran. Migrations applied before a failing one stay applied.
+func (r *MigrationRunner) Up(ctx context.Context, steps int) error {
+	if steps < 0 {
+		return fmt.Errorf("steps must not be negative: %d", steps)
+	}
+	files, applied, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []migrationFile
+	for _, file := range files {
+		record, found := applied[file.version]
+		if !found {
+			pending = append(pending, file)
+			continue
+		}
+		checksum, err := fileChecksum(file.upPath)
+		if err != nil {
+			return err
+		}
+		if checksum != record.checksum {
+			return fmt.Errorf("%w: %s", ErrMigrationChanged, filepath.Base(file.upPath))
+		}
+	}
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	for _, file := range pending {
+		if err := r.apply(ctx, file, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down rolls back the last steps applied migrations in reverse version order, or all of them if steps is 0.
+// Returns ErrNoDownMigration, before rolling anything back, if one of them has no .down.sql file.
+func (r *MigrationRunner) Down(ctx context.Context, steps int) error {
+	if steps < 0 {
+		return fmt.Errorf("steps must not be negative: %d", steps)
+	}
+	files, applied, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[int64]migrationFile, len(files))
+	for _, file := range files {
+		byVersion[file.version] = file
+	}
+	versions := make([]int64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if steps > 0 && steps < len(versions) {
+		versions = versions[:steps]
+	}
+
+	rollback := make([]migrationFile, 0, len(versions))
+	for _, version := range versions {
+		file, found := byVersion[version]
+		if !found {
+			return fmt.Errorf("migration %d is applied but its files are missing from %s", version, r.dir)
+		}
+		if file.downPath == "" {
+			return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, file.version, file.name)
+		}
+		rollback = append(rollback, file)
+	}
+
+	for _, file := range rollback {
+		if err := r.apply(ctx, file, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns every migration file in version order with whether it has been applied.
+func (r *MigrationRunner) Status(ctx context.Context) ([]MigrationRecord, error) {
+	files, applied, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]MigrationRecord, 0, len(files))
+	for _, file := range files {
+		checksum, err := fileChecksum(file.upPath)
+		if err != nil {
+			return nil, err
+		}
+		record := MigrationRecord{Version: file.version, Name: file.name, Checksum: checksum}
+		if row, found := applied[file.version]; found {
+			appliedAt := row.appliedAt
+			record.Applied = true
+			record.AppliedAt = &appliedAt
+			record.Modified = row.checksum != checksum
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// load reads the migration files and the applied migrations, creating schema_migrations if needed.
+func (r *MigrationRunner) load(ctx context.Context) ([]migrationFile, map[int64]appliedMigration, error) {
+	files, err := r.readFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, nil, err
+	}
+	applied, err := r.appliedMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, applied, nil
+}
+
+// readFiles lists the migrations in the directory, in version order.
+func (r *MigrationRunner) readFiles() ([]migrationFile, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int64]*migrationFile)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		file, found := byVersion[version]
+		if !found {
+			file = &migrationFile{version: version, name: match[2]}
+			byVersion[version] = file
+		} else if file.name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, file.name, match[2])
+		}
+		path := filepath.Join(r.dir, entry.Name())
+		if match[3] == "up" {
+			file.upPath = path
+		} else {
+			file.downPath = path
+		}
+	}
+
+	files := make([]migrationFile, 0, len(byVersion))
+	for _, file := range byVersion {
+		if file.upPath == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", file.version, file.name)
+		}
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].version < files[j].version })
+	return files, nil
+}
+
+// ensureTable creates the schema_migrations table if it does not exist.
+func (r *MigrationRunner) ensureTable(ctx context.Context) error {
+	createSQL := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version bigint PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now(),
+		checksum text NOT NULL)`, QuoteIdentifier(migrationsTable))
+	if _, err := r.conn.Exec(ctx, createSQL); err != nil {
+		return fmt.Errorf("failed to create %s table: %w", migrationsTable, err)
+	}
+	return nil
+}
+
+// appliedMigrations returns the rows of schema_migrations by version.
+func (r *MigrationRunner) appliedMigrations(ctx context.Context) (map[int64]appliedMigration, error) {
+	pool, err := r.conn.getPool()
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT version, applied_at, checksum FROM %s", QuoteIdentifier(migrationsTable))
+	rows, err := pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var version int64
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.appliedAt, &row.checksum); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// apply runs the up (or down) file of a migration and records it, in one transaction.
+// The schema_migrations table is locked first, so a concurrent runner that already applied
+// (or rolled back) the migration is detected and the migration is skipped.
+func (r *MigrationRunner) apply(ctx context.Context, file migrationFile, up bool) error {
+	path := file.downPath
+	if up {
+		path = file.upPath
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read migration: %w", err)
+	}
+
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	table := QuoteIdentifier(migrationsTable)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("LOCK TABLE %s IN SHARE ROW EXCLUSIVE MODE", table)); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", migrationsTable, err)
+	}
+	var applied bool
+	existsSQL := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE version = $1)", table)
+	if err := tx.Tx.QueryRow(ctx, existsSQL, file.version).Scan(&applied); err != nil {
+		return fmt.Errorf("failed to check migration %d: %w", file.version, err)
+	}
+	if applied == up {
+		return nil
+	}
+
+	// The simple protocol lets a file contain several statements
+	if _, err := tx.Tx.Exec(ctx, string(contents), pgx.QueryExecModeSimpleProtocol); err != nil {
+		return fmt.Errorf("failed to run migration %s: %w", filepath.Base(path), err)
+	}
+	if up {
+		_, err = tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (version, checksum) VALUES ($1, $2)", table),
+			file.version, checksumOf(contents))
+	} else {
+		_, err = tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s WHERE version = $1", table), file.version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", file.version, err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	direction := "Applied"
+	if !up {
+		direction = "Rolled back"
+	}
+	r.conn.logger().Infof("%s migration %s", direction, filepath.Base(path))
+	return nil
+}
+
+// fileChecksum returns the SHA-256 of a file as hex.
+func fileChecksum(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration: %w", err)
+	}
+	return checksumOf(contents), nil
+}
+
+// checksumOf returns the SHA-256 of contents as hex.
+func checksumOf(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,194 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// listenerRegistry tracks the active LISTEN goroutines of a DatabaseConnection.
+type listenerRegistry struct {
+	mu        sync.Mutex
+	listeners map[string]*listener
+	// closed is set by Close, which stopped the listeners; listeners started meanwhile are stopped too.
+	closed bool
+}
+
+// listener is a single LISTEN goroutine and its dedicated connection.
+type listener struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// listenerRegistry returns the connection's listener registry. ConnectDb creates it,
+// so copies of the connection share it; it is created here for pools assigned directly.
+func (conf *DatabaseConnection) listenerRegistry() *listenerRegistry {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	conf.initListeners()
+	return conf.listeners
+}
+
+// initListeners creates the listener registry if there is none. poolMu must be held.
+func (conf *DatabaseConnection) initListeners() {
+	if conf.listeners == nil {
+		conf.listeners = &listenerRegistry{listeners: make(map[string]*listener)}
+	}
+}
+
+// remove unregisters l from channel, if it is still registered there.
+func (r *listenerRegistry) remove(channel string, l *listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listeners[channel] == l {
+		delete(r.listeners, channel)
+	}
+}
+
+// Listen subscribes to a PostgreSQL notification channel (LISTEN) and returns a Go channel
+// that receives its notifications.
+//
+// A dedicated connection is opened for the listener, since a LISTEN connection must stay pinned
+// and cannot be returned to the pool. The returned channel is closed when ctx is cancelled,
+// Unlisten is called, the DatabaseConnection is closed, or the listener connection fails.
+//
+// Example:
+//
+//	notifications, err := db.Listen(ctx, "orders_created")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for n := range notifications {
+//	    log.Printf("new order: %s", n.Payload)
+//	}
+func (conf *DatabaseConnection) Listen(ctx context.Context, channel string) (<-chan *pgconn.Notification, error) {
+	if !isValidIdentifier(channel) {
+		return nil, fmt.Errorf("invalid channel name: '%s'", channel)
+	}
+	pool, err := conf.getPool()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserve the channel, then dial without holding the registry mutex, so a slow dial does not
+	// block the other channels. Unlisten and Close cancel listenCtx, which aborts the dial.
+	listenCtx, cancel := context.WithCancel(ctx)
+	l := &listener{cancel: cancel, done: make(chan struct{})}
+	registry := conf.listenerRegistry()
+	registry.mu.Lock()
+	if registry.closed {
+		registry.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("database connection is closed")
+	}
+	if _, exists := registry.listeners[channel]; exists {
+		registry.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("already listening on channel '%s'", channel)
+	}
+	registry.listeners[channel] = l
+	registry.mu.Unlock()
+
+	conn, err := pgx.ConnectConfig(listenCtx, pool.Config().ConnConfig.Copy())
+	if err == nil {
+		if _, err = conn.Exec(listenCtx, "LISTEN "+QuoteIdentifier(channel)); err != nil {
+			conn.Close(context.Background())
+			err = fmt.Errorf("failed to listen on channel '%s': %w", channel, err)
+		}
+	} else {
+		err = fmt.Errorf("failed to open listener connection: %w", err)
+	}
+	if err != nil {
+		registry.remove(channel, l)
+		cancel()
+		close(l.done)
+		return nil, err
+	}
+
+	notifications := make(chan *pgconn.Notification, 16)
+	go func() {
+		defer close(l.done)
+		defer close(notifications)
+		defer func() {
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cleanupCancel()
+			_, _ = conn.Exec(cleanupCtx, "UNLISTEN "+QuoteIdentifier(channel))
+			conn.Close(cleanupCtx)
+			registry.remove(channel, l)
+		}()
+
+		for {
+			notification, err := conn.WaitForNotification(listenCtx)
+			if err != nil {
+				if listenCtx.Err() == nil {
+					conf.logger().Errorf("Listener on channel %s stopped: %v", channel, err)
+				}
+				return
+			}
+			select {
+			case notifications <- notification:
+			case <-listenCtx.Done():
+				return
+			}
+		}
+	}()
+
+	conf.logger().Infof("Listening on channel %s", channel)
+	return notifications, nil
+}
+
+// Unlisten stops listening on a channel (UNLISTEN), closes its listener connection and
+// closes the channel returned by Listen. It waits for the listener to stop, up to ctx's deadline.
+func (conf *DatabaseConnection) Unlisten(ctx context.Context, channel string) error {
+	registry := conf.listenerRegistry()
+	registry.mu.Lock()
+	l, exists := registry.listeners[channel]
+	registry.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("not listening on channel '%s'", channel)
+	}
+
+	l.cancel()
+	select {
+	case <-l.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify sends a notification with the given payload on a channel (NOTIFY).
+// It uses pg_notify so the payload is passed as a parameter rather than inlined into the SQL.
+//
+// Example:
+//
+//	err := db.Notify(ctx, "orders_created", `{"id": 42}`)
+func (conf *DatabaseConnection) Notify(ctx context.Context, channel, payload string) error {
+	if !isValidIdentifier(channel) {
+		return fmt.Errorf("invalid channel name: '%s'", channel)
+	}
+	pool, err := conf.getPool()
+	if err != nil {
+		return err
+	}
+	if _, err := pool.Exec(ctx, "SELECT pg_notify($1, $2)", channel, payload); err != nil {
+		return fmt.Errorf("failed to notify channel '%s': %w", channel, err)
+	}
+	return nil
+}
+
+// stopListeners cancels all active listeners without waiting for them to finish.
+// Listeners started afterwards are refused until ConnectDb reopens the connection.
+func (conf *DatabaseConnection) stopListeners() {
+	registry := conf.listenerRegistry()
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.closed = true
+	for _, l := range registry.listeners {
+		l.cancel()
+	}
+}
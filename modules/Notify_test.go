@@ -0,0 +1,92 @@
+package modules
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentServer accepts TCP connections and never answers, so dialing it hangs until the dial is cancelled.
+func silentServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, c)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestListenDialDoesNotBlockOtherChannels(t *testing.T) {
+	conn := &DatabaseConnection{DB_URL: "postgres://user:password@" + silentServer(t) + "/pggo?sslmode=disable", MAX_CONNECTIONS: 2}
+	if _, err := conn.ConnectDb(); err != nil {
+		t.Fatalf("ConnectDb: %v", err)
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		_, err := conn.Listen(context.Background(), "slow")
+		listenErr <- err
+	}()
+
+	// Wait until the channel is reserved and its dial is hanging
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		registry := conn.listenerRegistry()
+		registry.mu.Lock()
+		_, reserved := registry.listeners["slow"]
+		registry.mu.Unlock()
+		if reserved {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Listen did not reserve its channel")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := conn.Listen(ctx, "slow"); err == nil {
+		t.Fatal("second Listen on a reserved channel succeeded")
+	}
+	if err := conn.Unlisten(ctx, "other"); err == nil || ctx.Err() != nil {
+		t.Fatalf("Unlisten of another channel = %v, ctx %v; want an immediate error", err, ctx.Err())
+	}
+
+	// Unlisten cancels the pending dial
+	if err := conn.Unlisten(ctx, "slow"); err != nil {
+		t.Fatalf("Unlisten during the dial: %v", err)
+	}
+	select {
+	case err := <-listenErr:
+		if err == nil {
+			t.Fatal("Listen succeeded after Unlisten")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after Unlisten")
+	}
+
+	if err := conn.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := conn.Listen(context.Background(), "late"); err == nil {
+		t.Fatal("Listen after Close succeeded")
+	}
+}
@@ -0,0 +1,851 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SelectExpr is an expression that can appear in the SELECT list of a QueryBuilder,
+// such as an aggregate (COUNT, SUM) or a raw SQL expression.
+type SelectExpr interface {
+	// SelectSQL renders the expression, numbering any parameters starting at argIndex.
+	SelectSQL(argIndex *int) (string, []interface{}, error)
+}
+
+// RawExpr is a raw SQL expression used in a SELECT list, optionally aliased.
+// The expression is inserted verbatim and must not contain untrusted input.
+type RawExpr struct {
+	Expr  string
+	Alias string
+}
+
+// Expr returns a raw SQL expression for use in QueryBuilder.Select.
+// Usage: Expr("date_trunc('day', created_at)").As("day")
+func Expr(expr string) *RawExpr {
+	return &RawExpr{Expr: expr}
+}
+
+// As sets the alias of the expression.
+func (e *RawExpr) As(alias string) *RawExpr {
+	e.Alias = alias
+	return e
+}
+
+// SelectSQL implements SelectExpr.
+func (e *RawExpr) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	return withAlias(e.Expr, e.Alias)
+}
+
+// AggregateExpr is an aggregate function call over a single column (e.g. COUNT("id")).
+type AggregateExpr struct {
+	Function string
+	Column   string
+	Distinct bool
+	Alias    string
+}
+
+// Aggregate returns an aggregate function call over a column.
+// The column is validated and quoted; "*" is allowed.
+// Usage: Aggregate("MAX", "price").As("max_price")
+func Aggregate(function, column string) *AggregateExpr {
+	return &AggregateExpr{Function: strings.ToUpper(function), Column: column}
+}
+
+// Count returns a COUNT aggregate. Use "*" to count rows.
+func Count(column string) *AggregateExpr {
+	return Aggregate("COUNT", column)
+}
+
+// Sum returns a SUM aggregate.
+func Sum(column string) *AggregateExpr {
+	return Aggregate("SUM", column)
+}
+
+// Avg returns an AVG aggregate.
+func Avg(column string) *AggregateExpr {
+	return Aggregate("AVG", column)
+}
+
+// Min returns a MIN aggregate.
+func Min(column string) *AggregateExpr {
+	return Aggregate("MIN", column)
+}
+
+// Max returns a MAX aggregate.
+func Max(column string) *AggregateExpr {
+	return Aggregate("MAX", column)
+}
+
+// DistinctValues makes the aggregate operate on distinct values only (e.g. COUNT(DISTINCT "email")).
+func (a *AggregateExpr) DistinctValues() *AggregateExpr {
+	a.Distinct = true
+	return a
+}
+
+// As sets the alias of the aggregate.
+func (a *AggregateExpr) As(alias string) *AggregateExpr {
+	a.Alias = alias
+	return a
+}
+
+// SelectSQL implements SelectExpr.
+func (a *AggregateExpr) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	if !isValidIdentifier(a.Function) {
+		return "", nil, fmt.Errorf("invalid aggregate function: '%s'", a.Function)
+	}
+	column := "*"
+	if a.Column != "*" {
+		if !isValidIdentifier(a.Column) {
+			return "", nil, fmt.Errorf("invalid aggregate column: '%s'", a.Column)
+		}
+		column = QuoteIdentifier(a.Column)
+	}
+	if a.Distinct {
+		column = "DISTINCT " + column
+	}
+	return withAlias(fmt.Sprintf("%s(%s)", a.Function, column), a.Alias)
+}
+
+// OrderedAggregateExpr is an aggregate over raw SQL expressions that can order its input,
+// such as json_agg, jsonb_object_agg, array_agg and string_agg.
+type OrderedAggregateExpr struct {
+	// Function is the aggregate function name, e.g. "json_agg".
+	Function string
+	// Expressions are the raw SQL arguments of the call, e.g. a column, a row alias or a jsonb_build_object(...) call.
+	Expressions []string
+	// Params are passed as parameters after Expressions (e.g. the string_agg delimiter).
+	Params []interface{}
+	// OrderBy orders the aggregated values (fn(... ORDER BY ...)).
+	OrderBy []OrderBySpec
+	Alias   string
+}
+
+// JsonAgg returns a json_agg(expression) aggregate, which collects the values (or whole rows,
+// given a table alias) into a JSON array, optionally ordered.
+// The expression is inserted verbatim and must not contain untrusted input.
+// Usage:
+//
+//	// One row per user with their orders as a JSON array
+//	OrdersTable.Query().
+//	    Select("user_id", JsonAgg("orders", OrderBySpec{Column: "created_at"}).As("orders")).
+//	    GroupBy("user_id")
+func JsonAgg(expression string, orderBy ...OrderBySpec) *OrderedAggregateExpr {
+	return &OrderedAggregateExpr{Function: "json_agg", Expressions: []string{expression}, OrderBy: orderBy}
+}
+
+// JsonbObjectAgg returns a jsonb_object_agg(keyExpr, valueExpr) aggregate, which collects key/value
+// pairs into a JSON object. The expressions are inserted verbatim and must not contain untrusted input.
+// Usage: JsonbObjectAgg("key", "value").As("settings")
+func JsonbObjectAgg(keyExpr, valueExpr string) *OrderedAggregateExpr {
+	return &OrderedAggregateExpr{Function: "jsonb_object_agg", Expressions: []string{keyExpr, valueExpr}}
+}
+
+// ArrayAgg returns an array_agg(expression) aggregate, which collects the values into an array, optionally ordered.
+// The expression is inserted verbatim and must not contain untrusted input.
+// Usage: ArrayAgg("tag", OrderBySpec{Column: "tag"}).As("tags")
+func ArrayAgg(expression string, orderBy ...OrderBySpec) *OrderedAggregateExpr {
+	return &OrderedAggregateExpr{Function: "array_agg", Expressions: []string{expression}, OrderBy: orderBy}
+}
+
+// StringAgg returns a string_agg(expression, delimiter) aggregate, which concatenates the values
+// separated by delimiter, optionally ordered. The delimiter is passed as a parameter;
+// the expression is inserted verbatim and must not contain untrusted input.
+// Usage: StringAgg("name", ", ", OrderBySpec{Column: "name"}).As("names")
+func StringAgg(expression, delimiter string, orderBy ...OrderBySpec) *OrderedAggregateExpr {
+	return &OrderedAggregateExpr{Function: "string_agg", Expressions: []string{expression}, Params: []interface{}{delimiter}, OrderBy: orderBy}
+}
+
+// As sets the alias of the aggregate.
+func (a *OrderedAggregateExpr) As(alias string) *OrderedAggregateExpr {
+	a.Alias = alias
+	return a
+}
+
+// SelectSQL implements SelectExpr.
+func (a *OrderedAggregateExpr) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	if !isValidIdentifier(a.Function) {
+		return "", nil, fmt.Errorf("invalid aggregate function: '%s'", a.Function)
+	}
+	callArgs := make([]string, 0, len(a.Expressions)+len(a.Params))
+	for _, expr := range a.Expressions {
+		if strings.TrimSpace(expr) == "" {
+			return "", nil, fmt.Errorf("%s expression is empty", a.Function)
+		}
+		callArgs = append(callArgs, expr)
+	}
+	for range a.Params {
+		callArgs = append(callArgs, fmt.Sprintf("$%d", *argIndex))
+		*argIndex++
+	}
+	orderClause, err := buildOrderByClause(a.OrderBy)
+	if err != nil {
+		return "", nil, err
+	}
+	sql, _, err := withAlias(fmt.Sprintf("%s(%s%s)", a.Function, strings.Join(callArgs, ", "), orderClause), a.Alias)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, a.Params, nil
+}
+
+// TsRankExpr ranks rows by how well a tsvector column matches a tsquery (ts_rank), for ordering search results.
+type TsRankExpr struct {
+	VectorColumn string
+	Query        string
+	Config       string
+	Alias        string
+}
+
+// TsRank returns a ts_rank("vectorCol", to_tsquery(config, query)) expression for a SELECT list.
+// config is the text search configuration and defaults to "english" when empty; pass the same
+// config as the TsMatch condition so the rank is computed from the same tsquery.
+// Alias it and order by the alias to sort search results by relevance.
+// Usage:
+//
+//	table.Query().
+//	    Select("*", TsRank("search_vector", "postgres & pool", "").As("rank")).
+//	    Where(map[string]interface{}{"search_vector": TsMatch("postgres & pool", "")}).
+//	    OrderBy(OrderBySpec{Column: "rank", Direction: "DESC"})
+func TsRank(vectorCol, query, config string) *TsRankExpr {
+	if config == "" {
+		config = "english"
+	}
+	return &TsRankExpr{VectorColumn: vectorCol, Query: query, Config: config}
+}
+
+// As sets the alias of the rank expression.
+func (r *TsRankExpr) As(alias string) *TsRankExpr {
+	r.Alias = alias
+	return r
+}
+
+// SelectSQL implements SelectExpr.
+func (r *TsRankExpr) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	if !isValidIdentifier(r.VectorColumn) {
+		return "", nil, fmt.Errorf("invalid tsvector column: '%s'", r.VectorColumn)
+	}
+	config := r.Config
+	if config == "" {
+		config = "english"
+	}
+	if !isValidIdentifier(config) {
+		return "", nil, fmt.Errorf("invalid text search config: '%s'", config)
+	}
+	expr := fmt.Sprintf("ts_rank(%s, to_tsquery($%d::regconfig, $%d))", QuoteIdentifier(r.VectorColumn), *argIndex, *argIndex+1)
+	*argIndex += 2
+	sql, _, err := withAlias(expr, r.Alias)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, []interface{}{config, r.Query}, nil
+}
+
+// WindowFunc is a window function call (e.g. ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...))
+// for use in a QueryBuilder SELECT list.
+type WindowFunc struct {
+	// Function is the raw function call, e.g. "ROW_NUMBER()", "SUM(amount)", "LAG(price, 1)".
+	Function string
+	// PartitionBy lists the columns to partition by. They are validated and quoted.
+	PartitionBy []string
+	// OrderBy lists the columns to order by within each partition.
+	OrderBy []OrderBySpec
+	// Frame is an optional raw frame clause, e.g. "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW".
+	Frame string
+	// Alias is the optional output column name.
+	Alias string
+}
+
+// Over returns a window function expression: funcExpr OVER (PARTITION BY ... ORDER BY ... frame).
+// funcExpr and frame are inserted verbatim and must not contain untrusted input.
+// Usage:
+//
+//	Over("ROW_NUMBER()", []string{"category"}, []OrderBySpec{{Column: "created_at", Direction: "DESC"}}, "").As("rank")
+//	Over("SUM(amount)", nil, []OrderBySpec{{Column: "id"}}, "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW").As("running_total")
+func Over(funcExpr string, partitionBy []string, orderBy []OrderBySpec, frame string) *WindowFunc {
+	return &WindowFunc{Function: funcExpr, PartitionBy: partitionBy, OrderBy: orderBy, Frame: frame}
+}
+
+// As sets the alias of the window function.
+func (w *WindowFunc) As(alias string) *WindowFunc {
+	w.Alias = alias
+	return w
+}
+
+// SelectSQL implements SelectExpr.
+func (w *WindowFunc) SelectSQL(argIndex *int) (string, []interface{}, error) {
+	if strings.TrimSpace(w.Function) == "" {
+		return "", nil, fmt.Errorf("window function expression is empty")
+	}
+	var window []string
+	if len(w.PartitionBy) > 0 {
+		quoted := make([]string, len(w.PartitionBy))
+		for i, col := range w.PartitionBy {
+			if !isValidIdentifier(col) {
+				return "", nil, fmt.Errorf("invalid partition by column: '%s'", col)
+			}
+			quoted[i] = QuoteIdentifier(col)
+		}
+		window = append(window, "PARTITION BY "+strings.Join(quoted, ", "))
+	}
+	orderClause, err := buildOrderByClause(w.OrderBy)
+	if err != nil {
+		return "", nil, err
+	}
+	if orderClause != "" {
+		window = append(window, strings.TrimPrefix(orderClause, " "))
+	}
+	if w.Frame != "" {
+		window = append(window, w.Frame)
+	}
+	return withAlias(fmt.Sprintf("%s OVER (%s)", w.Function, strings.Join(window, " ")), w.Alias)
+}
+
+// withAlias appends a quoted AS alias to an expression if alias is not empty.
+func withAlias(expr, alias string) (string, []interface{}, error) {
+	if alias == "" {
+		return expr, nil, nil
+	}
+	if !isValidIdentifier(alias) {
+		return "", nil, fmt.Errorf("invalid alias: '%s'", alias)
+	}
+	return fmt.Sprintf("%s AS %s", expr, QuoteIdentifier(alias)), nil, nil
+}
+
+// QueryBuilder builds SELECT queries that go beyond FetchMany, such as
+// DISTINCT, GROUP BY/HAVING, and aggregate columns.
+//
+// Results are never cached, since they may not contain complete rows.
+//
+// Example:
+//
+//	rows, err := OrdersTable.Query().
+//	    Select("user_id", Count("*").As("orders"), Sum("total").As("spent")).
+//	    Where(map[string]interface{}{"status": "paid"}).
+//	    GroupBy("user_id").
+//	    Having("COUNT(*) > 5").
+//	    OrderBy(OrderBySpec{Column: "user_id"}).
+//	    FetchMany(ctx)
+type QueryBuilder struct {
+	table    *Table
+	ctes     []cteDef
+	from     string
+	joins    []lateralJoin
+	distinct bool
+	columns  []interface{}
+	where    []interface{}
+	groupBy  []string
+	having   []interface{}
+	orderBy  []OrderBySpec
+	limit    int
+	offset   int
+	maxRows  int
+	setOp    string
+	operands []*QueryBuilder
+	err      error
+}
+
+// cteDef is a single common table expression in a WITH clause.
+type cteDef struct {
+	name      string
+	query     string
+	args      []interface{}
+	recursive bool
+}
+
+// lateralJoin is a LATERAL subquery joined to the FROM clause.
+type lateralJoin struct {
+	alias string
+	query string
+	args  []interface{}
+	left  bool
+}
+
+// lateralMarkerColumn names the column that SELECT * puts in front of the columns of each LATERAL join;
+// its value is the join's alias, which fetchRowResult uses to key colliding column names as "alias.column".
+const lateralMarkerColumn = "pggo.lateral"
+
+// Query starts a new QueryBuilder for the table.
+func (t *Table) Query() *QueryBuilder {
+	return &QueryBuilder{table: t}
+}
+
+// Select sets the SELECT list. Each column is either a column name (string, validated and quoted)
+// or a SelectExpr. If no columns are given, all columns (*) are selected.
+func (qb *QueryBuilder) Select(columns ...interface{}) *QueryBuilder {
+	for _, col := range columns {
+		switch v := col.(type) {
+		case string:
+			if v != "*" && !isValidIdentifier(v) {
+				qb.setErr(fmt.Errorf("invalid select column: '%s'", v))
+			}
+		case SelectExpr:
+		default:
+			qb.setErr(fmt.Errorf("unsupported select column type %T", col))
+		}
+	}
+	qb.columns = append(qb.columns, columns...)
+	return qb
+}
+
+// With adds a common table expression (WITH name AS (query)) in front of the query.
+// The CTE query's parameters ($1, $2, ... or ?) are relative to the CTE and are renumbered
+// so that numbering is sequential across all CTEs and the main query.
+// Use From to select from the CTE instead of the table.
+//
+// Example:
+//
+//	// WITH "big_spenders" AS (SELECT user_id FROM orders GROUP BY user_id HAVING SUM(total) > $1)
+//	// SELECT * FROM "users" WHERE ...
+//	qb := UsersTable.Query().
+//	    With("big_spenders", "SELECT user_id FROM orders GROUP BY user_id HAVING SUM(total) > $1", 1000).
+//	    Where(RawCondition("id IN (SELECT user_id FROM big_spenders)"))
+func (qb *QueryBuilder) With(name, query string, args ...interface{}) *QueryBuilder {
+	return qb.addCTE(name, query, args, false)
+}
+
+// WithRecursive adds a recursive common table expression. Its query may reference name itself.
+// If any CTE is recursive, the WITH clause is emitted as WITH RECURSIVE.
+func (qb *QueryBuilder) WithRecursive(name, query string, args ...interface{}) *QueryBuilder {
+	return qb.addCTE(name, query, args, true)
+}
+
+// WithRecursiveUnion adds a recursive common table expression built from its two terms:
+// WITH RECURSIVE name AS (initialQuery UNION ALL recursiveQuery). The recursive query references name.
+// Parameters ($1, $2, ... or ?) are numbered sequentially across both queries, and renumbered like With.
+// Bound the recursive query (e.g. with a depth counter) if the data can contain cycles.
+//
+// Example:
+//
+//	// All subordinates of employee 1, at most 20 levels down
+//	rows, err := EmployeesTable.Query().
+//	    WithRecursiveUnion("reports",
+//	        "SELECT id, manager_id, name, 1 AS depth FROM employees WHERE id = $1",
+//	        "SELECT e.id, e.manager_id, e.name, r.depth + 1 FROM employees e JOIN reports r ON e.manager_id = r.id WHERE r.depth < $2",
+//	        1, 20).
+//	    From("reports").
+//	    FetchMany(ctx)
+func (qb *QueryBuilder) WithRecursiveUnion(name, initialQuery, recursiveQuery string, args ...interface{}) *QueryBuilder {
+	return qb.addCTE(name, initialQuery+" UNION ALL "+recursiveQuery, args, true)
+}
+
+// addCTE validates and records a common table expression.
+func (qb *QueryBuilder) addCTE(name, query string, args []interface{}, recursive bool) *QueryBuilder {
+	if !isValidIdentifier(name) {
+		qb.setErr(fmt.Errorf("invalid CTE name: '%s'", name))
+	}
+	qb.ctes = append(qb.ctes, cteDef{name: name, query: query, args: args, recursive: recursive})
+	return qb
+}
+
+// From sets the relation to select from, typically the name of a CTE added with With.
+// Defaults to the table the builder was created from.
+func (qb *QueryBuilder) From(source string) *QueryBuilder {
+	if !isValidIdentifier(source) {
+		qb.setErr(fmt.Errorf("invalid from source: '%s'", source))
+	}
+	qb.from = source
+	return qb
+}
+
+// LateralJoin joins a subquery that may reference the columns of the FROM table (or earlier joins),
+// evaluated once per row: FROM "table" CROSS JOIN LATERAL (subquery) AS "alias". Rows for which the
+// subquery returns nothing are dropped; use LeftLateralJoin to keep them.
+// The subquery's parameters ($1, $2, ... or ?) are relative to the subquery and are renumbered like With.
+//
+// Without Select, the subquery's columns are added to each row; a column whose name the row already has
+// is keyed "alias.column" instead (e.g. "latest.id"). Qualify raw conditions on such columns
+// ("users.id = $1"), since an unqualified name is ambiguous to PostgreSQL.
+//
+// Example:
+//
+//	// Each user with their most recent order
+//	rows, err := UsersTable.Query().
+//	    LateralJoin("latest", "SELECT * FROM orders WHERE orders.user_id = users.id ORDER BY created_at DESC LIMIT $1", 1).
+//	    FetchMany(ctx)
+func (qb *QueryBuilder) LateralJoin(alias, subquery string, args ...interface{}) *QueryBuilder {
+	return qb.addLateralJoin(alias, subquery, args, false)
+}
+
+// LeftLateralJoin is LateralJoin with LEFT JOIN LATERAL (subquery) AS "alias" ON TRUE: rows for which
+// the subquery returns nothing are kept, with NULL for the subquery's columns.
+func (qb *QueryBuilder) LeftLateralJoin(alias, subquery string, args ...interface{}) *QueryBuilder {
+	return qb.addLateralJoin(alias, subquery, args, true)
+}
+
+// addLateralJoin validates and records a LATERAL join.
+func (qb *QueryBuilder) addLateralJoin(alias, query string, args []interface{}, left bool) *QueryBuilder {
+	if !isValidIdentifier(alias) {
+		qb.setErr(fmt.Errorf("invalid lateral join alias: '%s'", alias))
+	}
+	qb.joins = append(qb.joins, lateralJoin{alias: alias, query: query, args: args, left: left})
+	return qb
+}
+
+// UnionAll combines the rows of qb and other: (SELECT ...) UNION ALL (SELECT ...).
+// Duplicates are kept. Both queries must return the same number of columns with compatible types.
+//
+// It returns a new builder for the combined query; its OrderBy, Limit and Offset apply to the
+// combined rows, and parameters are numbered sequentially across both queries.
+// Conditions, columns and grouping belong on the operands, not on the combined builder.
+//
+// Example:
+//
+//	rows, err := ActiveUsersTable.Query().Select("id", "email").
+//	    UnionAll(ArchivedUsersTable.Query().Select("id", "email").Where(map[string]interface{}{"restorable": true})).
+//	    OrderBy(OrderBySpec{Column: "id"}).
+//	    Limit(50).
+//	    FetchMany(ctx)
+func (qb *QueryBuilder) UnionAll(other *QueryBuilder) *QueryBuilder {
+	return qb.combine("UNION ALL", other)
+}
+
+// Union combines the rows of qb and other without duplicates: (SELECT ...) UNION (SELECT ...). See UnionAll.
+func (qb *QueryBuilder) Union(other *QueryBuilder) *QueryBuilder {
+	return qb.combine("UNION", other)
+}
+
+// Intersect returns the distinct rows that both qb and other return: (SELECT ...) INTERSECT (SELECT ...). See UnionAll.
+func (qb *QueryBuilder) Intersect(other *QueryBuilder) *QueryBuilder {
+	return qb.combine("INTERSECT", other)
+}
+
+// Except returns the distinct rows of qb that other does not return: (SELECT ...) EXCEPT (SELECT ...). See UnionAll.
+func (qb *QueryBuilder) Except(other *QueryBuilder) *QueryBuilder {
+	return qb.combine("EXCEPT", other)
+}
+
+// combine returns a new builder that joins qb and other with a set operator.
+func (qb *QueryBuilder) combine(setOp string, other *QueryBuilder) *QueryBuilder {
+	combined := &QueryBuilder{table: qb.table, setOp: setOp, operands: []*QueryBuilder{qb, other}}
+	if other == nil {
+		combined.setErr(fmt.Errorf("%s requires a query to combine with", setOp))
+	}
+	return combined
+}
+
+// Distinct makes the query return only distinct rows (SELECT DISTINCT).
+func (qb *QueryBuilder) Distinct() *QueryBuilder {
+	qb.distinct = true
+	return qb
+}
+
+// Where adds conditions to the WHERE clause. It accepts the same arguments as FetchMany.
+func (qb *QueryBuilder) Where(whereArgs ...interface{}) *QueryBuilder {
+	qb.where = append(qb.where, whereArgs...)
+	return qb
+}
+
+// GroupBy adds columns to the GROUP BY clause. Column names are validated and quoted.
+func (qb *QueryBuilder) GroupBy(columns ...string) *QueryBuilder {
+	for _, col := range columns {
+		if !isValidIdentifier(col) {
+			qb.setErr(fmt.Errorf("invalid group by column: '%s'", col))
+		}
+	}
+	qb.groupBy = append(qb.groupBy, columns...)
+	return qb
+}
+
+// Having adds conditions to the HAVING clause. It accepts the same arguments as Where;
+// use raw SQL strings to filter on aggregates (e.g. "COUNT(*) > 5").
+func (qb *QueryBuilder) Having(havingArgs ...interface{}) *QueryBuilder {
+	qb.having = append(qb.having, havingArgs...)
+	return qb
+}
+
+// OrderBy adds columns to the ORDER BY clause.
+func (qb *QueryBuilder) OrderBy(orderBy ...OrderBySpec) *QueryBuilder {
+	qb.orderBy = append(qb.orderBy, orderBy...)
+	return qb
+}
+
+// Limit sets the maximum number of rows to return. Zero means no limit.
+func (qb *QueryBuilder) Limit(limit int) *QueryBuilder {
+	qb.limit = limit
+	return qb
+}
+
+// MaxRecursion caps the number of rows the query returns at n. It overrides a larger Limit. Zero means no cap.
+//
+// It only caps the returned rows: it stops a recursive CTE early only if the outer query streams its rows.
+// An ORDER BY, GROUP BY, DISTINCT or aggregate makes PostgreSQL run the whole recursion first, so a cycle
+// in the data still recurses forever. Bound the recursive term itself (e.g. with a depth counter) for that.
+func (qb *QueryBuilder) MaxRecursion(n int) *QueryBuilder {
+	qb.maxRows = n
+	return qb
+}
+
+// Offset sets the number of rows to skip.
+func (qb *QueryBuilder) Offset(offset int) *QueryBuilder {
+	qb.offset = offset
+	return qb
+}
+
+// setErr records the first error encountered while building the query.
+func (qb *QueryBuilder) setErr(err error) {
+	if qb.err == nil {
+		qb.err = err
+	}
+}
+
+// ToSQL builds the SQL statement and its arguments without executing it.
+func (qb *QueryBuilder) ToSQL() (string, []interface{}, error) {
+	argIndex := 1
+	return qb.build(&argIndex)
+}
+
+// build renders the query, numbering parameters starting at argIndex.
+func (qb *QueryBuilder) build(argIndex *int) (string, []interface{}, error) {
+	if qb.err != nil {
+		return "", nil, qb.err
+	}
+	if qb.setOp != "" {
+		return qb.buildCombined(argIndex)
+	}
+
+	var sb strings.Builder
+	var args []interface{}
+
+	withClause, withArgs, err := qb.buildWithClause(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	sb.WriteString(withClause)
+	args = append(args, withArgs...)
+
+	sb.WriteString("SELECT ")
+	if qb.distinct {
+		sb.WriteString("DISTINCT ")
+	}
+
+	selectList, selectArgs, err := qb.buildSelectList(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	sb.WriteString(selectList)
+	args = append(args, selectArgs...)
+
+	sb.WriteString(" FROM ")
+	sb.WriteString(QuoteIdentifier(qb.source()))
+	joinClause, joinArgs, err := qb.buildJoins(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	sb.WriteString(joinClause)
+	args = append(args, joinArgs...)
+
+	// The table's scopes and soft-delete filter apply when selecting from the table itself, not from a CTE
+	buildWhere := buildWhereClause
+	if qb.from == "" {
+		buildWhere = qb.table.readWhereClause
+	}
+	whereClause, whereArgs, err := buildWhere(qb.where, argIndex)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	sb.WriteString(whereClause)
+	args = append(args, whereArgs...)
+
+	if len(qb.groupBy) > 0 {
+		quoted := make([]string, len(qb.groupBy))
+		for i, col := range qb.groupBy {
+			quoted[i] = QuoteIdentifier(col)
+		}
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(strings.Join(quoted, ", "))
+	}
+
+	if len(qb.having) > 0 {
+		havingConditions, havingArgs, err := buildConditions(qb.having, argIndex)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to build having clause: %w", err)
+		}
+		if len(havingConditions) > 0 {
+			sb.WriteString(" HAVING ")
+			sb.WriteString(strings.Join(havingConditions, " AND "))
+		}
+		args = append(args, havingArgs...)
+	}
+
+	tail, err := qb.buildTail()
+	if err != nil {
+		return "", nil, err
+	}
+	sb.WriteString(tail)
+
+	return sb.String(), args, nil
+}
+
+// buildCombined renders a query built by UnionAll, Union, Intersect or Except:
+// (left) OP (right) followed by the combined ORDER BY, LIMIT and OFFSET.
+func (qb *QueryBuilder) buildCombined(argIndex *int) (string, []interface{}, error) {
+	if len(qb.ctes) > 0 || qb.from != "" || len(qb.joins) > 0 || qb.distinct || len(qb.columns) > 0 ||
+		len(qb.where) > 0 || len(qb.groupBy) > 0 || len(qb.having) > 0 {
+		return "", nil, fmt.Errorf("only OrderBy, Limit and Offset can be applied to a %s query; add the rest to its operands", qb.setOp)
+	}
+	left, args, err := qb.operands[0].build(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	right, rightArgs, err := qb.operands[1].build(argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(args, rightArgs...)
+
+	tail, err := qb.buildTail()
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("(%s) %s (%s)%s", left, qb.setOp, right, tail), args, nil
+}
+
+// buildTail renders the ORDER BY, LIMIT and OFFSET clauses.
+func (qb *QueryBuilder) buildTail() (string, error) {
+	orderClause, err := buildOrderByClause(qb.orderBy)
+	if err != nil {
+		return "", err
+	}
+	tail := orderClause
+
+	limit := qb.limit
+	if qb.maxRows > 0 && (limit <= 0 || limit > qb.maxRows) {
+		limit = qb.maxRows
+	}
+	if limit > 0 {
+		tail += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if qb.offset > 0 {
+		tail += fmt.Sprintf(" OFFSET %d", qb.offset)
+	}
+	return tail, nil
+}
+
+// buildWithClause renders the WITH clause (including a trailing space), or "" if there are no CTEs.
+func (qb *QueryBuilder) buildWithClause(argIndex *int) (string, []interface{}, error) {
+	if len(qb.ctes) == 0 {
+		return "", nil, nil
+	}
+	keyword := "WITH "
+	parts := make([]string, 0, len(qb.ctes))
+	var args []interface{}
+	for _, cte := range qb.ctes {
+		if cte.recursive {
+			keyword = "WITH RECURSIVE "
+		}
+		query, err := renumberPlaceholders(cte.query, len(cte.args), argIndex)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid CTE '%s': %w", cte.name, err)
+		}
+		parts = append(parts, fmt.Sprintf("%s AS (%s)", QuoteIdentifier(cte.name), query))
+		args = append(args, cte.args...)
+	}
+	return keyword + strings.Join(parts, ", ") + " ", args, nil
+}
+
+// source returns the relation the query selects from: the From source or the table.
+func (qb *QueryBuilder) source() string {
+	if qb.from != "" {
+		return qb.from
+	}
+	return qb.table.Name
+}
+
+// buildJoins renders the LATERAL joins (including a leading space), or "" if there are none.
+func (qb *QueryBuilder) buildJoins(argIndex *int) (string, []interface{}, error) {
+	var sb strings.Builder
+	var args []interface{}
+	for _, join := range qb.joins {
+		query, err := renumberPlaceholders(join.query, len(join.args), argIndex)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid lateral join '%s': %w", join.alias, err)
+		}
+		if join.left {
+			sb.WriteString(fmt.Sprintf(" LEFT JOIN LATERAL (%s) AS %s ON TRUE", query, QuoteIdentifier(join.alias)))
+		} else {
+			sb.WriteString(fmt.Sprintf(" CROSS JOIN LATERAL (%s) AS %s", query, QuoteIdentifier(join.alias)))
+		}
+		args = append(args, join.args...)
+	}
+	return sb.String(), args, nil
+}
+
+// buildSelectList renders the SELECT list, defaulting to "*".
+// With LATERAL joins, "*" selects each relation in turn, with a marker column in front of each join's columns.
+func (qb *QueryBuilder) buildSelectList(argIndex *int) (string, []interface{}, error) {
+	if len(qb.columns) == 0 && len(qb.joins) > 0 {
+		parts := []string{QuoteIdentifier(qb.source()) + ".*"}
+		for _, join := range qb.joins {
+			parts = append(parts, fmt.Sprintf("%s AS %s", quoteLiteral(join.alias), QuoteIdentifier(lateralMarkerColumn)),
+				QuoteIdentifier(join.alias)+".*")
+		}
+		return strings.Join(parts, ", "), nil, nil
+	}
+	if len(qb.columns) == 0 {
+		return "*", nil, nil
+	}
+	parts := make([]string, 0, len(qb.columns))
+	var args []interface{}
+	for _, col := range qb.columns {
+		switch v := col.(type) {
+		case string:
+			if v == "*" {
+				parts = append(parts, v)
+			} else {
+				parts = append(parts, QuoteIdentifier(v))
+			}
+		case SelectExpr:
+			sql, exprArgs, err := v.SelectSQL(argIndex)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, sql)
+			args = append(args, exprArgs...)
+		}
+	}
+	return strings.Join(parts, ", "), args, nil
+}
+
+// FetchMany executes the query and returns all resulting rows.
+func (qb *QueryBuilder) FetchMany(ctx context.Context) ([]map[string]interface{}, error) {
+	query, params, err := qb.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	return qb.table.queryRows(ctx, "Query", query, params)
+}
+
+// FetchOne executes the query with LIMIT 1 and returns the resulting row.
+// Returns an error if no rows are found.
+func (qb *QueryBuilder) FetchOne(ctx context.Context) (map[string]interface{}, error) {
+	one := *qb
+	one.limit = 1
+	rows, err := one.FetchMany(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows found")
+	}
+	return rows[0], nil
+}
+
+// Count returns the number of rows the query returns, Limit and Offset included, by running
+// SELECT COUNT(*) FROM (query). It works on combined queries (UnionAll and friends) as well.
+func (qb *QueryBuilder) Count(ctx context.Context) (int64, error) {
+	query, params, err := qb.ToSQL()
+	if err != nil {
+		return 0, err
+	}
+	countQuery := fmt.Sprintf("SELECT COUNT(*) AS total FROM (%s) AS %s", query, QuoteIdentifier("counted"))
+	rows, err := qb.table.queryRows(ctx, "QueryCount", countQuery, params)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("failed to count rows: no result returned")
+	}
+	total, _ := rows[0]["total"].(int64)
+	return total, nil
+}
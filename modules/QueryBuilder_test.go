@@ -0,0 +1,456 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// queryTest is a QueryBuilder case: the builder and the SQL and arguments it must produce.
+type queryTest struct {
+	name     string
+	query    *QueryBuilder
+	want     string
+	wantArgs []interface{}
+}
+
+func runQueryTests(t *testing.T, tests []queryTest) {
+	t.Helper()
+	for _, test := range tests {
+		sql, args, err := test.query.ToSQL()
+		if err != nil {
+			t.Errorf("%s: ToSQL: %v", test.name, err)
+			continue
+		}
+		if sql != test.want {
+			t.Errorf("%s:\n got  %s\n want %s", test.name, sql, test.want)
+		}
+		if !reflect.DeepEqual(args, test.wantArgs) {
+			t.Errorf("%s: args = %v, want %v", test.name, args, test.wantArgs)
+		}
+	}
+}
+
+func TestWindowFunctions(t *testing.T) {
+	items := &Table{Name: "items"}
+	runQueryTests(t, []queryTest{
+		{
+			name: "row number per partition",
+			query: items.Query().Select("id",
+				Over("ROW_NUMBER()", []string{"category"}, []OrderBySpec{{Column: "created_at", Direction: "DESC"}}, "").As("rank")),
+			want: `SELECT "id", ROW_NUMBER() OVER (PARTITION BY "category" ORDER BY "created_at" DESC NULLS LAST) AS "rank" FROM "items"`,
+		},
+		{
+			name: "running total with a frame",
+			query: items.Query().Select("id",
+				Over("SUM(amount)", nil, []OrderBySpec{{Column: "id"}}, "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW").As("total")).
+				Where(map[string]interface{}{"category": "a"}),
+			want:     `SELECT "id", SUM(amount) OVER (ORDER BY "id" ASC NULLS LAST ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS "total" FROM "items" WHERE "category" = $1`,
+			wantArgs: []interface{}{"a"},
+		},
+		{
+			name:  "empty window",
+			query: items.Query().Select(Over("COUNT(*)", nil, nil, "")),
+			want:  `SELECT COUNT(*) OVER () FROM "items"`,
+		},
+	})
+
+	for _, w := range []*WindowFunc{
+		Over("ROW_NUMBER()", []string{"category; DROP TABLE items"}, nil, ""),
+		Over("ROW_NUMBER()", nil, []OrderBySpec{{Column: "id\""}}, ""),
+		Over(" ", nil, nil, ""),
+	} {
+		if _, _, err := items.Query().Select(w).ToSQL(); err == nil {
+			t.Errorf("Over(%q, %v, %v) succeeded, want an error", w.Function, w.PartitionBy, w.OrderBy)
+		}
+	}
+}
+
+func TestWindowFunctionRanking(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil,
+		Column{Name: "category", DataType: *DataType{}.Text()},
+		Column{Name: "created_at", DataType: *DataType{}.Integer()})
+	for _, row := range []map[string]interface{}{
+		{"category": "a", "created_at": 1},
+		{"category": "a", "created_at": 3},
+		{"category": "a", "created_at": 2},
+		{"category": "b", "created_at": 5},
+	} {
+		if _, err := table.Insert(row); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	rows, err := table.Query().
+		Select("category", "created_at",
+			Over("ROW_NUMBER()", []string{"category"}, []OrderBySpec{{Column: "created_at", Direction: "DESC"}}, "").As("rank")).
+		OrderBy(OrderBySpec{Column: "category"}, OrderBySpec{Column: "rank"}).
+		FetchMany(context.Background())
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	want := [][3]interface{}{{"a", int32(3), int64(1)}, {"a", int32(2), int64(2)}, {"a", int32(1), int64(3)}, {"b", int32(5), int64(1)}}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if got := [3]interface{}{row["category"], row["created_at"], row["rank"]}; got != want[i] {
+			t.Errorf("row %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestAggregateExpressions(t *testing.T) {
+	orders := &Table{Name: "orders"}
+	runQueryTests(t, []queryTest{
+		{
+			name: "json_agg of rows",
+			query: orders.Query().Select("user_id", JsonAgg("orders", OrderBySpec{Column: "created_at"}).As("orders")).
+				GroupBy("user_id"),
+			want: `SELECT "user_id", json_agg(orders ORDER BY "created_at" ASC NULLS LAST) AS "orders" FROM "orders" GROUP BY "user_id"`,
+		},
+		{
+			name:  "jsonb_object_agg",
+			query: orders.Query().Select(JsonbObjectAgg("key", "value").As("settings")),
+			want:  `SELECT jsonb_object_agg(key, value) AS "settings" FROM "orders"`,
+		},
+		{
+			name:  "array_agg",
+			query: orders.Query().Select(ArrayAgg("tag")),
+			want:  `SELECT array_agg(tag) FROM "orders"`,
+		},
+		{
+			name: "string_agg parameters follow the where clause numbering",
+			query: orders.Query().
+				Select("user_id", StringAgg("status", ", ", OrderBySpec{Column: "status", Direction: "DESC"}).As("statuses")).
+				Where(map[string]interface{}{"total": Gt(10)}).
+				GroupBy("user_id"),
+			want:     `SELECT "user_id", string_agg(status, $1 ORDER BY "status" DESC NULLS LAST) AS "statuses" FROM "orders" WHERE "total" > $2 GROUP BY "user_id"`,
+			wantArgs: []interface{}{", ", 10},
+		},
+	})
+
+	for _, expr := range []*OrderedAggregateExpr{
+		JsonAgg(" "),
+		JsonAgg("orders").As("bad alias\""),
+		{Function: "json_agg(); DROP TABLE orders; --", Expressions: []string{"x"}},
+	} {
+		if _, _, err := orders.Query().Select(expr).ToSQL(); err == nil {
+			t.Errorf("aggregate %+v succeeded, want an error", *expr)
+		}
+	}
+}
+
+func TestJsonAggByUser(t *testing.T) {
+	conn := newTestConnection(t)
+	orders := newTestTable(t, conn, nil,
+		Column{Name: "user_id", DataType: *DataType{}.Integer()},
+		Column{Name: "total", DataType: *DataType{}.Integer()})
+	for _, order := range []map[string]interface{}{
+		{"user_id": 1, "total": 30},
+		{"user_id": 1, "total": 10},
+		{"user_id": 2, "total": 20},
+	} {
+		if _, err := orders.Insert(order); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	rows, err := orders.Query().
+		Select("user_id",
+			JsonAgg(fmt.Sprintf("json_build_object('total', %s.total)", QuoteIdentifier(orders.Name)), OrderBySpec{Column: "total"}).As("orders"),
+			StringAgg("total::text", ",", OrderBySpec{Column: "total"}).As("totals")).
+		GroupBy("user_id").
+		OrderBy(OrderBySpec{Column: "user_id"}).
+		FetchMany(context.Background())
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d groups, want 2", len(rows))
+	}
+	want := []interface{}{
+		map[string]interface{}{"total": float64(10)},
+		map[string]interface{}{"total": float64(30)},
+	}
+	if got := rows[0]["orders"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("orders of user 1 = %#v, want %#v", got, want)
+	}
+	if got := rows[0]["totals"]; got != "10,30" {
+		t.Errorf("totals of user 1 = %v, want 10,30", got)
+	}
+	if got := rows[1]["orders"]; !reflect.DeepEqual(got, []interface{}{map[string]interface{}{"total": float64(20)}}) {
+		t.Errorf("orders of user 2 = %#v", got)
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	active := &Table{Name: "active_users"}
+	archived := &Table{Name: "archived_users"}
+	left := func() *QueryBuilder {
+		return active.Query().Select("id", "email").Where(map[string]interface{}{"country": "BD"})
+	}
+	right := func() *QueryBuilder {
+		return archived.Query().Select("id", "email").Where(map[string]interface{}{"restorable": true}, "archived_year > $1", 2023)
+	}
+	runQueryTests(t, []queryTest{
+		{
+			name:     "union all with sequential parameters",
+			query:    left().UnionAll(right()),
+			want:     `(SELECT "id", "email" FROM "active_users" WHERE "country" = $1) UNION ALL (SELECT "id", "email" FROM "archived_users" WHERE "restorable" = $2 AND archived_year > $3)`,
+			wantArgs: []interface{}{"BD", true, 2023},
+		},
+		{
+			name:     "union with order, limit and offset",
+			query:    left().Union(right()).OrderBy(OrderBySpec{Column: "id"}).Limit(50).Offset(100),
+			want:     `(SELECT "id", "email" FROM "active_users" WHERE "country" = $1) UNION (SELECT "id", "email" FROM "archived_users" WHERE "restorable" = $2 AND archived_year > $3) ORDER BY "id" ASC NULLS LAST LIMIT 50 OFFSET 100`,
+			wantArgs: []interface{}{"BD", true, 2023},
+		},
+		{
+			name:     "nested combination",
+			query:    left().Intersect(right()).Except(active.Query().Select("id", "email").Where(map[string]interface{}{"banned": true})),
+			want:     `((SELECT "id", "email" FROM "active_users" WHERE "country" = $1) INTERSECT (SELECT "id", "email" FROM "archived_users" WHERE "restorable" = $2 AND archived_year > $3)) EXCEPT (SELECT "id", "email" FROM "active_users" WHERE "banned" = $4)`,
+			wantArgs: []interface{}{"BD", true, 2023, true},
+		},
+	})
+
+	if _, _, err := left().UnionAll(right()).Where(map[string]interface{}{"id": 1}).ToSQL(); err == nil {
+		t.Error("Where on a combined query succeeded, want an error")
+	}
+	if _, _, err := left().UnionAll(nil).ToSQL(); err == nil {
+		t.Error("UnionAll(nil) succeeded, want an error")
+	}
+}
+
+func TestUnionAllKeepsDuplicates(t *testing.T) {
+	conn := newTestConnection(t)
+	first := newTestTable(t, conn, nil, Column{Name: "email", DataType: *DataType{}.Text()})
+	second := newTestTable(t, conn, nil, Column{Name: "email", DataType: *DataType{}.Text()})
+	for _, insert := range []struct {
+		table *Table
+		email string
+	}{{first, "a@example.com"}, {first, "b@example.com"}, {second, "a@example.com"}, {second, "c@example.com"}} {
+		if _, err := insert.table.Insert(map[string]interface{}{"email": insert.email}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	emails := func(qb *QueryBuilder) []interface{} {
+		t.Helper()
+		rows, err := qb.OrderBy(OrderBySpec{Column: "email"}).FetchMany(ctx)
+		if err != nil {
+			t.Fatalf("FetchMany: %v", err)
+		}
+		var emails []interface{}
+		for _, row := range rows {
+			emails = append(emails, row["email"])
+		}
+		return emails
+	}
+	all := emails(first.Query().Select("email").UnionAll(second.Query().Select("email")))
+	if want := []interface{}{"a@example.com", "a@example.com", "b@example.com", "c@example.com"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("UnionAll = %v, want %v", all, want)
+	}
+	distinct := emails(first.Query().Select("email").Union(second.Query().Select("email")))
+	if want := []interface{}{"a@example.com", "b@example.com", "c@example.com"}; !reflect.DeepEqual(distinct, want) {
+		t.Errorf("Union = %v, want %v", distinct, want)
+	}
+
+	count, err := first.Query().Select("email").UnionAll(second.Query().Select("email").Where(map[string]interface{}{"email": "c@example.com"})).Count(ctx)
+	if err != nil || count != 3 {
+		t.Errorf("Count of UnionAll = %d, %v; want 3", count, err)
+	}
+}
+
+func TestLateralJoin(t *testing.T) {
+	users := &Table{Name: "users"}
+	latest := "SELECT * FROM orders WHERE orders.user_id = users.id AND orders.total > $1 ORDER BY created_at DESC LIMIT $2"
+	runQueryTests(t, []queryTest{
+		{
+			name: "cross join with renumbered parameters",
+			query: users.Query().
+				With("recent", "SELECT id FROM users WHERE created_at > $1", 2020).
+				LateralJoin("latest", latest, 10, 1).
+				Where(map[string]interface{}{"active": true}),
+			want: `WITH "recent" AS (SELECT id FROM users WHERE created_at > $1) ` +
+				`SELECT "users".*, 'latest' AS "pggo.lateral", "latest".* FROM "users" ` +
+				`CROSS JOIN LATERAL (SELECT * FROM orders WHERE orders.user_id = users.id AND orders.total > $2 ORDER BY created_at DESC LIMIT $3) AS "latest" ` +
+				`WHERE "active" = $4`,
+			wantArgs: []interface{}{2020, 10, 1, true},
+		},
+		{
+			name: "left join with a select list",
+			query: users.Query().Select("name", Expr("latest.total")).
+				LeftLateralJoin("latest", "SELECT total FROM orders WHERE orders.user_id = users.id LIMIT ?", 1),
+			want:     `SELECT "name", latest.total FROM "users" LEFT JOIN LATERAL (SELECT total FROM orders WHERE orders.user_id = users.id LIMIT $1) AS "latest" ON TRUE`,
+			wantArgs: []interface{}{1},
+		},
+	})
+
+	if _, _, err := users.Query().LateralJoin("bad alias", latest, 10, 1).ToSQL(); err == nil {
+		t.Error("LateralJoin with an invalid alias succeeded")
+	}
+	if _, _, err := users.Query().LateralJoin("latest", latest, 10).ToSQL(); err == nil {
+		t.Error("LateralJoin with a missing parameter succeeded")
+	}
+}
+
+func TestLateralJoinLatestOrder(t *testing.T) {
+	conn := newTestConnection(t)
+	users := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+	orders := newTestTable(t, conn, nil,
+		Column{Name: "user_id", DataType: *DataType{}.Integer()},
+		Column{Name: "name", DataType: *DataType{}.Text()})
+
+	ids := map[string]interface{}{}
+	for _, name := range []string{"alice", "bob"} {
+		row, err := users.Insert(map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		ids[name] = row["id"]
+	}
+	orderIDs := map[string]interface{}{}
+	for _, name := range []string{"first", "second"} {
+		row, err := orders.Insert(map[string]interface{}{"user_id": ids["alice"], "name": name})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		orderIDs[name] = row["id"]
+	}
+
+	latest := fmt.Sprintf("SELECT * FROM %s o WHERE o.user_id = %s.id ORDER BY o.id DESC LIMIT $1",
+		QuoteIdentifier(orders.Name), QuoteIdentifier(users.Name))
+	ctx := context.Background()
+
+	rows, err := users.Query().LateralJoin("latest", latest, 1).FetchMany(ctx)
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("CROSS JOIN LATERAL returned %d rows, want only alice's", len(rows))
+	}
+	row := rows[0]
+	if row["id"] != ids["alice"] || row["name"] != "alice" || row["latest.id"] != orderIDs["second"] ||
+		row["latest.name"] != "second" || row["user_id"] != ids["alice"] {
+		t.Errorf("row = %v, want alice with her second order under latest.id and latest.name", row)
+	}
+
+	// Ordering by "id" or "name" would be ambiguous, since the join has the same columns
+	rows, err = users.Query().LeftLateralJoin("latest", latest, 1).Where(map[string]interface{}{"user_id": IsNull()}).FetchMany(ctx)
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "bob" || rows[0]["latest.id"] != nil {
+		t.Errorf("LEFT JOIN LATERAL rows without an order = %v, want bob", rows)
+	}
+}
+
+func TestFullTextSearch(t *testing.T) {
+	posts := &Table{Name: "posts"}
+	runQueryTests(t, []queryTest{
+		{
+			name: "ranked search",
+			query: posts.Query().Select("id", TsRank("search_vector", "postgres & pool", "").As("rank")).
+				Where(map[string]interface{}{"search_vector": TsMatch("postgres & pool", "")}).
+				OrderBy(OrderBySpec{Column: "rank", Direction: "DESC"}),
+			want: `SELECT "id", ts_rank("search_vector", to_tsquery($1::regconfig, $2)) AS "rank" FROM "posts" ` +
+				`WHERE "search_vector" @@ to_tsquery($3::regconfig, $4) ORDER BY "rank" DESC NULLS LAST`,
+			wantArgs: []interface{}{"english", "postgres & pool", "english", "postgres & pool"},
+		},
+		{
+			name:     "rank with a config",
+			query:    posts.Query().Select(TsRank("search_vector", "pool", "simple")),
+			want:     `SELECT ts_rank("search_vector", to_tsquery($1::regconfig, $2)) FROM "posts"`,
+			wantArgs: []interface{}{"simple", "pool"},
+		},
+	})
+
+	runWhereTests(t, []whereTest{
+		{
+			name:      "match with a config",
+			whereArgs: []interface{}{map[string]interface{}{"search_vector": TsMatch("pool | pooling", "simple")}},
+			want:      ` WHERE "search_vector" @@ to_tsquery($1::regconfig, $2)`,
+			wantArgs:  []interface{}{"simple", "pool | pooling"},
+		},
+		{
+			name:      "phrase",
+			whereArgs: []interface{}{map[string]interface{}{"search_vector": TsMatchPhrase("connection pool", "")}},
+			want:      ` WHERE "search_vector" @@ phraseto_tsquery($1::regconfig, $2)`,
+			wantArgs:  []interface{}{"english", "connection pool"},
+		},
+	})
+
+	if _, _, err := BuildWhere(map[string]interface{}{"search_vector": TsMatch("pool", "english'; --")}); err == nil {
+		t.Error("TsMatch with an invalid config succeeded, want an error")
+	}
+	if _, _, err := posts.Query().Select(TsRank("search_vector", "pool", "english'; --")).ToSQL(); err == nil {
+		t.Error("TsRank with an invalid config succeeded, want an error")
+	}
+	if _, _, err := posts.Query().Select(TsRank("bad column", "pool", "")).ToSQL(); err == nil {
+		t.Error("TsRank with an invalid column succeeded, want an error")
+	}
+}
+
+func TestFullTextSearchRanked(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil,
+		Column{Name: "body", DataType: *DataType{}.Text()},
+		Column{Name: "search_vector", DataType: *DataType{}.Tsvector()})
+	for _, body := range []string{
+		"A postgres connection pool",
+		"Pooling postgres connections: the postgres pool keeps connections open",
+		"A mysql connection pool",
+	} {
+		if _, err := table.Insert(map[string]interface{}{"body": body}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	ctx := context.Background()
+	if _, err := conn.Exec(ctx, fmt.Sprintf("UPDATE %s SET search_vector = to_tsvector('english', body)", QuoteIdentifier(table.Name))); err != nil {
+		t.Fatalf("filling search_vector: %v", err)
+	}
+
+	rows, err := table.Query().Select("body", TsRank("search_vector", "postgres & pool", "").As("rank")).
+		Where(map[string]interface{}{"search_vector": TsMatch("postgres & pool", "")}).
+		OrderBy(OrderBySpec{Column: "rank", Direction: "DESC"}).
+		FetchMany(ctx)
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want the 2 postgres posts: %v", len(rows), rows)
+	}
+	if rows[0]["body"] != "Pooling postgres connections: the postgres pool keeps connections open" {
+		t.Errorf("best match = %v, want the post mentioning postgres and pool twice", rows[0]["body"])
+	}
+	if first, second := rows[0]["rank"].(float32), rows[1]["rank"].(float32); first <= second || second <= 0 {
+		t.Errorf("ranks = %v, %v; want positive and descending", first, second)
+	}
+
+	phrase, err := table.FetchMany(map[string]interface{}{"search_vector": TsMatchPhrase("connection pool", "")})
+	if err != nil {
+		t.Fatalf("FetchMany: %v", err)
+	}
+	if len(phrase) != 2 {
+		t.Errorf("phrase matches = %v, want the two posts containing \"connection pool\"", phrase)
+	}
+}
+
+func TestSoftDeleteFilterIsQualified(t *testing.T) {
+	users := &Table{Name: "users", SoftDeleteColumn: "deleted_at"}
+	runQueryTests(t, []queryTest{
+		{
+			name: "lateral join with the same column",
+			query: users.Query().LateralJoin("latest", "SELECT * FROM orders o WHERE o.user_id = users.id LIMIT $1", 1).
+				Where(map[string]interface{}{"active": true}),
+			want: `SELECT "users".*, 'latest' AS "pggo.lateral", "latest".* FROM "users" ` +
+				`CROSS JOIN LATERAL (SELECT * FROM orders o WHERE o.user_id = users.id LIMIT $1) AS "latest" ` +
+				`WHERE "users"."deleted_at" IS NULL AND "active" = $2`,
+			wantArgs: []interface{}{1, true},
+		},
+	})
+}
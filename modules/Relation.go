@@ -0,0 +1,135 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+)
+
+// relationBatchSize is the number of key values loaded per query by Relation.LoadFor,
+// which keeps the IN list well under PostgreSQL's limit of 65535 parameters.
+const relationBatchSize = 5000
+
+// Relation describes how the rows of a table relate to the rows of another table, so that the related
+// rows of many rows can be loaded with one query instead of one query per row (the N+1 problem).
+// Create it with Table.HasMany or Table.BelongsTo.
+type Relation struct {
+	// Name is the key the related rows are stored under in each row; it defaults to the related table's name.
+	Name string
+
+	related    *Table
+	foreignKey string
+	localKey   string
+	belongsTo  bool
+	err        error
+}
+
+// HasMany defines a one-to-many relation: the rows of related whose foreignKey column equals the
+// localKey column of a row of t belong to that row, e.g. UsersTable.HasMany(OrdersTable, "user_id", "id").
+// LoadFor stores them in each row as a []map[string]interface{} under the related table's name.
+//
+// Example:
+//
+//	users, err := UsersTable.FetchMany(map[string]interface{}{"active": true})
+//	err = UsersTable.HasMany(OrdersTable, "user_id", "id").LoadFor(ctx, users)
+//	orders := users[0]["orders"].([]map[string]interface{})
+func (t *Table) HasMany(related *Table, foreignKey, localKey string) *Relation {
+	return newRelation(related, foreignKey, localKey, false)
+}
+
+// BelongsTo defines the inverse relation: a row of t belongs to the row of owner whose localKey column
+// equals the row's foreignKey column, e.g. OrdersTable.BelongsTo(UsersTable, "user_id", "id").
+// LoadFor stores it in each row as a map[string]interface{} (nil if there is none) under the owner
+// table's name; use As to name it, e.g. "user".
+//
+// Example:
+//
+//	orders, err := OrdersTable.FetchMany(map[string]interface{}{"status": "paid"})
+//	err = OrdersTable.BelongsTo(UsersTable, "user_id", "id").As("user").LoadFor(ctx, orders)
+func (t *Table) BelongsTo(owner *Table, foreignKey, localKey string) *Relation {
+	return newRelation(owner, foreignKey, localKey, true)
+}
+
+// newRelation validates and returns a relation to the related table.
+func newRelation(related *Table, foreignKey, localKey string, belongsTo bool) *Relation {
+	r := &Relation{related: related, foreignKey: foreignKey, localKey: localKey, belongsTo: belongsTo}
+	switch {
+	case related == nil:
+		r.err = fmt.Errorf("relation requires a related table")
+	case !isValidIdentifier(foreignKey):
+		r.err = fmt.Errorf("invalid foreign key column: '%s'", foreignKey)
+	case !isValidIdentifier(localKey):
+		r.err = fmt.Errorf("invalid local key column: '%s'", localKey)
+	default:
+		r.Name = related.Name
+	}
+	return r
+}
+
+// As sets the key the related rows are stored under. It returns the relation for chaining.
+func (r *Relation) As(name string) *Relation {
+	r.Name = name
+	return r
+}
+
+// LoadFor loads the related rows of rows, with one query per 5000 distinct keys
+// (SELECT * FROM related WHERE key IN (...)), and stores them in each row under Name.
+// Key values are matched by value, so an integer key matches whatever integer type it has on either side.
+// The related table's scopes and SoftDeleteColumn filter apply. Rows whose key is NULL get no related rows.
+func (r *Relation) LoadFor(ctx context.Context, rows []map[string]interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	// The rows hold rowKey; the related table's rows hold relatedKey
+	rowKey, relatedKey := r.localKey, r.foreignKey
+	if r.belongsTo {
+		rowKey, relatedKey = r.foreignKey, r.localKey
+	}
+
+	seen := make(map[string]bool)
+	var keys []interface{}
+	for _, row := range rows {
+		value, found := row[rowKey]
+		if !found {
+			return fmt.Errorf("row has no column '%s' to load '%s' for", rowKey, r.Name)
+		}
+		if value == nil || seen[cacheKeyPart(value)] {
+			continue
+		}
+		seen[cacheKeyPart(value)] = true
+		keys = append(keys, value)
+	}
+
+	byKey := make(map[string][]map[string]interface{})
+	related := r.related.WithContext(ctx)
+	for start := 0; start < len(keys); start += relationBatchSize {
+		batch := keys[start:min(start+relationBatchSize, len(keys))]
+		relatedRows, err := related.FetchMany(map[string]interface{}{relatedKey: In(batch)})
+		if err != nil {
+			return fmt.Errorf("failed to load '%s': %w", r.Name, err)
+		}
+		for _, relatedRow := range relatedRows {
+			key := cacheKeyPart(relatedRow[relatedKey])
+			byKey[key] = append(byKey[key], relatedRow)
+		}
+	}
+
+	for _, row := range rows {
+		var matches []map[string]interface{}
+		if value := row[rowKey]; value != nil {
+			matches = byKey[cacheKeyPart(value)]
+		}
+		if r.belongsTo {
+			var owner map[string]interface{}
+			if len(matches) > 0 {
+				owner = matches[0]
+			}
+			row[r.Name] = owner
+			continue
+		}
+		if matches == nil {
+			matches = []map[string]interface{}{}
+		}
+		row[r.Name] = matches
+	}
+	return nil
+}
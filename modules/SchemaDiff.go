@@ -0,0 +1,149 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ColumnChange is a column whose declared type differs from its type in the database.
+type ColumnChange struct {
+	// Name is the column name.
+	Name string
+	// OldType is the column's current type in the database (as reported by format_type, e.g. "character varying(50)").
+	OldType string
+	// NewType is the declared type the column is converted to.
+	NewType string
+}
+
+// SchemaDiff is the difference between a Table's Columns and the table in the database,
+// as computed by Table.SchemaDiff.
+type SchemaDiff struct {
+	// ToAdd are the defined columns missing from the database.
+	ToAdd []Column
+	// ToDrop are the database columns that are no longer defined.
+	ToDrop []string
+	// ToAlter are the columns whose type changed.
+	ToAlter []ColumnChange
+	// AllowDrop must be set for Apply to drop the ToDrop columns, preventing accidental data loss.
+	AllowDrop bool
+
+	table *Table
+	// create is set if the table does not exist, in which case ToAdd holds all the columns.
+	create bool
+}
+
+// SchemaDiff compares the live database schema of the table with t.Columns, without changing anything.
+// Unlike CreateTable, which drops undefined columns silently, it lets the changes be reviewed first.
+//
+// Example:
+//
+//	diff, err := UsersTable.SchemaDiff(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	if !diff.IsEmpty() {
+//	    log.Println(diff.ToSQL())
+//	    err = diff.Apply(ctx) // fails if columns would be dropped, unless diff.AllowDrop is set
+//	}
+func (t *Table) SchemaDiff(ctx context.Context) (SchemaDiff, error) {
+	dbColumns, err := t.WithContext(ctx).getColumnTypesFromDB()
+	if err != nil {
+		return SchemaDiff{}, fmt.Errorf("failed to read columns: %w", err)
+	}
+	return t.computeSchemaDiff(dbColumns), nil
+}
+
+// computeSchemaDiff compares t.Columns with the given database columns.
+func (t *Table) computeSchemaDiff(dbColumns []dbColumn) SchemaDiff {
+	diff := SchemaDiff{table: t}
+	if len(dbColumns) == 0 {
+		diff.create = true
+		diff.ToAdd = append(diff.ToAdd, t.Columns...)
+		return diff
+	}
+
+	existing := make(map[string]dbColumn, len(dbColumns))
+	for _, col := range dbColumns {
+		existing[col.Name] = col
+	}
+	for _, col := range t.Columns {
+		dbCol, found := existing[col.Name]
+		if !found {
+			diff.ToAdd = append(diff.ToAdd, col)
+			continue
+		}
+		if col.DataType.Type == "" {
+			continue
+		}
+		if wantType := col.DataType.normalizedType(); wantType != dbCol.Type {
+			diff.ToAlter = append(diff.ToAlter, ColumnChange{
+				Name:    col.Name,
+				OldType: dbCol.Type,
+				NewType: col.DataType.castType() + col.DataType.typeModifier(),
+			})
+		}
+	}
+	for _, dbCol := range dbColumns {
+		if t.columnNotExists(dbCol.Name, t.Columns) {
+			diff.ToDrop = append(diff.ToDrop, dbCol.Name)
+		}
+	}
+	return diff
+}
+
+// IsEmpty reports whether the database already matches the table definition.
+func (d SchemaDiff) IsEmpty() bool {
+	return !d.create && len(d.ToAdd) == 0 && len(d.ToDrop) == 0 && len(d.ToAlter) == 0
+}
+
+// statements returns the DDL statements that bring the database in sync, one per change.
+func (d SchemaDiff) statements() []string {
+	if d.table == nil {
+		return nil
+	}
+	if d.create {
+		return []string{d.table.createTableSQL()}
+	}
+
+	tableName := QuoteIdentifier(d.table.Name)
+	var statements []string
+	for _, col := range d.ToAdd {
+		columnType := "TEXT"
+		if col.DataType != (ColumnDef{}) {
+			columnType = col.DataType.String()
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", tableName, QuoteIdentifier(col.Name), columnType))
+	}
+	for _, change := range d.ToAlter {
+		colName := QuoteIdentifier(change.Name)
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s",
+			tableName, colName, change.NewType, colName, change.NewType))
+	}
+	for _, col := range d.ToDrop {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", tableName, QuoteIdentifier(col)))
+	}
+	return statements
+}
+
+// ToSQL returns the statements needed to bring the database in sync, separated by newlines.
+// It is empty if there are no changes.
+func (d SchemaDiff) ToSQL() string {
+	statements := d.statements()
+	if len(statements) == 0 {
+		return ""
+	}
+	return strings.Join(statements, ";\n") + ";"
+}
+
+// Apply executes the diff in a single transaction.
+// It refuses to run if columns would be dropped and AllowDrop is not set.
+func (d SchemaDiff) Apply(ctx context.Context) error {
+	if d.table == nil {
+		return fmt.Errorf("schema diff is not bound to a table; create it with Table.SchemaDiff")
+	}
+	if len(d.ToDrop) > 0 && !d.AllowDrop {
+		return fmt.Errorf("schema diff would drop columns %v; set AllowDrop to apply it", d.ToDrop)
+	}
+	return d.table.applyStatements(ctx, "SchemaDiff.Apply", d.statements())
+}
@@ -18,18 +18,87 @@ type Table struct {
 	Connection DatabaseConnection
 	// Columns is a list of column definitions for the table.
 	Columns []Column
+	// Checks is a list of table-level CHECK constraints. Add them with AddCheck.
+	Checks []TableCheck
+	// UUIDPrimaryKey is a UUID key column filled in automatically by Insert and InsertMany
+	// when the inserted data does not contain it.
+	UUIDPrimaryKey string
+	// UUIDGeneratedByDB generates UUIDPrimaryKey values in PostgreSQL with gen_random_uuid()
+	// instead of in Go. gen_random_uuid() is built in since PostgreSQL 13 (pgcrypto before that).
+	UUIDGeneratedByDB bool
+	// TimestampColumns configures created_at/updated_at columns that are set automatically on writes.
+	TimestampColumns TimestampConfig
+	// Indexes is a list of indexes created by CreateTable and Migrate. Add them with AddIndex.
+	Indexes []IndexDef
 	// Cached enables in-memory caching for this table.
 	Cached bool
 	// CacheTTL defines the time-to-live for cached items.
 	CacheTTL time.Duration
 	// CacheKey is the column name used as the key for caching (usually the primary key).
 	CacheKey string
+	// CacheKeys are the columns of a composite cache key (e.g. a composite primary key).
+	// If set, it is used instead of CacheKey; a row is only cached when all of them are present.
+	CacheKeys []string
 	// CacheMax is the maximum number of items to store in the cache.
 	CacheMax int
 	// CacheData holds the actual in-memory cache instance.
 	CacheData *MemoryCache
+	// CacheBackend is an external cache (e.g. Redis) used instead of CacheData. Set it with EnableExternalCache.
+	CacheBackend ExternalCache
+	// WarmCacheWhere optionally restricts the rows loaded by WarmCache when it is called without
+	// whereArgs (same format as FetchMany's whereArgs).
+	WarmCacheWhere []interface{}
+	// SoftDeleteColumn is a nullable timestamp column, e.g. "deleted_at", that marks rows as deleted.
+	// When set, every read (FetchOne, FetchMany, FetchAll, GetPage and its variants, Count, Query, ...)
+	// only returns rows where it IS NULL, so FetchAll can return fewer rows than SELECT COUNT(*).
+	// Update and Delete are not filtered. Use FetchDeleted for the deleted rows and Unscoped for all rows.
+	SoftDeleteColumn string
+	// AllowFullTableWrite lets Update and Delete run without conditions, changing every row.
+	// It is false by default, so a forgotten WHERE returns ErrFullTableWrite instead. See UpdateAll and DeleteAll.
+	AllowFullTableWrite bool
+	// VersionColumn is an integer column, e.g. "version", used for optimistic locking. Insert sets it to 1
+	// and every Update and BulkUpdate increments it. If the data passed to Update holds it, only rows still
+	// at that version are updated, and ErrVersionConflict is returned if the matching row is at another version.
+	VersionColumn string
+	// ClaimColumn is the column ClaimRow sets on the claimed row, e.g. "status". If empty, ClaimRow only locks the row.
+	ClaimColumn string
+	// ClaimValue is the value ClaimRow sets ClaimColumn to, e.g. "processing".
+	ClaimValue interface{}
+	// ClaimOrderBy is the column ClaimRow claims rows in order of, e.g. "created_at".
+	// Defaults to the cache key columns, or the primary key column.
+	ClaimOrderBy string
+	// UseReplica routes reads (FetchOne, FetchMany, FetchAll, GetPage and its variants) to the
+	// connection's replica pool, if one is configured. Writes always use the primary pool.
+	UseReplica bool
 	// DebugMode enables verbose logging of SQL queries and operations.
 	DebugMode bool
+	// Logger receives the table's log output. If nil, the connection's Logger is used,
+	// falling back to the standard log package in DebugMode and discarding output otherwise.
+	Logger Logger
+	// QueryHooks run before and after every statement executed by the table.
+	QueryHooks []QueryHook
+
+	// ctx is the context used for statements issued by this table. Set it with WithContext.
+	ctx context.Context
+	// comment is the table description stored by CreateTable. Set it with Comment.
+	comment string
+	// cacheTasks tracks the background cache updates. It is created by EnableCache/EnableExternalCache.
+	cacheTasks *cacheTaskGroup
+	// events holds the handlers registered with On, shared by copies of the table.
+	events *eventEmitter
+	// returning lists the columns returned by writes when returningSet is true (nil means no RETURNING).
+	// Set it with Returning.
+	returning    []string
+	returningSet bool
+	// scopes are the named conditions registered with DefineScope.
+	scopes map[string][]interface{}
+	// activeScopes are the conditions of the scopes applied with Scope; scopeErr reports an unknown scope name.
+	activeScopes [][]interface{}
+	scopeErr     error
+	// unscoped disables the SoftDeleteColumn filter. Set it with Unscoped.
+	unscoped bool
+	// writeLimit restricts Update and Delete to a number of rows. Set it with UpdateLimit and DeleteLimit.
+	writeLimit *writeLimit
 }
 
 // Column represents a single column definition in a database table.
@@ -38,11 +107,41 @@ type Column struct {
 	Name string
 	// DataType defines the column's type and constraints (e.g., INTEGER, TEXT, UNIQUE).
 	DataType ColumnDef
+	// Comment is an optional description stored with COMMENT ON COLUMN when the table is created.
+	// It takes precedence over a comment set with ColumnDef.Comment.
+	Comment string
 }
 
 // Row is an alias for pgx.Row, representing a single row of results.
 type Row = pgx.Row
 
+// primaryKeyColumn returns the column declared with PrimaryKey(), or UUIDPrimaryKey if there is none.
+func (t *Table) primaryKeyColumn() (string, error) {
+	for _, col := range t.Columns {
+		if col.DataType.isPrimaryKey {
+			return col.Name, nil
+		}
+	}
+	if t.UUIDPrimaryKey != "" {
+		return t.UUIDPrimaryKey, nil
+	}
+	return "", fmt.Errorf("table '%s' has no primary key column", t.Name)
+}
+
+// defaultOrderColumn returns the column used to order results when no orderBy is given.
+// It is the column declared with PrimaryKey(), or the first defined column if there is none.
+func (t *Table) defaultOrderColumn() (string, error) {
+	for _, col := range t.Columns {
+		if col.DataType.isPrimaryKey {
+			return col.Name, nil
+		}
+	}
+	if len(t.Columns) > 0 {
+		return t.Columns[0].Name, nil
+	}
+	return "", fmt.Errorf("cannot determine default order column for table '%s': no columns defined; pass orderBy explicitly", t.Name)
+}
+
 // isDefinedColumnUnique checks if a column has a UNIQUE constraint defined in the table schema.
 func (t *Table) isDefinedColumnUnique(column Column) bool {
 	for _, col := range t.Columns {
@@ -76,29 +175,61 @@ func (t *Table) getDefinedColumnNames() []string {
 //	    log.Fatalf("Failed to create table: %v", err)
 //	}
 func (t *Table) CreateTable() error {
-	conn, err := t.Connection.GetConnection()
-	if err != nil {
+	t.addTimestampColumns()
+	t.addVersionColumn()
+	if err := t.validateChecks(); err != nil {
 		return err
 	}
-	// Release connection back to pool when function exits
-	defer conn.Release()
 
-	var columnDefs []string
-	for _, col := range t.Columns {
-		columnDefs = append(columnDefs, fmt.Sprintf("%s %s", QuoteIdentifier(col.Name), col.DataType.String()))
-	}
-	createTableSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", QuoteIdentifier(t.Name), strings.Join(columnDefs, ", "))
-	_, err = conn.Exec(context.Background(), createTableSQL)
+	_, err := t.execSQL(t.context(), "CreateTable", t.createTableSQL(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %v", err)
 	}
 
 	t.createCurrentColumn()
 	t.deleteNonExistingColumnsFromDB()
+	if err := t.syncChecks(); err != nil {
+		return err
+	}
+	for _, def := range t.Indexes {
+		if err := t.CreateIndex(t.context(), def); err != nil {
+			return err
+		}
+	}
+
+	if t.comment != "" {
+		if err := t.SetTableComment(t.context(), t.comment); err != nil {
+			return err
+		}
+	}
+	for _, col := range t.Columns {
+		comment := col.Comment
+		if comment == "" {
+			comment = col.DataType.comment
+		}
+		if comment == "" {
+			continue
+		}
+		if err := t.SetColumnComment(t.context(), col.Name, comment); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// createTableSQL renders the CREATE TABLE IF NOT EXISTS statement for the table's columns and checks.
+func (t *Table) createTableSQL() string {
+	var columnDefs []string
+	for _, col := range t.Columns {
+		columnDefs = append(columnDefs, fmt.Sprintf("%s %s", QuoteIdentifier(col.Name), col.DataType.String()))
+	}
+	for _, check := range t.Checks {
+		columnDefs = append(columnDefs, check.toSQL())
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", QuoteIdentifier(t.Name), strings.Join(columnDefs, ", "))
+}
+
 // GetColumnsFromDB retrieves the list of column names for the table from the database's information_schema.
 //
 // Returns:
@@ -113,28 +244,79 @@ func (t *Table) CreateTable() error {
 //	}
 func (t *Table) GetColumnsFromDB() ([]string, error) {
 	// will get from database
-	conn, err := t.Connection.GetConnection()
+	const QueryString = "SELECT column_name  FROM information_schema.columns WHERE table_name = $1"
+	rows, err := t.queryRows(t.context(), "GetColumnsFromDB", QueryString, []interface{}{t.Name})
 	if err != nil {
 		return nil, err
 	}
-	// Release connection back to pool when function exits
-	defer conn.Release()
 
-	const QueryString = "SELECT column_name  FROM information_schema.columns WHERE table_name = $1"
-	rows, err := conn.Query(context.Background(), QueryString, t.Name)
-	if err != nil {
-		return nil, err
+	var columns []string
+	for _, row := range rows {
+		if col, ok := row["column_name"].(string); ok {
+			columns = append(columns, col)
+		}
 	}
+	return columns, nil
+}
 
-	fmt.Printf("DEBUG: Rows: %v\n", rows)
+// ColumnMetadata describes a column as it exists in the database (from information_schema.columns).
+type ColumnMetadata struct {
+	// Name is the column name.
+	Name string
+	// DataType is the column's data type as reported by PostgreSQL (e.g. "integer", "character varying").
+	DataType string
+	// IsNullable reports whether the column accepts NULL values.
+	IsNullable bool
+	// ColumnDefault is the default expression, or nil if the column has no default.
+	ColumnDefault *string
+	// OrdinalPosition is the column's 1-based position in the table.
+	OrdinalPosition int
+	// CharacterMaximumLength is the declared maximum length for character types, or nil.
+	CharacterMaximumLength *int
+}
 
-	defer rows.Close() // Also close the rows when done
+// GetColumnsMetadata retrieves full column metadata for the table from the database's information_schema,
+// ordered by column position. Only the current schema (search_path) is inspected.
+//
+// Returns:
+//   - []ColumnMetadata: The columns found in the database.
+//   - error: An error if the query fails.
+//
+// Example:
+//
+//	cols, err := table.GetColumnsMetadata()
+//	for _, col := range cols {
+//	    log.Printf("%s %s nullable=%v", col.Name, col.DataType, col.IsNullable)
+//	}
+func (t *Table) GetColumnsMetadata() ([]ColumnMetadata, error) {
+	// information_schema uses its own domain types, so cast to plain types for decoding
+	const QueryString = `SELECT column_name::text AS column_name, data_type::text AS data_type,
+		is_nullable::text AS is_nullable, column_default::text AS column_default,
+		ordinal_position::int AS ordinal_position, character_maximum_length::int AS character_maximum_length
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY ordinal_position`
+	rows, err := t.queryRows(t.context(), "GetColumnsMetadata", QueryString, []interface{}{t.Name})
+	if err != nil {
+		return nil, err
+	}
 
-	var columns []string
-	for rows.Next() {
-		var col string
-		if err := rows.Scan(&col); err != nil {
-			return nil, err
+	columns := make([]ColumnMetadata, 0, len(rows))
+	for _, row := range rows {
+		col := ColumnMetadata{}
+		col.Name, _ = row["column_name"].(string)
+		col.DataType, _ = row["data_type"].(string)
+		nullable, _ := row["is_nullable"].(string)
+		col.IsNullable = nullable == "YES"
+		if def, ok := row["column_default"].(string); ok {
+			col.ColumnDefault = &def
+		}
+		if pos, ok := row["ordinal_position"].(int32); ok {
+			col.OrdinalPosition = int(pos)
+		}
+		if length, ok := row["character_maximum_length"].(int32); ok {
+			maxLength := int(length)
+			col.CharacterMaximumLength = &maxLength
 		}
 		columns = append(columns, col)
 	}
@@ -192,20 +374,14 @@ func (t *Table) columnNotExists(column string, db_columns []Column) bool {
 //	    log.Println("Failed to remove column")
 //	}
 func (t *Table) removeColumn(column string) bool {
-	conn, err := t.Connection.GetConnection()
-	if err != nil {
-		return false
-	}
-	defer conn.Release()
-
-	fmt.Printf("Removing column <%s> from table <%s>\n", column, t.Name)
+	t.debugf("Removing column <%s> from table <%s>", column, t.Name)
 	removeColumnSQL := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", QuoteIdentifier(t.Name), QuoteIdentifier(column))
-	_, err = conn.Exec(context.Background(), removeColumnSQL)
+	_, err := t.execSQL(t.context(), "removeColumn", removeColumnSQL, nil)
 	if err != nil {
-		fmt.Printf("Error removing column: %v\n", err)
+		t.logger().Errorf("Error removing column: %v", err)
 		return false
 	}
-	fmt.Println("DEBUG: SQL executed successfully For Removing column:", column)
+	t.debugf("SQL executed successfully For Removing column: %s", column)
 
 	return true
 }
@@ -241,13 +417,7 @@ func (t *Table) deleteNonExistingColumnsFromDB() (bool, error) {
 //	    log.Println("Failed to add column")
 //	}
 func (t *Table) addColumn(column Column) bool {
-	fmt.Printf("Adding column <%s> of type <%s> to table <%s>\n", column.Name, column.DataType.String(), t.Name)
-
-	conn, err := t.Connection.GetConnection()
-	if err != nil {
-		return false
-	}
-	defer conn.Release()
+	t.debugf("Adding column <%s> of type <%s> to table <%s>", column.Name, column.DataType.String(), t.Name)
 
 	var columnType string
 
@@ -257,14 +427,14 @@ func (t *Table) addColumn(column Column) bool {
 		columnType = column.DataType.String()
 	}
 
-	fmt.Printf("DEBUG: Prepared to execute SQL to add column %s of type %s\n", column.Name, columnType)
+	t.debugf("Prepared to execute SQL to add column %s of type %s", column.Name, columnType)
 	addColumnSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", QuoteIdentifier(t.Name), QuoteIdentifier(column.Name), columnType)
-	_, err = conn.Exec(context.Background(), addColumnSQL)
+	_, err := t.execSQL(t.context(), "addColumn", addColumnSQL, nil)
 	if err != nil {
-		fmt.Printf("Error adding column: %v\n", err)
+		t.logger().Errorf("Error adding column: %v", err)
 		return false
 	}
-	fmt.Println("DEBUG: SQL executed successfully For Adding column:", column.Name)
+	t.debugf("SQL executed successfully For Adding column: %s", column.Name)
 
 	t.Columns = append(t.Columns, column)
 	return true
@@ -283,23 +453,120 @@ func (t *Table) addColumn(column Column) bool {
 //	    log.Printf("Failed to drop table: %v", err)
 //	}
 func (t *Table) DropTable() error {
-	conn, err := t.Connection.GetConnection()
+	dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", QuoteIdentifier(t.Name))
+	_, err := t.execSQL(t.context(), "DropTable", dropTableSQL, nil)
 	if err != nil {
 		return err
 	}
-	defer conn.Release()
+	t.debugf("SQL executed successfully For Dropping table: %s", t.Name)
 
-	dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s", QuoteIdentifier(t.Name))
-	_, err = conn.Exec(context.Background(), dropTableSQL)
-	if err != nil {
-		fmt.Printf("Error dropping table: %v\n", err)
-		return err
+	return nil
+}
+
+// Truncate deletes all rows from the table with TRUNCATE TABLE, which is much faster than Delete
+// without conditions on large tables. The cache is cleared afterwards.
+//
+// restartIdentity resets the table's serial and identity sequences (RESTART IDENTITY).
+// cascade also truncates every table with a foreign key referencing this one (CASCADE).
+//
+// As a safeguard against accidental calls, ctx must not be nil.
+//
+// Example:
+//
+//	err := UsersTable.Truncate(ctx, true, false)
+func (t *Table) Truncate(ctx context.Context, restartIdentity bool, cascade bool) error {
+	if ctx == nil {
+		return fmt.Errorf("refusing to truncate table '%s' without a context", t.Name)
 	}
-	fmt.Println("DEBUG: SQL executed successfully For Dropping table:", t.Name)
 
+	truncateSQL := fmt.Sprintf("TRUNCATE TABLE %s", QuoteIdentifier(t.Name))
+	if restartIdentity {
+		truncateSQL += " RESTART IDENTITY"
+	}
+	if cascade {
+		t.logger().Warnf("Truncating table %s with CASCADE: tables referencing it will also be truncated", t.Name)
+		truncateSQL += " CASCADE"
+	}
+
+	if _, err := t.execSQL(ctx, "Truncate", truncateSQL, nil); err != nil {
+		return fmt.Errorf("failed to truncate table: %w", err)
+	}
+	t.invalidateCache()
+	t.emit(EventTruncate, nil, nil)
 	return nil
 }
 
+// WithContext returns a shallow copy of the table whose statements run with the given context.
+// The copy shares the connection, cache, and hooks with the original table.
+// Use it to propagate cancellation, deadlines, and tracing spans to PgGo queries.
+//
+// Example:
+//
+//	user, err := UsersTable.WithContext(r.Context()).FetchOne(map[string]interface{}{"id": 5})
+func (t *Table) WithContext(ctx context.Context) *Table {
+	clone := t.clone()
+	clone.ctx = ctx
+	return &clone
+}
+
+// Returning returns a shallow copy of the table whose writes (Insert, InsertMany, Update, BulkUpdate, Delete
+// and their variants) return only the given columns instead of RETURNING *.
+// With no columns the statements have no RETURNING clause at all, and the write methods return no rows.
+// Column names are validated and quoted when the statement is built.
+//
+// Rows returned with a custom column list are not stored in the cache, since they are incomplete.
+//
+// Example:
+//
+//	// Avoid pulling back a large "avatar" bytea column
+//	user, err := UsersTable.Returning("id", "email").Insert(data)
+//
+//	// No RETURNING at all
+//	_, err = UsersTable.Returning().Update(updates, map[string]interface{}{"id": 5})
+func (t *Table) Returning(columns ...string) *Table {
+	clone := t.clone()
+	clone.returning = columns
+	clone.returningSet = true
+	return &clone
+}
+
+// returningClause renders the RETURNING clause for writes: " RETURNING *" by default, the selected
+// columns if set with Returning, or an empty string if Returning was called without columns.
+// If qualifier is not empty, columns are qualified with it (e.g. "users".* for UPDATE ... FROM).
+func (t *Table) returningClause(qualifier string) (string, error) {
+	prefix := ""
+	if qualifier != "" {
+		prefix = qualifier + "."
+	}
+	if !t.returningSet {
+		return " RETURNING " + prefix + "*", nil
+	}
+	if len(t.returning) == 0 {
+		return "", nil
+	}
+	cols := make([]string, len(t.returning))
+	for i, col := range t.returning {
+		if !isValidIdentifier(col) {
+			return "", fmt.Errorf("invalid returning column name: '%s'", col)
+		}
+		cols[i] = prefix + QuoteIdentifier(col)
+	}
+	return " RETURNING " + strings.Join(cols, ", "), nil
+}
+
+// returnsRows reports whether writes have a RETURNING clause, i.e. Returning was not called without columns.
+func (t *Table) returnsRows() bool {
+	return !t.returningSet || len(t.returning) > 0
+}
+
+// context returns the context set with WithContext, or context.Background() if none was set.
+func (t *Table) context() context.Context {
+	if t.ctx != nil {
+		return t.ctx
+	}
+	return context.Background()
+}
+
 // GetTableName returns the name of the table.
 //
 // Example:
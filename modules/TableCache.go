@@ -1,11 +1,211 @@
 package modules
 
+import (
+	"context"
+	"sync"
+)
+
 // clearCache invalidates all items in the table's in-memory cache.
 // It does nothing if caching is not enabled or initialized.
 func (t *Table) clearCache() error {
-	if !t.Cached || t.CacheData == nil {
+	store := t.cacheStore()
+	if store == nil {
 		return nil // Cache not enabled, ignore
 	}
-	t.CacheData.Clear()
+	store.Clear()
 	return nil
 }
+
+// CacheStats returns the hit, miss and eviction counters of the table's in-memory cache.
+// It returns nil if caching is not enabled or initialized, or if the table uses an ExternalCache
+// that does not report stats (a backend can do so by implementing Stats() CacheStats).
+//
+// Example:
+//
+//	if stats := UsersTable.CacheStats(); stats != nil {
+//	    log.Printf("cache hit rate: %.2f (%d/%d items)", stats.HitRate, stats.Size, stats.MaxSize)
+//	}
+func (t *Table) CacheStats() *CacheStats {
+	reporter, ok := t.cacheStore().(interface{ Stats() CacheStats })
+	if !ok {
+		return nil
+	}
+	stats := reporter.Stats()
+	return &stats
+}
+
+// cacheTaskGroup tracks the background goroutines that populate the cache after reads and writes,
+// so Table.Close can wait for them. It is shared by copies of the table (e.g. from WithContext).
+type cacheTaskGroup struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closed  bool
+	aborted bool
+}
+
+// start runs fn in a tracked goroutine. It returns false without running fn if the group is closed.
+func (g *cacheTaskGroup) start(fn func()) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		fn()
+	}()
+	return true
+}
+
+// isAborted reports whether Close gave up waiting, so running tasks can stop early.
+func (g *cacheTaskGroup) isAborted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.aborted
+}
+
+// close rejects new tasks and waits for the running ones until ctx is done,
+// in which case the running tasks are told to stop.
+func (g *cacheTaskGroup) close(ctx context.Context) error {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		g.aborted = true
+		g.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// runCacheTask runs a background cache update in a goroutine tracked by the table, so Close can wait for it.
+// fn receives a function reporting whether Close timed out, to stop early between rows.
+func (t *Table) runCacheTask(fn func(aborted func() bool)) {
+	if t.cacheTasks == nil {
+		// Caching was enabled without EnableCache/EnableExternalCache; run untracked
+		go fn(func() bool { return false })
+		return
+	}
+	if !t.cacheTasks.start(func() { fn(t.cacheTasks.isAborted) }) {
+		t.debugf("Table %s is closed, skipping background cache update", t.Name)
+	}
+}
+
+// forEachCacheKey calls fn with the cache key of each row, skipping rows without a complete cache key.
+// It stops early once aborted reports true; aborted may be nil.
+func (t *Table) forEachCacheKey(rows []map[string]interface{}, aborted func() bool, fn func(key string, row map[string]interface{})) {
+	for _, row := range rows {
+		if aborted != nil && aborted() {
+			return
+		}
+		if key, err := t.getCacheKey(row); err == nil {
+			fn(key, row)
+		}
+	}
+}
+
+// cacheRowsAsync stores rows in the cache under their cache keys in the background,
+// so callers do not wait for serialization or a remote cache.
+// All reads and writes that return rows populate the cache through it.
+func (t *Table) cacheRowsAsync(rows []map[string]interface{}) {
+	if !t.Cached || len(rows) == 0 {
+		return
+	}
+	t.runCacheTask(func(aborted func() bool) {
+		t.forEachCacheKey(rows, aborted, func(key string, row map[string]interface{}) {
+			_ = t.setCache(key, row)
+		})
+	})
+}
+
+// uncacheRows removes deleted rows from the cache before the delete returns, so a following read
+// never sees them. Like refreshCachedRows, it clears the whole cache only when the affected keys are
+// unknown: when no rows or only some columns without the cache key were returned (see Returning).
+func (t *Table) uncacheRows(rows []map[string]interface{}) {
+	if !t.Cached {
+		return
+	}
+	if !t.returnsRows() {
+		t.invalidateCache()
+		return
+	}
+	for _, row := range rows {
+		key, err := t.getCacheKey(row)
+		if err != nil {
+			// The cache key columns were not returned, so the cached entry cannot be found
+			t.invalidateCache()
+			return
+		}
+		_ = t.deleteCache(key)
+	}
+}
+
+// refreshCachedRows updates the cache after an UPDATE of the given columns.
+//
+// Only the affected entries change, so unrelated cached rows stay cached: the returned rows replace
+// their cached copies, or are removed from the cache when only some of their columns were returned
+// (see Returning). This happens before the update returns, so a following read never sees the old values.
+// The whole cache is cleared only when the affected keys are unknown: when the update changes a
+// cache key column (the old keys are not returned) or when no rows were returned at all.
+func (t *Table) refreshCachedRows(columns []string, rows []map[string]interface{}) {
+	if !t.Cached {
+		return
+	}
+	keyColumns := t.cacheKeyColumns()
+	for _, col := range columns {
+		for _, keyColumn := range keyColumns {
+			if col == keyColumn {
+				t.invalidateCache()
+				return
+			}
+		}
+	}
+	if !t.returnsRows() {
+		t.invalidateCache()
+		return
+	}
+
+	for _, row := range rows {
+		key, err := t.getCacheKey(row)
+		if err != nil {
+			// The cache key columns were not returned, so the stale entry cannot be found
+			t.invalidateCache()
+			return
+		}
+		if t.returningSet {
+			_ = t.deleteCache(key)
+		} else {
+			_ = t.setCache(key, row)
+		}
+	}
+}
+
+// Close waits for pending background cache updates to finish and stops new ones from starting,
+// so they do not race with the shutdown of the connection or an external cache. Call it before
+// closing the DatabaseConnection. The table can still run queries, but results are no longer
+// cached in the background.
+//
+// If ctx expires first, Close returns ctx.Err() and the remaining updates stop at the next row.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	_ = UsersTable.Close(ctx)
+//	_ = db.Close(ctx)
+func (t *Table) Close(ctx context.Context) error {
+	if t.cacheTasks == nil {
+		return nil
+	}
+	return t.cacheTasks.close(ctx)
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+)
+
+// Comment sets a description for the table, stored with COMMENT ON TABLE when CreateTable runs.
+// Use SetTableComment to change the comment of an existing table.
+//
+// Example:
+//
+//	UsersTable.Comment("Registered application users").CreateTable()
+func (t *Table) Comment(text string) *Table {
+	t.comment = text
+	return t
+}
+
+// SetTableComment stores a description for the table (COMMENT ON TABLE).
+// An empty comment removes the existing one.
+//
+// Example:
+//
+//	err := UsersTable.SetTableComment(ctx, "Registered application users")
+func (t *Table) SetTableComment(ctx context.Context, comment string) error {
+	commentSQL := fmt.Sprintf("COMMENT ON TABLE %s IS %s", QuoteIdentifier(t.Name), commentLiteral(comment))
+	if _, err := t.execSQL(ctx, "SetTableComment", commentSQL, nil); err != nil {
+		return fmt.Errorf("failed to set table comment: %w", err)
+	}
+	return nil
+}
+
+// SetColumnComment stores a description for a column (COMMENT ON COLUMN).
+// An empty comment removes the existing one.
+//
+// Example:
+//
+//	err := UsersTable.SetColumnComment(ctx, "email", "Primary contact address, unique per user")
+func (t *Table) SetColumnComment(ctx context.Context, columnName, comment string) error {
+	if !isValidIdentifier(columnName) {
+		return fmt.Errorf("invalid column name: '%s'", columnName)
+	}
+	commentSQL := fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s",
+		QuoteIdentifier(t.Name), QuoteIdentifier(columnName), commentLiteral(comment))
+	if _, err := t.execSQL(ctx, "SetColumnComment", commentSQL, nil); err != nil {
+		return fmt.Errorf("failed to set column comment: %w", err)
+	}
+	return nil
+}
+
+// GetTableComment returns the table's description, or an empty string if it has none.
+func (t *Table) GetTableComment(ctx context.Context) (string, error) {
+	const QueryString = "SELECT obj_description(to_regclass($1), 'pg_class') AS comment"
+	rows, err := t.queryRows(ctx, "GetTableComment", QueryString, []interface{}{QuoteIdentifier(t.Name)})
+	if err != nil {
+		return "", fmt.Errorf("failed to get table comment: %w", err)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	comment, _ := rows[0]["comment"].(string)
+	return comment, nil
+}
+
+// GetColumnComment returns a column's description, or an empty string if it has none.
+func (t *Table) GetColumnComment(ctx context.Context, columnName string) (string, error) {
+	const QueryString = `SELECT col_description(a.attrelid, a.attnum) AS comment
+		FROM pg_attribute a
+		WHERE a.attrelid = to_regclass($1) AND a.attname = $2 AND NOT a.attisdropped`
+	rows, err := t.queryRows(ctx, "GetColumnComment", QueryString, []interface{}{QuoteIdentifier(t.Name), columnName})
+	if err != nil {
+		return "", fmt.Errorf("failed to get column comment: %w", err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("column '%s' not found in table '%s'", columnName, t.Name)
+	}
+	comment, _ := rows[0]["comment"].(string)
+	return comment, nil
+}
+
+// commentLiteral renders a comment for COMMENT ON; an empty comment becomes NULL, which removes it.
+func commentLiteral(comment string) string {
+	if comment == "" {
+		return "NULL"
+	}
+	return quoteLiteral(comment)
+}
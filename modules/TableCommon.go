@@ -1,11 +1,14 @@
 package modules
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // fetchRowResult extracts a single row's data into a map.
@@ -20,8 +23,20 @@ func (t *Table) fetchRowResult(rows pgx.Rows, fields []pgconn.FieldDescription)
 	}
 
 	result := make(map[string]interface{})
+	prefix := ""
 	for i, fd := range fields {
-		result[string(fd.Name)] = values[i]
+		name := string(fd.Name)
+		// Columns after a LATERAL join's marker belong to that join; colliding names are prefixed with its alias
+		if name == lateralMarkerColumn {
+			if alias, ok := values[i].(string); ok {
+				prefix = alias + "."
+				continue
+			}
+		}
+		if _, taken := result[name]; taken && prefix != "" {
+			name = prefix + name
+		}
+		result[name] = values[i]
 	}
 	return result, nil
 }
@@ -40,39 +55,282 @@ func (t *Table) fetchRowsResult(rows pgx.Rows) ([]map[string]interface{}, error)
 	return results, nil
 }
 
+// acquireConn acquires a pooled connection for a statement.
+// Reads from a table with UseReplica set are served by the replica pool when available,
+// falling back to the primary pool (with a warning) if there is no replica or it cannot be reached.
+func (t *Table) acquireConn(readOnly bool) (*pgxpool.Conn, error) {
+	if readOnly && t.UseReplica {
+		conn, err := t.Connection.GetReplicaConnection()
+		if err == nil {
+			return conn, nil
+		}
+		t.logger().Warnf("Replica unavailable for table %s, falling back to primary: %v", t.Name, err)
+	}
+	return t.Connection.GetConnection()
+}
+
+// queryRows executes a query on a pooled connection and returns all resulting rows.
+// It logs the statement in DebugMode and runs the configured QueryHooks around it.
+func (t *Table) queryRows(ctx context.Context, operation, query string, params []interface{}) ([]map[string]interface{}, error) {
+	return t.runQuery(ctx, false, operation, query, params)
+}
+
+// readRows is like queryRows for read-only statements, which may be routed to a replica (see UseReplica).
+func (t *Table) readRows(ctx context.Context, operation, query string, params []interface{}) ([]map[string]interface{}, error) {
+	return t.runQuery(ctx, true, operation, query, params)
+}
+
+// runQuery implements queryRows and readRows.
+func (t *Table) runQuery(ctx context.Context, readOnly bool, operation, query string, params []interface{}) ([]map[string]interface{}, error) {
+	// Acquire connection from pool
+	conn, err := t.acquireConn(readOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release() // Release connection back to pool when done
+
+	return t.queryWith(ctx, conn, operation, query, params)
+}
+
+// rowQuerier runs a query that returns rows; it is implemented by pooled connections and pgx.Tx.
+type rowQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+// txRows is like queryRows but runs the statement inside the transaction tx.
+func (t *Table) txRows(ctx context.Context, tx *Transaction, operation, query string, params []interface{}) ([]map[string]interface{}, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("%s requires a transaction", operation)
+	}
+	return t.queryWith(ctx, tx.Tx, operation, query, params)
+}
+
+// writeRows is like queryRows but also returns the number of rows the statement affected,
+// which differs from the number of rows returned when the write has no RETURNING clause.
+func (t *Table) writeRows(ctx context.Context, operation, query string, params []interface{}) ([]map[string]interface{}, int64, error) {
+	conn, err := t.acquireConn(false)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	return t.queryWithCount(ctx, conn, operation, query, params)
+}
+
+// queryWith executes a query on q, running the QueryHooks around it, and returns its rows.
+func (t *Table) queryWith(ctx context.Context, q rowQuerier, operation, query string, params []interface{}) ([]map[string]interface{}, error) {
+	results, _, err := t.queryWithCount(ctx, q, operation, query, params)
+	return results, err
+}
+
+// queryWithCount is queryWith that also returns the number of rows affected, from the command tag.
+func (t *Table) queryWithCount(ctx context.Context, q rowQuerier, operation, query string, params []interface{}) ([]map[string]interface{}, int64, error) {
+	t.debugf("Executing %s with SQL: %s Params: %v", operation, query, params)
+
+	ctx, event := t.beforeQuery(ctx, operation, query, params)
+	rows, err := q.Query(ctx, query, params...)
+	if err != nil {
+		t.afterQuery(ctx, event, 0, err)
+		return nil, 0, fmt.Errorf("failed to execute %s: %w", operation, err)
+	}
+	defer rows.Close() // Also close the rows when done
+
+	results, err := t.fetchRowsResult(rows)
+	rows.Close() // The command tag is complete once the rows are closed
+	if err == nil {
+		err = rows.Err()
+	}
+	t.afterQuery(ctx, event, int64(len(results)), err)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch rows: %w", err)
+	}
+	return results, rows.CommandTag().RowsAffected(), nil
+}
+
+// execSQL executes a statement that returns no rows on a pooled connection.
+// It logs the statement in DebugMode and runs the configured QueryHooks around it.
+// Returns the number of rows affected.
+func (t *Table) execSQL(ctx context.Context, operation, query string, params []interface{}) (int64, error) {
+	conn, err := t.Connection.GetConnection()
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	t.debugf("Executing %s with SQL: %s Params: %v", operation, query, params)
+
+	ctx, event := t.beforeQuery(ctx, operation, query, params)
+	tag, err := conn.Exec(ctx, query, params...)
+	t.afterQuery(ctx, event, tag.RowsAffected(), err)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// offsetPlaceholders adds offset to every $n placeholder of a raw fragment whose placeholders refer to
+// argCount positional arguments, and returns the highest n used. Placeholders inside single-quoted
+// string literals are left untouched.
+// Returns an error if the fragment references a placeholder beyond argCount.
+func offsetPlaceholders(fragment string, argCount, offset int) (string, int, error) {
+	var sb strings.Builder
+	maxUsed := 0
+	inQuote := false
+	for i := 0; i < len(fragment); i++ {
+		ch := fragment[i]
+		switch {
+		case ch == '\'':
+			inQuote = !inQuote
+			sb.WriteByte(ch)
+		case !inQuote && ch == '$' && i+1 < len(fragment) && isDigit(fragment[i+1]):
+			j := i + 1
+			n := 0
+			for j < len(fragment) && isDigit(fragment[j]) {
+				n = n*10 + int(fragment[j]-'0')
+				j++
+			}
+			if n < 1 || n > argCount {
+				return "", 0, fmt.Errorf("placeholder $%d in %q has no matching argument (%d given)", n, fragment, argCount)
+			}
+			sb.WriteString(fmt.Sprintf("$%d", n+offset))
+			maxUsed = max(maxUsed, n)
+			i = j - 1
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String(), maxUsed, nil
+}
+
+// sortedKeys returns the keys of a data or condition map in sorted order,
+// so that statements built from maps are deterministic.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// buildOrderByClause constructs an ORDER BY clause from a list of OrderBySpec.
+// Column names are validated and quoted. Returns an empty string if orderBy is empty.
+//
+// Example output:
+//
+//	" ORDER BY \"name\" ASC NULLS LAST, \"created_at\" DESC NULLS FIRST"
+func buildOrderByClause(orderBy []OrderBySpec) (string, error) {
+	if len(orderBy) == 0 {
+		return "", nil
+	}
+	parts := make([]string, 0, len(orderBy))
+	for _, spec := range orderBy {
+		part, err := spec.toSQL()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
 // QuoteIdentifier safely quotes a SQL identifier (table name, column name).
 func QuoteIdentifier(ident string) string {
 	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
 }
 
+// quoteLiteral safely quotes a SQL string literal by doubling single quotes.
+// It is only used where PostgreSQL does not accept parameters, such as COMMENT ON.
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// BuildWhere builds a WHERE clause from the same arguments accepted by FetchMany, Update and Delete
+// (maps, conditions such as In or Between, WhereGroup/Or, raw fragments), numbering parameters from $1.
+// It lets raw queries (e.g. with Queue) reuse the library's condition handling safely.
+//
+// The clause is returned with a leading " WHERE ", ready to append to a query, or as an empty string
+// if there are no conditions.
+//
+// Example:
+//
+//	where, args, err := pggo.BuildWhere(map[string]interface{}{"age": pggo.Gt(18), "status": "active"})
+//	// where: ` WHERE "age" > $1 AND "status" = $2`, args: [18 active]
+//	rows, err := UsersTable.Queue("SELECT * FROM users"+where, args...)
+func BuildWhere(whereArgs ...interface{}) (string, []interface{}, error) {
+	argIndex := 1
+	return buildWhereClause(whereArgs, &argIndex)
+}
+
 // buildWhereClause constructs the WHERE clause and corresponding arguments.
 //
-// It automatically quotes identifiers in map keys to prevent SQL injection.
+// Map keys must be valid identifiers (see isValidIdentifier) and are quoted to prevent SQL injection.
 // Raw string arguments are assumed to be safe SQL fragments (e.g., "id = $1").
+// Grouping conditions (WhereGroup, Or) can be passed directly and are rendered in parentheses.
+// All top-level arguments are ANDed together.
+//
+// Positional (non-map, non-Condition) arguments are the values of the raw fragments' placeholders.
+// Their $1, $2, ... refer to the positional arguments in order, and are renumbered after the
+// parameters generated for map conditions (and any parameters before the WHERE clause),
+// so raw fragments and map conditions can be mixed freely.
 //
 // Example input:
 //
 //	whereArgs: []interface{}{
 //	    "id = $1",
-//	    map[string]interface{}{"name": "John", "email": "john@example.com"}
+//	    map[string]interface{}{"name": "John", "email": "john@example.com"},
+//	    5,
 //	}
 //
 // Example output:
 //
-//	whereClause: " WHERE id = $1 AND \"name\" = $2 AND \"email\" = $3"
-//	args: []interface{}{"John", "john@example.com"}
+//	whereClause: " WHERE id = $3 AND \"email\" = $1 AND \"name\" = $2"
+//	args: []interface{}{"john@example.com", "John", 5}
 //	argIndex: updated index after processing
-func buildWhereClause(whereArgs []interface{}, argIndex *int) (string, []interface{}) {
+//
+// Returns an error if a map key is not a valid identifier, a condition is malformed
+// (e.g. Between without two values), or a raw fragment references a missing positional argument.
+func buildWhereClause(whereArgs []interface{}, argIndex *int) (string, []interface{}, error) {
+	conditions, args, err := buildConditions(whereArgs, argIndex)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// buildConditions converts WHERE arguments into a list of SQL conditions (to be ANDed) and their arguments.
+// It is shared by buildWhereClause and grouping conditions such as WhereGroup and Or.
+func buildConditions(whereArgs []interface{}, argIndex *int) ([]string, []interface{}, error) {
 	conditions := []string{}
 	args := []interface{}{}
 
+	// Positional arguments belong to the raw string fragments; they are appended after all
+	// generated parameters, and the fragments' placeholders renumbered to match, once all are known.
+	rawFragments := []int{}
+	positional := []interface{}{}
+
 	for _, arg := range whereArgs {
 		switch v := arg.(type) {
 		case map[string]interface{}:
-			for key, val := range v {
+			if err := validateMapKeys(v); err != nil {
+				return nil, nil, err
+			}
+			for _, key := range sortedKeys(v) {
+				val := v[key]
 				quotedKey := QuoteIdentifier(key)
 				if cond, ok := val.(Condition); ok {
-					sql, condArgs := cond.ToSQL(quotedKey, argIndex)
+					sql, condArgs, err := cond.ToSQL(quotedKey, argIndex)
+					if err != nil {
+						return nil, nil, fmt.Errorf("invalid condition for column '%s': %w", key, err)
+					}
+					if sql == "" {
+						continue // Empty condition (e.g. Between(nil, nil)) means no filter
+					}
 					conditions = append(conditions, sql)
 					args = append(args, condArgs...)
 				} else {
@@ -82,17 +340,58 @@ func buildWhereClause(whereArgs []interface{}, argIndex *int) (string, []interfa
 				}
 			}
 
+		case Condition:
+			if !v.isStandalone() {
+				return nil, nil, fmt.Errorf("condition %s requires a column and must be used as a map value", v.Type)
+			}
+			sql, condArgs, err := v.ToSQL("", argIndex)
+			if err != nil {
+				return nil, nil, err
+			}
+			if sql == "" {
+				continue
+			}
+			conditions = append(conditions, sql)
+			args = append(args, condArgs...)
+
 		case string:
+			rawFragments = append(rawFragments, len(conditions))
 			conditions = append(conditions, v)
 
 		default:
-			args = append(args, v)
+			positional = append(positional, v)
 		}
 	}
 
-	if len(conditions) == 0 {
-		return "", args
+	if err := bindPositional(conditions, rawFragments, positional, argIndex); err != nil {
+		return nil, nil, err
 	}
+	return conditions, append(args, positional...), nil
+}
 
-	return " WHERE " + strings.Join(conditions, " AND "), args
+// bindPositional renumbers the placeholders of the raw fragments at the given indexes of conditions
+// so they refer to the positional arguments, which are appended after the parameters generated so far,
+// and advances argIndex past them.
+func bindPositional(conditions []string, rawFragments []int, positional []interface{}, argIndex *int) error {
+	if len(positional) == 0 {
+		return nil
+	}
+	if len(rawFragments) == 0 {
+		return fmt.Errorf("%d positional argument(s) given without a raw SQL fragment to reference them", len(positional))
+	}
+	referenced := 0
+	for _, i := range rawFragments {
+		renumbered, maxUsed, err := offsetPlaceholders(conditions[i], len(positional), *argIndex-1)
+		if err != nil {
+			return err
+		}
+		conditions[i] = renumbered
+		referenced = max(referenced, maxUsed)
+	}
+	if referenced < len(positional) {
+		// PostgreSQL would reject the unreferenced parameters with a less helpful error
+		return fmt.Errorf("positional argument $%d is not referenced by any raw SQL fragment (%d given)", referenced+1, len(positional))
+	}
+	*argIndex += len(positional)
+	return nil
 }
@@ -0,0 +1,83 @@
+package modules
+
+import (
+	"fmt"
+)
+
+// TableCheck is a named table-level CHECK constraint, which can reference several columns.
+type TableCheck struct {
+	// Name is the constraint name. It must be a valid identifier and is quoted.
+	Name string
+	// Expr is the boolean SQL expression checked for every row, e.g. "start_date < end_date".
+	// It is emitted verbatim and must not contain untrusted input.
+	Expr string
+}
+
+// AddCheck adds a named table-level CHECK constraint (CONSTRAINT "name" CHECK (expr)).
+// It is included in CREATE TABLE, and added with ALTER TABLE by CreateTable if the table already exists.
+// The expression is raw SQL and must be trusted; the name is validated and quoted.
+//
+// Example:
+//
+//	BookingsTable.AddCheck("valid_period", "start_date < end_date").CreateTable()
+func (t *Table) AddCheck(name, expr string) *Table {
+	t.Checks = append(t.Checks, TableCheck{Name: name, Expr: expr})
+	return t
+}
+
+// validateChecks checks that every table-level constraint has a valid name and an expression.
+func (t *Table) validateChecks() error {
+	for _, check := range t.Checks {
+		if !isValidIdentifier(check.Name) {
+			return fmt.Errorf("invalid check constraint name: '%s'", check.Name)
+		}
+		if check.Expr == "" {
+			return fmt.Errorf("check constraint '%s' has no expression", check.Name)
+		}
+	}
+	return nil
+}
+
+// toSQL renders the constraint as a table constraint definition.
+func (c TableCheck) toSQL() string {
+	return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", QuoteIdentifier(c.Name), c.Expr)
+}
+
+// getCheckNamesFromDB returns the names of the CHECK constraints defined on the table in the database.
+func (t *Table) getCheckNamesFromDB() (map[string]bool, error) {
+	const QueryString = "SELECT conname::text AS conname FROM pg_constraint WHERE conrelid = to_regclass($1) AND contype = 'c'"
+	rows, err := t.queryRows(t.context(), "getCheckNamesFromDB", QueryString, []interface{}{QuoteIdentifier(t.Name)})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if name, ok := row["conname"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// syncChecks adds the table-level CHECK constraints that do not exist in the database yet.
+// Existing constraints are not modified.
+func (t *Table) syncChecks() error {
+	if len(t.Checks) == 0 {
+		return nil
+	}
+	existing, err := t.getCheckNamesFromDB()
+	if err != nil {
+		return fmt.Errorf("failed to read check constraints: %w", err)
+	}
+	for _, check := range t.Checks {
+		if existing[check.Name] {
+			continue
+		}
+		t.debugf("Adding check constraint <%s> to table <%s>", check.Name, t.Name)
+		alterSQL := fmt.Sprintf("ALTER TABLE %s ADD %s", QuoteIdentifier(t.Name), check.toSQL())
+		if _, err := t.execSQL(t.context(), "addCheck", alterSQL, nil); err != nil {
+			return fmt.Errorf("failed to add check constraint '%s': %w", check.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// serialTypes maps integer types to the serial pseudo-type that creates their owned sequence.
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// ToDDL returns the SQL that recreates the table as it exists in the database: a
+// CREATE TABLE IF NOT EXISTS statement with its columns (type, NOT NULL, DEFAULT, identity and
+// generated columns) and its PRIMARY KEY, UNIQUE, CHECK, FOREIGN KEY and EXCLUDE constraints,
+// followed by a CREATE INDEX IF NOT EXISTS statement for every other index. Statements end with ";".
+//
+// The schema is read from information_schema and the system catalogs, not from Columns, so ToDDL
+// also exports tables created outside PgGo. Columns whose default draws from a sequence they own are
+// exported as serial types, so the sequence is recreated with them. Only the current schema
+// (search_path) is inspected; comments, triggers, grants and other objects are not included.
+//
+// Example:
+//
+//	ddl, err := UsersTable.ToDDL(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("schema/users.sql", []byte(ddl), 0o644)
+func (t *Table) ToDDL(ctx context.Context) (string, error) {
+	columns, err := t.columnDDL(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table '%s' does not exist", t.Name)
+	}
+	constraints, err := t.constraintDDL(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read constraints: %w", err)
+	}
+	indexes, err := t.indexDDL(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n    ", QuoteIdentifier(t.Name)))
+	sb.WriteString(strings.Join(append(columns, constraints...), ",\n    "))
+	sb.WriteString("\n);\n")
+	for _, index := range indexes {
+		sb.WriteString(index)
+		sb.WriteString(";\n")
+	}
+	return sb.String(), nil
+}
+
+// columnDDL returns the column definitions of the table in the database, in column order.
+func (t *Table) columnDDL(ctx context.Context) ([]string, error) {
+	// format_type keeps type modifiers and array types, which information_schema.columns.data_type drops
+	const QueryString = `SELECT c.column_name::text AS column_name,
+		format_type(a.atttypid, a.atttypmod) AS data_type,
+		c.is_nullable::text AS is_nullable, c.column_default::text AS column_default,
+		c.identity_generation::text AS identity_generation,
+		c.is_generated::text AS is_generated, c.generation_expression::text AS generation_expression,
+		pg_get_serial_sequence($2, c.column_name) IS NOT NULL AS owns_sequence
+		FROM information_schema.columns c
+		JOIN pg_attribute a ON a.attrelid = to_regclass($2) AND a.attname = c.column_name
+		WHERE c.table_schema = current_schema() AND c.table_name = $1
+		ORDER BY c.ordinal_position`
+	rows, err := t.queryRows(ctx, "ToDDL", QueryString, []interface{}{t.Name, QuoteIdentifier(t.Name)})
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(rows))
+	for _, row := range rows {
+		name, _ := row["column_name"].(string)
+		dataType, _ := row["data_type"].(string)
+		nullable, _ := row["is_nullable"].(string)
+		columnDefault, _ := row["column_default"].(string)
+		identity, _ := row["identity_generation"].(string)
+		generated, _ := row["is_generated"].(string)
+		generationExpr, _ := row["generation_expression"].(string)
+		ownsSequence, _ := row["owns_sequence"].(bool)
+
+		def := QuoteIdentifier(name) + " "
+		serialType, isInteger := serialTypes[dataType]
+		isSerial := ownsSequence && identity == "" && isInteger && strings.HasPrefix(columnDefault, "nextval(")
+		if isSerial {
+			def += serialType
+		} else {
+			def += dataType
+		}
+		switch {
+		case identity != "":
+			def += fmt.Sprintf(" GENERATED %s AS IDENTITY", identity)
+		case generated == "ALWAYS":
+			def += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", generationExpr)
+		case columnDefault != "" && !isSerial:
+			def += " DEFAULT " + columnDefault
+		}
+		if nullable == "NO" {
+			def += " NOT NULL"
+		}
+		columns = append(columns, def)
+	}
+	return columns, nil
+}
+
+// constraintDDL returns the table constraint definitions (CONSTRAINT "name" ...), primary key first.
+// NOT NULL is part of the column definitions and is skipped here.
+func (t *Table) constraintDDL(ctx context.Context) ([]string, error) {
+	const QueryString = `SELECT conname::text AS conname, pg_get_constraintdef(oid) AS definition
+		FROM pg_constraint
+		WHERE conrelid = to_regclass($1) AND contype IN ('p', 'u', 'c', 'f', 'x')
+		ORDER BY CASE contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'c' THEN 2 WHEN 'f' THEN 3 ELSE 4 END, conname`
+	rows, err := t.queryRows(ctx, "ToDDL", QueryString, []interface{}{QuoteIdentifier(t.Name)})
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make([]string, 0, len(rows))
+	for _, row := range rows {
+		name, _ := row["conname"].(string)
+		definition, _ := row["definition"].(string)
+		constraints = append(constraints, fmt.Sprintf("CONSTRAINT %s %s", QuoteIdentifier(name), definition))
+	}
+	return constraints, nil
+}
+
+// indexDDL returns a CREATE INDEX IF NOT EXISTS statement for every index of the table
+// that does not back a constraint (those are recreated by constraintDDL).
+func (t *Table) indexDDL(ctx context.Context) ([]string, error) {
+	const QueryString = `SELECT indexdef::text AS indexdef FROM pg_indexes
+		WHERE schemaname = current_schema() AND tablename = $1
+		AND indexname NOT IN (SELECT conname FROM pg_constraint WHERE conrelid = to_regclass($2) AND contype IN ('p', 'u', 'x'))
+		ORDER BY indexname`
+	rows, err := t.queryRows(ctx, "ToDDL", QueryString, []interface{}{t.Name, QuoteIdentifier(t.Name)})
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		indexDef, _ := row["indexdef"].(string)
+		switch {
+		case strings.HasPrefix(indexDef, "CREATE UNIQUE INDEX "):
+			indexDef = "CREATE UNIQUE INDEX IF NOT EXISTS " + strings.TrimPrefix(indexDef, "CREATE UNIQUE INDEX ")
+		case strings.HasPrefix(indexDef, "CREATE INDEX "):
+			indexDef = "CREATE INDEX IF NOT EXISTS " + strings.TrimPrefix(indexDef, "CREATE INDEX ")
+		}
+		indexes = append(indexes, indexDef)
+	}
+	return indexes, nil
+}
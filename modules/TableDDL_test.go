@@ -0,0 +1,74 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToDDLRoundTrip(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) {
+		table.AddIndex(IndexDef{Columns: IndexColumns("name")})
+		table.AddIndex(IndexDef{Columns: IndexColumns("email"), Unique: true, Where: "deleted_at IS NULL"})
+	},
+		Column{Name: "email", DataType: *DataType{}.Varchar(120).NotNull().Unique()},
+		Column{Name: "name", DataType: *DataType{}.Text().DefaultValue("anonymous")},
+		Column{Name: "price", DataType: *DataType{}.Numeric(10, 2).CheckConstraint("price >= 0")},
+		Column{Name: "tags", DataType: *DataType{}.Array("text")},
+		Column{Name: "parent_id", DataType: *DataType{}.Integer()},
+		Column{Name: "created_at", DataType: *DataType{}.Timestamptz().NotNull().DefaultRaw("now()")},
+		Column{Name: "deleted_at", DataType: *DataType{}.Timestamptz()})
+	ctx := context.Background()
+	quoted := QuoteIdentifier(table.Name)
+	if _, err := conn.Exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (parent_id) REFERENCES %s (id) ON DELETE CASCADE",
+		quoted, QuoteIdentifier(table.Name+"_parent_fk"), quoted)); err != nil {
+		t.Fatalf("adding the foreign key: %v", err)
+	}
+
+	ddl, err := table.ToDDL(ctx)
+	if err != nil {
+		t.Fatalf("ToDDL: %v", err)
+	}
+	for _, want := range []string{
+		`"id" serial NOT NULL`,
+		`"email" character varying(120) NOT NULL`,
+		`DEFAULT 'anonymous'::text`,
+		`"price" numeric(10,2)`,
+		`"tags" text[]`,
+		`DEFAULT now() NOT NULL`,
+		`PRIMARY KEY (id)`,
+		`UNIQUE (email)`,
+		`CHECK ((price >= (0)::numeric))`,
+		`FOREIGN KEY (parent_id) REFERENCES ` + table.Name + `(id) ON DELETE CASCADE`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS`,
+		`WHERE (deleted_at IS NULL);`,
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("DDL does not contain %q:\n%s", want, ddl)
+		}
+	}
+
+	if err := table.DropTable(); err != nil {
+		t.Fatalf("DropTable: %v", err)
+	}
+	if _, err := conn.Exec(ctx, ddl); err != nil {
+		t.Fatalf("executing the DDL: %v\n%s", err, ddl)
+	}
+
+	diff, err := table.SchemaDiff(ctx)
+	if err != nil {
+		t.Fatalf("SchemaDiff: %v", err)
+	}
+	if !diff.IsEmpty() {
+		t.Errorf("SchemaDiff of the recreated table is not empty: %s", diff.ToSQL())
+	}
+	recreated, err := table.ToDDL(ctx)
+	if err != nil {
+		t.Fatalf("ToDDL: %v", err)
+	}
+	if recreated != ddl {
+		t.Errorf("DDL of the recreated table differs:\n%s\nwant\n%s", recreated, ddl)
+	}
+}
@@ -0,0 +1,203 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TableFromStruct builds a Table whose Columns are derived from the fields of the struct type T.
+//
+// The column name comes from the field's `db` tag, or the field name in snake_case if there is no tag.
+// Options follow the name, separated by commas:
+//   - primary: PRIMARY KEY
+//   - unique: UNIQUE
+//   - notnull: NOT NULL
+//   - default:<expr>: DEFAULT <expr>, emitted verbatim (e.g. default:NOW()); must be the last option
+//
+// A tag of "-" skips the field, as do unexported fields. Embedded structs are flattened.
+// Go types map to column types as follows: string -> text, int/int32 -> integer, int16 -> smallint,
+// int64 -> bigint, float32 -> real, float64 -> double precision, bool -> boolean,
+// time.Time -> timestamptz, []byte -> bytea, []string -> text[], maps -> jsonb.
+// Pointer fields map to the type they point to.
+//
+// Example:
+//
+//	type User struct {
+//	    ID        int64     `db:"id,primary"`
+//	    Email     string    `db:"email,unique,notnull"`
+//	    CreatedAt time.Time `db:"created_at,notnull,default:NOW()"`
+//	}
+//	UsersTable, err := pggo.TableFromStruct[User]("users", *connection)
+func TableFromStruct[T any](name string, conn DatabaseConnection) (*Table, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("TableFromStruct requires a struct type, got %s", typ)
+	}
+	if !isValidIdentifier(name) {
+		return nil, fmt.Errorf("invalid table name: '%s'", name)
+	}
+
+	columns, err := columnsFromStruct(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("struct %s has no columns", typ)
+	}
+	return &Table{Name: name, Connection: conn, Columns: columns}, nil
+}
+
+// columnsFromStruct returns the columns for the exported fields of a struct type, flattening embedded structs.
+func columnsFromStruct(typ reflect.Type) ([]Column, error) {
+	var columns []Column
+	err := walkStructFields(typ, nil, func(field reflect.StructField, tag string, _ []int) error {
+		column, err := columnFromField(field, tag)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, column)
+		return nil
+	})
+	return columns, err
+}
+
+// walkStructFields calls fn with the exported fields of a struct type that map to columns, their `db` tag and
+// their index path from the outer struct, flattening embedded structs. Fields tagged "-" are skipped.
+func walkStructFields(typ reflect.Type, index []int, fn func(field reflect.StructField, tag string, index []int) error) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		tag, hasTag := field.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && !hasTag {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded != reflect.TypeOf(time.Time{}) {
+				if err := walkStructFields(embedded, fieldIndex, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if err := fn(field, tag, fieldIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldColumnName returns the column name of a struct field from its `db` tag, or the field name
+// in snake_case if the tag has no name, and the tag's options.
+func fieldColumnName(field reflect.StructField, tag string) (string, string) {
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = toSnakeCase(field.Name)
+	}
+	return name, options
+}
+
+// columnFromField builds a Column from a struct field and its `db` tag.
+func columnFromField(field reflect.StructField, tag string) (Column, error) {
+	name, options := fieldColumnName(field, tag)
+	if !isValidIdentifier(name) {
+		return Column{}, fmt.Errorf("invalid column name '%s' for field %s", name, field.Name)
+	}
+
+	columnDef, err := columnDefForType(field.Type)
+	if err != nil {
+		return Column{}, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+
+	for options != "" {
+		var option string
+		if strings.HasPrefix(options, "default:") {
+			// The default expression may itself contain commas, so it takes the rest of the tag
+			option, options = options, ""
+		} else {
+			option, options, _ = strings.Cut(options, ",")
+		}
+		switch {
+		case option == "primary":
+			columnDef.PrimaryKey()
+		case option == "unique":
+			columnDef.Unique()
+		case option == "notnull":
+			columnDef.NotNull()
+		case strings.HasPrefix(option, "default:"):
+			columnDef.DefaultRaw(strings.TrimPrefix(option, "default:"))
+		case option == "":
+		default:
+			return Column{}, fmt.Errorf("unknown db tag option '%s' on field %s", option, field.Name)
+		}
+	}
+
+	return Column{Name: name, DataType: *columnDef}, nil
+}
+
+// columnDefForType maps a Go type to a column type.
+func columnDefForType(typ reflect.Type) (*ColumnDef, error) {
+	dt := DataType{}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return dt.Timestamptz(), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return dt.Text(), nil
+	case reflect.Int, reflect.Int32, reflect.Uint16:
+		return dt.Integer(), nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return dt.Smallint(), nil
+	case reflect.Int64, reflect.Uint32:
+		return dt.Bigint(), nil
+	case reflect.Float32:
+		return dt.Real(), nil
+	case reflect.Float64:
+		return dt.DoublePrecision(), nil
+	case reflect.Bool:
+		return dt.Boolean(), nil
+	case reflect.Map:
+		return dt.Jsonb(), nil
+	case reflect.Slice:
+		switch typ.Elem().Kind() {
+		case reflect.Uint8:
+			return dt.Bytea(), nil
+		case reflect.String:
+			return dt.Array("text"), nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported Go type %s", typ)
+}
+
+// toSnakeCase converts a Go field name to snake_case, e.g. "CreatedAt" -> "created_at", "UserID" -> "user_id".
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at a lower->upper boundary, or at the last capital of an acronym ("IDName" -> "id_name")
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
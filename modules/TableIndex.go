@@ -0,0 +1,224 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// IndexDef describes an index created by CreateIndex.
+type IndexDef struct {
+	// Name is the index name. If empty, it defaults to "<table>_<col1>_<col2>_idx".
+	Name string
+	// Columns are the indexed columns, in order. Use IndexColumns for plain columns.
+	Columns []IndexColumn
+	// Unique creates a UNIQUE index.
+	Unique bool
+	// Method is the index access method (USING ...), e.g. "gin", "gist", "brin" or "hash".
+	// If empty, PostgreSQL's default (btree) is used.
+	Method string
+	// Include lists non-key columns stored in the index (INCLUDE (...)), for index-only scans.
+	Include []string
+	// Where is an optional raw SQL predicate that makes the index partial,
+	// e.g. "deleted_at IS NULL". It is emitted verbatim and must not contain untrusted input.
+	Where string
+}
+
+// IndexColumn is a column of an index with its optional operator class and sort order.
+type IndexColumn struct {
+	// Name is the column name.
+	Name string
+	// OperatorClass is the operator class for the column, e.g. "jsonb_path_ops" or "gin_trgm_ops".
+	OperatorClass string
+	// SortOrder is "ASC" or "DESC", optionally followed by "NULLS FIRST" or "NULLS LAST" (btree only).
+	SortOrder string
+}
+
+// IndexColumns returns index columns for the given column names, without operator class or sort order.
+//
+// Example:
+//
+//	pggo.IndexDef{Columns: pggo.IndexColumns("last_name", "first_name")}
+func IndexColumns(names ...string) []IndexColumn {
+	cols := make([]IndexColumn, len(names))
+	for i, name := range names {
+		cols[i] = IndexColumn{Name: name}
+	}
+	return cols
+}
+
+// AddIndex adds an index to the table definition. It is created by CreateTable (and planned by Migrate)
+// if it does not exist yet.
+//
+// Example:
+//
+//	UsersTable.AddIndex(pggo.IndexDef{Columns: pggo.IndexColumns("email"), Unique: true}).CreateTable()
+func (t *Table) AddIndex(def IndexDef) *Table {
+	t.Indexes = append(t.Indexes, def)
+	return t
+}
+
+// CreateIndex creates an index on the table if it does not already exist.
+// When def.Where is set, a partial index is created that only covers rows matching the predicate.
+//
+// Example (unique email among non-deleted users):
+//
+//	err := UsersTable.CreateIndex(ctx, pggo.IndexDef{
+//	    Name:    "users_email_active_key",
+//	    Columns: pggo.IndexColumns("email"),
+//	    Unique:  true,
+//	    Where:   "deleted_at IS NULL",
+//	})
+//
+// Example (GIN index on a JSONB column):
+//
+//	err := ProductsTable.CreateIndex(ctx, pggo.IndexDef{
+//	    Method:  "gin",
+//	    Columns: []pggo.IndexColumn{{Name: "data", OperatorClass: "jsonb_path_ops"}},
+//	})
+//	// CREATE INDEX IF NOT EXISTS "products_data_idx" ON "products" USING gin ("data" jsonb_path_ops)
+func (t *Table) CreateIndex(ctx context.Context, def IndexDef) error {
+	indexSQL, err := t.buildCreateIndexSQL(def)
+	if err != nil {
+		return err
+	}
+	if _, err := t.execSQL(ctx, "CreateIndex", indexSQL, nil); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	return nil
+}
+
+// buildCreateIndexSQL renders the CREATE INDEX statement for def.
+func (t *Table) buildCreateIndexSQL(def IndexDef) (string, error) {
+	if len(def.Columns) == 0 {
+		return "", fmt.Errorf("index must have at least one column")
+	}
+	cols := make([]string, len(def.Columns))
+	for i, col := range def.Columns {
+		colSQL, err := col.toSQL()
+		if err != nil {
+			return "", err
+		}
+		cols[i] = colSQL
+	}
+
+	name := t.indexName(def)
+	if !isValidIdentifier(name) {
+		return "", fmt.Errorf("invalid index name: '%s'", name)
+	}
+
+	unique := ""
+	if def.Unique {
+		unique = "UNIQUE "
+	}
+	using := ""
+	if def.Method != "" {
+		if !isValidIdentifier(def.Method) {
+			return "", fmt.Errorf("invalid index method: '%s'", def.Method)
+		}
+		using = " USING " + strings.ToLower(def.Method)
+	}
+	indexSQL := fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s%s (%s)",
+		unique, QuoteIdentifier(name), QuoteIdentifier(t.Name), using, strings.Join(cols, ", "))
+
+	if len(def.Include) > 0 {
+		include := make([]string, len(def.Include))
+		for i, col := range def.Include {
+			if !isValidIdentifier(col) {
+				return "", fmt.Errorf("invalid index include column name: '%s'", col)
+			}
+			include[i] = QuoteIdentifier(col)
+		}
+		indexSQL += fmt.Sprintf(" INCLUDE (%s)", strings.Join(include, ", "))
+	}
+
+	if where := strings.TrimSpace(def.Where); where != "" {
+		if err := validatePredicate(where); err != nil {
+			return "", fmt.Errorf("invalid index predicate: %w", err)
+		}
+		indexSQL += " WHERE " + where
+	}
+	return indexSQL, nil
+}
+
+// indexName returns the index's name, or the default "<table>_<col1>_<col2>_idx" if none is set.
+func (t *Table) indexName(def IndexDef) string {
+	if def.Name != "" {
+		return def.Name
+	}
+	names := make([]string, len(def.Columns))
+	for i, col := range def.Columns {
+		names[i] = col.Name
+	}
+	return t.Name + "_" + strings.Join(names, "_") + "_idx"
+}
+
+// validIndexSortOrders are the accepted IndexColumn.SortOrder values (upper-cased).
+var validIndexSortOrders = map[string]bool{
+	"ASC": true, "DESC": true,
+	"ASC NULLS FIRST": true, "ASC NULLS LAST": true,
+	"DESC NULLS FIRST": true, "DESC NULLS LAST": true,
+	"NULLS FIRST": true, "NULLS LAST": true,
+}
+
+// toSQL renders the index column as `"name" [opclass] [sort order]`.
+func (c IndexColumn) toSQL() (string, error) {
+	if !isValidIdentifier(c.Name) {
+		return "", fmt.Errorf("invalid index column name: '%s'", c.Name)
+	}
+	parts := []string{QuoteIdentifier(c.Name)}
+	if c.OperatorClass != "" {
+		if !isValidIdentifier(c.OperatorClass) {
+			return "", fmt.Errorf("invalid operator class: '%s'", c.OperatorClass)
+		}
+		parts = append(parts, c.OperatorClass)
+	}
+	if c.SortOrder != "" {
+		order := strings.ToUpper(strings.Join(strings.Fields(c.SortOrder), " "))
+		if !validIndexSortOrders[order] {
+			return "", fmt.Errorf("invalid sort order for index column '%s': '%s'", c.Name, c.SortOrder)
+		}
+		parts = append(parts, order)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// getIndexNamesFromDB returns the names of the indexes defined on the table in the database.
+func (t *Table) getIndexNamesFromDB() (map[string]bool, error) {
+	const QueryString = "SELECT indexname::text AS indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1"
+	rows, err := t.queryRows(t.context(), "getIndexNamesFromDB", QueryString, []interface{}{t.Name})
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if name, ok := row["indexname"].(string); ok {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// validatePredicate performs a basic sanity check on a raw SQL predicate:
+// it must not contain semicolons and its parentheses must be balanced.
+func validatePredicate(predicate string) error {
+	if strings.Contains(predicate, ";") {
+		return fmt.Errorf("predicate must not contain ';'")
+	}
+	depth := 0
+	for _, r := range predicate {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses in predicate")
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses in predicate")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package modules
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBuildCreateIndexSQL(t *testing.T) {
+	table := &Table{Name: "users"}
+	tests := []struct {
+		def  IndexDef
+		want string
+	}{
+		{
+			def:  IndexDef{Columns: IndexColumns("last_name", "first_name")},
+			want: `CREATE INDEX IF NOT EXISTS "users_last_name_first_name_idx" ON "users" ("last_name", "first_name")`,
+		},
+		{
+			def:  IndexDef{Name: "users_email_active_key", Columns: IndexColumns("email"), Unique: true, Where: " deleted_at IS NULL "},
+			want: `CREATE UNIQUE INDEX IF NOT EXISTS "users_email_active_key" ON "users" ("email") WHERE deleted_at IS NULL`,
+		},
+		{
+			def: IndexDef{Method: "GIN", Columns: []IndexColumn{{Name: "data", OperatorClass: "jsonb_path_ops"}},
+				Where: "(data ? 'tags') AND (status IN ('a', 'b'))"},
+			want: `CREATE INDEX IF NOT EXISTS "users_data_idx" ON "users" USING gin ("data" jsonb_path_ops) WHERE (data ? 'tags') AND (status IN ('a', 'b'))`,
+		},
+		{
+			def:  IndexDef{Columns: []IndexColumn{{Name: "created_at", SortOrder: "desc  nulls last"}}, Include: []string{"id"}},
+			want: `CREATE INDEX IF NOT EXISTS "users_created_at_idx" ON "users" ("created_at" DESC NULLS LAST) INCLUDE ("id")`,
+		},
+	}
+	for _, test := range tests {
+		sql, err := table.buildCreateIndexSQL(test.def)
+		if err != nil {
+			t.Errorf("buildCreateIndexSQL(%+v): %v", test.def, err)
+			continue
+		}
+		if sql != test.want {
+			t.Errorf("sql = %s\nwant  %s", sql, test.want)
+		}
+	}
+}
+
+func TestBuildCreateIndexSQLRejectsBadPredicates(t *testing.T) {
+	table := &Table{Name: "users"}
+	for _, where := range []string{
+		"deleted_at IS NULL; DROP TABLE users",
+		"(deleted_at IS NULL",
+		"deleted_at IS NULL)",
+		") OR (TRUE",
+	} {
+		if _, err := table.buildCreateIndexSQL(IndexDef{Columns: IndexColumns("email"), Where: where}); err == nil {
+			t.Errorf("predicate %q was accepted", where)
+		}
+	}
+}
+
+func TestPartialUniqueIndex(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil,
+		Column{Name: "email", DataType: *DataType{}.Text()},
+		Column{Name: "deleted_at", DataType: *DataType{}.Timestamptz()})
+	ctx := context.Background()
+	if err := table.CreateIndex(ctx, IndexDef{Columns: IndexColumns("email"), Unique: true, Where: "deleted_at IS NULL"}); err != nil {
+		t.Fatalf("CreateIndex: %v", err)
+	}
+
+	if _, err := table.Insert(map[string]interface{}{"email": "a@example.com", "deleted_at": time.Now()}); err != nil {
+		t.Fatalf("Insert of a deleted row: %v", err)
+	}
+	if _, err := table.Insert(map[string]interface{}{"email": "a@example.com"}); err != nil {
+		t.Fatalf("Insert of a live row with the email of a deleted one: %v", err)
+	}
+	if _, err := table.Insert(map[string]interface{}{"email": "a@example.com"}); err == nil {
+		t.Fatal("Insert of a second live row with the same email succeeded")
+	}
+}
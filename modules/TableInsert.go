@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Insert inserts a single row into the table.
@@ -11,82 +12,116 @@ import (
 // It automatically filters out any keys in the data map that do not correspond to defined columns in the table.
 // Column names are safely quoted to prevent identifier injection.
 // Values are passed as parameters to prevent SQL injection.
+// Values for array columns (DataType.Array) are Go slices, e.g. []string{"go", "sql"};
+// pgx encodes them as PostgreSQL arrays, and they are returned as []interface{}.
 //
 // Parameters:
 //   - data: A map where keys are column names and values are the data to insert.
 //
 // Returns:
-//   - map[string]interface{}: The inserted row data, including any auto-generated fields (like ID).
+//   - map[string]interface{}: The inserted row data, including any auto-generated fields (like ID),
+//     or nil if Returning was called without columns.
 //   - error: An error if the insert operation fails or if no valid columns are provided.
 func (t *Table) Insert(data map[string]interface{}) (map[string]interface{}, error) {
+	rows, err := t.insertRow("Insert", data, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		if !t.returnsRows() {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no rows returned")
+	}
+	return rows[0], nil
+}
+
+// InsertIgnore inserts a single row, silently skipping it if it conflicts with an existing row
+// (INSERT ... ON CONFLICT DO NOTHING).
+//
+// Returns the inserted row, or nil (and no error) if the row already existed and nothing was inserted.
+//
+// Example (idempotent many-to-many relation insert):
+//
+//	_, err := UserRolesTable.InsertIgnore(map[string]interface{}{"user_id": 1, "role_id": 2})
+func (t *Table) InsertIgnore(data map[string]interface{}) (map[string]interface{}, error) {
+	rows, err := t.insertRow("InsertIgnore", data, " ON CONFLICT DO NOTHING")
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[0], nil
+}
+
+// insertRow builds and executes a single-row INSERT, appending conflictClause before RETURNING.
+// Returned rows are added to the cache.
+func (t *Table) insertRow(operation string, data map[string]interface{}, conflictClause string) ([]map[string]interface{}, error) {
+	insertSQL, args, err := t.buildInsertSQL(data, conflictClause)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := t.queryRows(t.context(), operation, insertSQL, args)
+	if err != nil {
+		return nil, t.wrapUUIDError(err)
+	}
+
+	if len(rows) > 0 && !t.returningSet {
+		t.cacheRowsAsync(rows[:1])
+	}
+	t.emit(EventInsert, rows, nil)
+
+	return rows, nil
+}
+
+// buildInsertSQL builds a single-row INSERT statement and its arguments.
+// Columns are emitted in sorted order so the generated SQL is deterministic.
+func (t *Table) buildInsertSQL(data map[string]interface{}, conflictClause string) (string, []interface{}, error) {
 	// Build columns and args
 	columns := make([]string, 0, len(data))
+	placeholders := make([]string, 0, len(data))
 	args := make([]interface{}, 0, len(data))
+	argIndex := 1
 
-	// Filter columns to match defined schema (ignore unknown columns)
-	validColumns := make(map[string]bool)
-	for _, col := range t.Columns {
-		validColumns[col.Name] = true
+	// Reject malformed keys before filtering, so untrusted input never reaches the SQL
+	if err := validateMapKeys(data); err != nil {
+		return "", nil, err
 	}
+	data, err := t.prepareInsertData(data, time.Now().UTC())
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Filter columns to match defined schema (ignore unknown columns)
+	validColumns := t.definedColumnSet()
 
-	for col, val := range data {
+	for _, col := range sortedKeys(data) {
 		if validColumns[col] {
 			columns = append(columns, QuoteIdentifier(col))
-			args = append(args, val)
+			placeholders = append(placeholders, insertValue(data[col], &argIndex, &args))
 		}
 	}
 
 	if len(columns) == 0 {
-		return nil, fmt.Errorf("no valid columns provided for insert")
+		return "", nil, fmt.Errorf("no valid columns provided for insert")
 	}
 
-	placeholders := make([]string, len(columns))
-	for i := range columns {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	returningClause, err := t.returningClause("")
+	if err != nil {
+		return "", nil, err
 	}
 
-	returningClause := " RETURNING *"
-
 	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)%s",
+		"INSERT INTO %s (%s) VALUES (%s)%s%s",
 		t.Name,
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
+		conflictClause,
 		returningClause,
 	)
-
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
-	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
-	}
-	defer conn.Release() // Release connection back to pool when done
-
-	// Execute QueryRow
-	rows, err := conn.Query(context.Background(), insertSQL, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute insert with returning: %w", err)
-	}
-	defer rows.Close() // Also close the rows when done
-
-	if !rows.Next() {
-		return nil, fmt.Errorf("no rows returned")
-	}
-
-	result, err := t.fetchRowResult(rows, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch returned row: %w", err)
-	}
-
-	if t.Cached {
-		go func(row map[string]interface{}) {
-			if key, err := t.getCacheKey(row); err == nil {
-				_ = t.setCache(key, row)
-			}
-		}(result)
-	}
-
-	return result, nil
+	return insertSQL, args, nil
 }
 
 // InsertMany inserts multiple rows into the table in a single query.
@@ -101,30 +136,193 @@ func (t *Table) Insert(data map[string]interface{}) (map[string]interface{}, err
 //   - []map[string]interface{}: A slice of maps representing the inserted rows.
 //   - error: An error if the insert operation fails.
 func (t *Table) InsertMany(dataList []map[string]interface{}) ([]map[string]interface{}, error) {
+	results, err := t.insertRows("InsertMany", dataList, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 && t.returnsRows() {
+		return nil, fmt.Errorf("no rows returned")
+	}
+	return results, nil
+}
+
+// InsertManyIgnore inserts multiple rows in a single query, silently skipping rows that conflict
+// with existing rows (INSERT ... ON CONFLICT DO NOTHING).
+//
+// Returns only the rows that were actually inserted; the result is empty (and not an error)
+// if every row already existed.
+func (t *Table) InsertManyIgnore(dataList []map[string]interface{}) ([]map[string]interface{}, error) {
+	return t.insertRows("InsertManyIgnore", dataList, " ON CONFLICT DO NOTHING")
+}
+
+// ConflictAction is what InsertManyOnConflict does with a row that conflicts with an existing one.
+type ConflictAction int
+
+const (
+	// ConflictDoNothing skips the conflicting row (ON CONFLICT DO NOTHING).
+	ConflictDoNothing ConflictAction = iota
+	// ConflictDoUpdate overwrites the existing row with the inserted values (ON CONFLICT DO UPDATE).
+	ConflictDoUpdate
+)
+
+// InsertManyOnConflict inserts multiple rows in a single query, resolving rows that conflict with
+// existing ones on conflictColumns (a unique index or constraint) with action, instead of failing
+// the whole statement:
+//   - ConflictDoNothing skips them. conflictColumns may be empty to skip conflicts on any constraint.
+//   - ConflictDoUpdate updates the existing rows with the inserted values (a bulk upsert). Every inserted
+//     column is overwritten except conflictColumns, primary key columns and the created_at column;
+//     the updated_at column (TimestampColumns) is set to now() and the VersionColumn is incremented.
+//     conflictColumns are required, and the same key may not appear twice in dataList.
+//
+// Returns the rows that were inserted or updated; with ConflictDoNothing the skipped rows are missing,
+// so the result can be shorter than dataList (or empty, which is not an error).
+//
+// Example:
+//
+//	rows, err := ProductsTable.InsertManyOnConflict(products, []string{"sku"}, pggo.ConflictDoUpdate)
+func (t *Table) InsertManyOnConflict(dataList []map[string]interface{}, conflictColumns []string, action ConflictAction) ([]map[string]interface{}, error) {
 	if len(dataList) == 0 {
 		return nil, fmt.Errorf("no data provided to insert")
 	}
+	if err := validateMapKeys(dataList[0]); err != nil {
+		return nil, err
+	}
+	firstRow, err := t.prepareInsertData(dataList[0], time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	conflictClause, err := t.buildConflictClause(t.insertColumns(firstRow), conflictColumns, action)
+	if err != nil {
+		return nil, err
+	}
+	return t.insertRows("InsertManyOnConflict", dataList, conflictClause)
+}
 
-	var results []map[string]interface{}
+// buildConflictClause renders the ON CONFLICT clause of InsertManyOnConflict for the inserted columns.
+func (t *Table) buildConflictClause(columns, conflictColumns []string, action ConflictAction) (string, error) {
+	target := ""
+	if len(conflictColumns) > 0 {
+		quoted := make([]string, len(conflictColumns))
+		for i, col := range conflictColumns {
+			if !isValidIdentifier(col) {
+				return "", fmt.Errorf("invalid conflict column name: '%s'", col)
+			}
+			quoted[i] = QuoteIdentifier(col)
+		}
+		target = " (" + strings.Join(quoted, ", ") + ")"
+	}
+
+	switch action {
+	case ConflictDoNothing:
+		return " ON CONFLICT" + target + " DO NOTHING", nil
+	case ConflictDoUpdate:
+		if target == "" {
+			return "", fmt.Errorf("ConflictDoUpdate requires conflict columns")
+		}
+		keep := make(map[string]bool, len(conflictColumns)+2)
+		for _, col := range conflictColumns {
+			keep[col] = true
+		}
+		for _, col := range t.Columns {
+			if col.DataType.isPrimaryKey {
+				keep[col.Name] = true
+			}
+		}
+		keep[t.UUIDPrimaryKey] = true
+		keep[t.TimestampColumns.CreatedAt] = true
+		keep[t.TimestampColumns.UpdatedAt] = true
+		keep[t.VersionColumn] = true
 
-	// Filter columns to match defined schema
-	validColumns := make(map[string]bool)
-	for _, col := range t.Columns {
-		validColumns[col.Name] = true
+		setParts := make([]string, 0, len(columns)+1)
+		for _, col := range columns {
+			if !keep[col] {
+				setParts = append(setParts, fmt.Sprintf("%s = EXCLUDED.%s", QuoteIdentifier(col), QuoteIdentifier(col)))
+			}
+		}
+		if len(setParts) == 0 {
+			return "", fmt.Errorf("no columns left to update on conflict")
+		}
+		if updatedAt := t.TimestampColumns.UpdatedAt; updatedAt != "" {
+			// The managed timestamp overrides any value provided in the rows
+			setParts = append(setParts, fmt.Sprintf("%s = now()", QuoteIdentifier(updatedAt)))
+		}
+		if version := t.VersionColumn; version != "" {
+			// Unqualified, the column would be ambiguous with EXCLUDED's
+			setParts = append(setParts, t.versionIncrement(QuoteIdentifier(t.Name)))
+		}
+		return " ON CONFLICT" + target + " DO UPDATE SET " + strings.Join(setParts, ", "), nil
 	}
+	return "", fmt.Errorf("invalid conflict action: %d", int(action))
+}
 
-	// Determine columns from the first row, filtering invalid ones
-	columns := make([]string, 0)
-	rawColumns := make([]string, 0) // Keep raw names for looking up values
-	for col := range dataList[0] {
+// insertColumns returns the defined columns present in row, in sorted order.
+func (t *Table) insertColumns(row map[string]interface{}) []string {
+	validColumns := t.definedColumnSet()
+	columns := make([]string, 0, len(row))
+	for _, col := range sortedKeys(row) {
 		if validColumns[col] {
-			columns = append(columns, QuoteIdentifier(col))
-			rawColumns = append(rawColumns, col)
+			columns = append(columns, col)
 		}
 	}
+	return columns
+}
+
+// insertRows builds and executes a multi-row INSERT, appending conflictClause before RETURNING.
+// Returned rows are added to the cache.
+func (t *Table) insertRows(operation string, dataList []map[string]interface{}, conflictClause string) ([]map[string]interface{}, error) {
+	insertSQL, args, err := t.buildInsertManySQL(dataList, conflictClause)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := t.queryRows(t.context(), operation, insertSQL, args)
+	if err != nil {
+		return nil, t.wrapUUIDError(err)
+	}
+
+	if !t.returningSet {
+		t.cacheRowsAsync(results)
+	}
+	t.emit(EventInsert, results, nil)
+
+	return results, nil
+}
+
+// buildInsertManySQL builds a multi-row INSERT statement and its arguments.
+// The columns are taken from the first row, in sorted order.
+func (t *Table) buildInsertManySQL(dataList []map[string]interface{}, conflictClause string) (string, []interface{}, error) {
+	if len(dataList) == 0 {
+		return "", nil, fmt.Errorf("no data provided to insert")
+	}
+
+	// Reject malformed keys in any row before building the statement
+	for _, data := range dataList {
+		if err := validateMapKeys(data); err != nil {
+			return "", nil, err
+		}
+	}
+	if t.TimestampColumns.CreatedAt != "" || t.UUIDPrimaryKey != "" {
+		now := time.Now().UTC()
+		prepared := make([]map[string]interface{}, len(dataList))
+		for i, data := range dataList {
+			row, err := t.prepareInsertData(data, now)
+			if err != nil {
+				return "", nil, err
+			}
+			prepared[i] = row
+		}
+		dataList = prepared
+	}
+
+	// Determine columns from the first row, filtering invalid ones
+	rawColumns := t.insertColumns(dataList[0]) // Keep raw names for looking up values
+	columns := make([]string, len(rawColumns))
+	for i, col := range rawColumns {
+		columns[i] = QuoteIdentifier(col)
+	}
 
 	if len(columns) == 0 {
-		return nil, fmt.Errorf("no valid columns found in the first row of dataList")
+		return "", nil, fmt.Errorf("no valid columns found in the first row of dataList")
 	}
 
 	// Build placeholders and args
@@ -135,54 +333,114 @@ func (t *Table) InsertMany(dataList []map[string]interface{}) ([]map[string]inte
 	for _, data := range dataList {
 		placeholders := make([]string, len(columns))
 		for i, colName := range rawColumns {
-			placeholders[i] = fmt.Sprintf("$%d", argIndex)
-			args = append(args, data[colName])
-			argIndex++
+			placeholders[i] = insertValue(data[colName], &argIndex, &args)
 		}
 		valuePlaceholders = append(valuePlaceholders, fmt.Sprintf("(%s)", strings.Join(placeholders, ", ")))
 	}
 
-	returningClause := " RETURNING *"
+	returningClause, err := t.returningClause("")
+	if err != nil {
+		return "", nil, err
+	}
 
 	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES %s%s",
+		"INSERT INTO %s (%s) VALUES %s%s%s",
 		t.Name,
 		strings.Join(columns, ", "),
 		strings.Join(valuePlaceholders, ", "),
+		conflictClause,
 		returningClause,
 	)
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	return insertSQL, args, nil
+}
+
+// sqlExpression is an internally generated SQL expression used as an insert value,
+// emitted verbatim instead of as a parameter (e.g. gen_random_uuid()). It is never built from user input.
+type sqlExpression string
+
+// InsertFromSelect copies the rows of a SELECT into the table with INSERT INTO "t" ("col1", ...) SELECT ...,
+// without loading them into Go, and returns the number of rows inserted.
+//
+// columns are the target columns, in the order of the SELECT list; if empty, the SELECT must return
+// every column of the table in order. Column names are quoted. selectSQL is used as-is, so user-provided
+// values must be passed as parameters ($1, $2, ...).
+//
+// The inserted rows are not cached; created_at and UUID columns are only filled by their database defaults.
+//
+// Example:
+//
+//	copied, err := ArchiveTable.InsertFromSelect(ctx, []string{"id", "email"},
+//	    "SELECT id, email FROM users WHERE deleted_at < $1", cutoff)
+func (t *Table) InsertFromSelect(ctx context.Context, columns []string, selectSQL string, params ...interface{}) (int64, error) {
+	insertSQL, err := t.buildInsertFromSelectSQL(columns, selectSQL, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return 0, err
 	}
-	defer conn.Release() // Release connection back to pool when done
-
-	// Execute Query
-	rows, err := conn.Query(context.Background(), insertSQL, args...)
+	inserted, err := t.execSQL(ctx, "InsertFromSelect", insertSQL, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute insert many with returning: %w", err)
+		return 0, fmt.Errorf("failed to execute InsertFromSelect: %w", err)
 	}
-	defer rows.Close() // Also close the rows when done
+	return inserted, nil
+}
 
-	results, err = t.fetchRowsResult(rows)
+// InsertFromSelectReturning is like InsertFromSelect but returns the inserted rows (see Returning),
+// and adds them to the cache.
+func (t *Table) InsertFromSelectReturning(ctx context.Context, columns []string, selectSQL string, params ...interface{}) ([]map[string]interface{}, error) {
+	returningClause, err := t.returningClause("")
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch returned rows: %w", err)
+		return nil, err
 	}
-
-	if len(results) == 0 {
-		return nil, fmt.Errorf("no rows returned")
+	insertSQL, err := t.buildInsertFromSelectSQL(columns, selectSQL, returningClause)
+	if err != nil {
+		return nil, err
 	}
+	results, err := t.queryRows(ctx, "InsertFromSelectReturning", insertSQL, params)
+	if err != nil {
+		return nil, err
+	}
+	if !t.returningSet {
+		t.cacheRowsAsync(results)
+	}
+	t.emit(EventInsert, results, nil)
+	return results, nil
+}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
+// buildInsertFromSelectSQL builds an INSERT ... SELECT statement.
+func (t *Table) buildInsertFromSelectSQL(columns []string, selectSQL, returningClause string) (string, error) {
+	selectSQL = strings.TrimSuffix(strings.TrimSpace(selectSQL), ";")
+	if selectSQL == "" {
+		return "", fmt.Errorf("no SELECT statement provided")
+	}
+	columnList := ""
+	if len(columns) > 0 {
+		quoted := make([]string, len(columns))
+		for i, col := range columns {
+			if !isValidIdentifier(col) {
+				return "", fmt.Errorf("invalid column name: '%s'", col)
 			}
-		}(results)
+			quoted[i] = QuoteIdentifier(col)
+		}
+		columnList = " (" + strings.Join(quoted, ", ") + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s%s %s%s", QuoteIdentifier(t.Name), columnList, selectSQL, returningClause), nil
+}
+
+// insertValue renders the VALUES entry for val: the expression itself for an sqlExpression,
+// otherwise the next parameter placeholder, appending val to args.
+func insertValue(val interface{}, argIndex *int, args *[]interface{}) string {
+	if expr, ok := val.(sqlExpression); ok {
+		return string(expr)
 	}
+	placeholder := fmt.Sprintf("$%d", *argIndex)
+	*args = append(*args, val)
+	*argIndex++
+	return placeholder
+}
 
-	return results, nil
+// prepareInsertData fills in the values managed by the table (CreatedAt timestamp, VersionColumn,
+// UUID primary key) that are missing from data. The caller's map is not modified.
+func (t *Table) prepareInsertData(data map[string]interface{}, now time.Time) (map[string]interface{}, error) {
+	data = t.withCreatedAt(data, now)
+	data = t.withInitialVersion(data)
+	return t.withUUIDPrimaryKey(data)
 }
@@ -0,0 +1,49 @@
+package modules
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInsertReturningNoColumns(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+
+	row, err := table.Returning().Insert(map[string]interface{}{"name": "a"})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if row != nil {
+		t.Fatalf("Insert with Returning() = %v, want nil", row)
+	}
+
+	count, err := table.Count(context.Background())
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Count = %d, want 1", count)
+	}
+}
+
+func TestInsertStructReturningNoColumns(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+
+	type item struct {
+		ID   int64  `db:"id,primary"`
+		Name string `db:"name"`
+	}
+	typed, err := NewTypedTable[item](table.Returning())
+	if err != nil {
+		t.Fatalf("NewTypedTable: %v", err)
+	}
+	value := &item{Name: "a"}
+	inserted, err := typed.Insert(value)
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if inserted != value {
+		t.Fatalf("Insert with Returning() = %+v, want the value unchanged", inserted)
+	}
+}
@@ -0,0 +1,295 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrLockNotAvailable is returned by the locking reads when NoWait is set and a row is locked by another transaction.
+var ErrLockNotAvailable = errors.New("row lock not available")
+
+// LockMode is the row-level lock taken by a locking read (SELECT ... FOR UPDATE and friends).
+type LockMode int
+
+const (
+	// ForUpdate locks the rows against updates, deletes and other locks. Use it to read-then-update.
+	ForUpdate LockMode = iota
+	// ForShare locks the rows against updates and deletes, but allows other ForShare/ForKeyShare locks.
+	ForShare
+	// ForNoKeyUpdate is like ForUpdate but weaker: it does not block ForKeyShare, so inserts
+	// referencing the rows through foreign keys can proceed. Use it when the key columns are not updated.
+	ForNoKeyUpdate
+	// ForKeyShare only blocks deletes and updates of the key columns.
+	ForKeyShare
+)
+
+// String returns the SQL locking clause of the mode, e.g. "FOR UPDATE".
+func (m LockMode) String() string {
+	switch m {
+	case ForUpdate:
+		return "FOR UPDATE"
+	case ForShare:
+		return "FOR SHARE"
+	case ForNoKeyUpdate:
+		return "FOR NO KEY UPDATE"
+	case ForKeyShare:
+		return "FOR KEY SHARE"
+	}
+	return fmt.Sprintf("LockMode(%d)", int(m))
+}
+
+// LockOptions configures a locking read.
+type LockOptions struct {
+	// Mode is the lock taken on the selected rows. Defaults to ForUpdate.
+	Mode LockMode
+	// SkipLocked skips rows locked by other transactions instead of waiting for them (SKIP LOCKED),
+	// e.g. to let several workers pick jobs from the same queue table.
+	SkipLocked bool
+	// NoWait fails with ErrLockNotAvailable instead of waiting for rows locked by other transactions (NOWAIT).
+	NoWait bool
+}
+
+// clause returns the locking clause for the options, e.g. " FOR UPDATE SKIP LOCKED".
+func (o LockOptions) clause() (string, error) {
+	if o.Mode < ForUpdate || o.Mode > ForKeyShare {
+		return "", fmt.Errorf("invalid lock mode: %d", int(o.Mode))
+	}
+	if o.SkipLocked && o.NoWait {
+		return "", fmt.Errorf("SkipLocked and NoWait cannot be combined")
+	}
+	clause := " " + o.Mode.String()
+	if o.SkipLocked {
+		clause += " SKIP LOCKED"
+	} else if o.NoWait {
+		clause += " NOWAIT"
+	}
+	return clause, nil
+}
+
+// FetchOneForUpdate fetches a single row inside tx and locks it with SELECT ... FOR UPDATE,
+// so no other transaction can modify it until tx is committed or rolled back.
+// whereArgs are the same as for FetchOne. Returns an error if no rows are found.
+//
+// Locked rows are read from the primary and are never served from or stored in the cache.
+//
+// Example:
+//
+//	tx, err := db.Begin(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	defer tx.Rollback(ctx)
+//	account, err := AccountsTable.FetchOneForUpdate(ctx, tx, map[string]interface{}{"id": 5})
+//	if err != nil {
+//	    return err
+//	}
+//	// ... check the balance, then update the row inside tx ...
+//	return tx.Commit(ctx)
+func (t *Table) FetchOneForUpdate(ctx context.Context, tx *Transaction, whereArgs ...interface{}) (map[string]interface{}, error) {
+	return t.FetchOneLocked(ctx, tx, LockOptions{Mode: ForUpdate}, whereArgs...)
+}
+
+// FetchManyForUpdate fetches the rows matching whereArgs inside tx and locks them with SELECT ... FOR UPDATE.
+func (t *Table) FetchManyForUpdate(ctx context.Context, tx *Transaction, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	return t.FetchManyLocked(ctx, tx, LockOptions{Mode: ForUpdate}, whereArgs...)
+}
+
+// FetchOneForShare fetches a single row inside tx and locks it with SELECT ... FOR SHARE,
+// so it cannot be modified until tx ends while still allowing other transactions to read-lock it.
+func (t *Table) FetchOneForShare(ctx context.Context, tx *Transaction, whereArgs ...interface{}) (map[string]interface{}, error) {
+	return t.FetchOneLocked(ctx, tx, LockOptions{Mode: ForShare}, whereArgs...)
+}
+
+// FetchManyForShare fetches the rows matching whereArgs inside tx and locks them with SELECT ... FOR SHARE.
+func (t *Table) FetchManyForShare(ctx context.Context, tx *Transaction, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	return t.FetchManyLocked(ctx, tx, LockOptions{Mode: ForShare}, whereArgs...)
+}
+
+// FetchOneLocked fetches a single row inside tx with the lock described by opts:
+// SELECT * FROM "t" WHERE ... LIMIT 1 FOR UPDATE [SKIP LOCKED | NOWAIT].
+//
+// With SkipLocked, rows locked by other transactions are skipped; an error is returned if none is left.
+// With NoWait, ErrLockNotAvailable is returned if the row is locked.
+//
+// Example:
+//
+//	job, err := JobsTable.FetchOneLocked(ctx, tx, pggo.LockOptions{Mode: pggo.ForUpdate, NoWait: true},
+//	    map[string]interface{}{"id": 7})
+//	if errors.Is(err, pggo.ErrLockNotAvailable) {
+//	    // another worker holds the job
+//	}
+func (t *Table) FetchOneLocked(ctx context.Context, tx *Transaction, opts LockOptions, whereArgs ...interface{}) (map[string]interface{}, error) {
+	rows, err := t.fetchLocked(ctx, tx, "FetchOneLocked", opts, " LIMIT 1", whereArgs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows found")
+	}
+	return rows[0], nil
+}
+
+// FetchManyLocked fetches the rows matching whereArgs inside tx with the lock described by opts.
+// See FetchOneLocked.
+func (t *Table) FetchManyLocked(ctx context.Context, tx *Transaction, opts LockOptions, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	return t.fetchLocked(ctx, tx, "FetchManyLocked", opts, "", whereArgs)
+}
+
+// fetchLocked runs a locking SELECT inside tx. suffix is inserted before the locking clause (e.g. " LIMIT 1").
+func (t *Table) fetchLocked(ctx context.Context, tx *Transaction, operation string, opts LockOptions, suffix string, whereArgs []interface{}) ([]map[string]interface{}, error) {
+	lockClause, err := opts.clause()
+	if err != nil {
+		return nil, err
+	}
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	selectSQL := fmt.Sprintf("SELECT * FROM %s%s%s%s", QuoteIdentifier(t.Name), whereClause, suffix, lockClause)
+
+	rows, err := t.txRows(ctx, tx, operation, selectSQL, params)
+	if err != nil {
+		return nil, wrapLockError(err)
+	}
+	return rows, nil
+}
+
+// wrapLockError maps PostgreSQL's lock_not_available error (SQLSTATE 55P03, raised by NOWAIT) to ErrLockNotAvailable.
+func wrapLockError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "55P03" {
+		return fmt.Errorf("%w: %w", ErrLockNotAvailable, err)
+	}
+	return err
+}
+
+// ClaimRow atomically claims the next row matching whereArgs inside tx, for queue workers:
+// it locks the first row in ClaimOrderBy order with SELECT ... LIMIT 1 FOR UPDATE SKIP LOCKED,
+// so concurrent workers never wait for or receive the same row, and then sets ClaimColumn to ClaimValue
+// on it. Both statements run in tx; the claim is released if tx is rolled back.
+//
+// The row is identified for the update by the primary key column (PrimaryKey() or UUIDPrimaryKey),
+// or, without one, by the cache key columns (CacheKey/CacheKeys) if they are declared unique. Returns the claimed row as updated, or nil, nil if no row is available.
+//
+// Example:
+//
+//	JobsTable.ClaimColumn = "status"
+//	JobsTable.ClaimValue = "processing"
+//	JobsTable.ClaimOrderBy = "created_at"
+//
+//	tx, err := db.Begin(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	defer tx.Rollback(ctx)
+//	job, err := JobsTable.ClaimRow(ctx, tx, map[string]interface{}{"status": "pending"})
+//	if err != nil || job == nil {
+//	    return err // nil job: the queue is empty
+//	}
+//	// ... process the job ...
+//	return tx.Commit(ctx)
+func (t *Table) ClaimRow(ctx context.Context, tx *Transaction, whereArgs ...interface{}) (map[string]interface{}, error) {
+	keyColumns := t.claimKeyColumns()
+	if len(keyColumns) == 0 {
+		return nil, fmt.Errorf("ClaimRow requires a primary key column or a unique CacheKey on table '%s'", t.Name)
+	}
+	orderBy := make([]string, 0, len(keyColumns))
+	if t.ClaimOrderBy != "" {
+		if !isValidIdentifier(t.ClaimOrderBy) {
+			return nil, fmt.Errorf("invalid ClaimOrderBy column name: '%s'", t.ClaimOrderBy)
+		}
+		orderBy = append(orderBy, QuoteIdentifier(t.ClaimOrderBy))
+	} else {
+		for _, col := range keyColumns {
+			orderBy = append(orderBy, QuoteIdentifier(col))
+		}
+	}
+
+	suffix := " ORDER BY " + strings.Join(orderBy, ", ") + " LIMIT 1"
+	rows, err := t.fetchLocked(ctx, tx, "ClaimRow", LockOptions{Mode: ForUpdate, SkipLocked: true}, suffix, whereArgs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+
+	key := make(map[string]interface{}, len(keyColumns))
+	for _, col := range keyColumns {
+		val, found := row[col]
+		if !found {
+			return nil, fmt.Errorf("claimed row has no '%s' column", col)
+		}
+		key[col] = val
+	}
+	if t.Cached {
+		// The cached copy becomes stale once tx commits
+		if cacheKey, err := t.getCacheKey(row); err == nil {
+			_ = t.deleteCache(cacheKey)
+		}
+	}
+	if t.ClaimColumn == "" {
+		return row, nil
+	}
+
+	updateSQL, args, err := t.buildUpdateSQL(map[string]interface{}{t.ClaimColumn: t.ClaimValue}, []interface{}{key})
+	if err != nil {
+		return nil, err
+	}
+	updated, err := t.txRows(ctx, tx, "ClaimRow", updateSQL, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		// No RETURNING clause (see Returning)
+		return row, nil
+	}
+	return updated[0], nil
+}
+
+// claimKeyColumns returns the columns that identify a row claimed by ClaimRow: the primary key column,
+// or the cache key columns if they are declared unique. A non-unique cache key could match other rows.
+func (t *Table) claimKeyColumns() []string {
+	if pk, err := t.primaryKeyColumn(); err == nil {
+		return []string{pk}
+	}
+	if keyColumns := t.cacheKeyColumns(); len(keyColumns) > 0 && t.isUniqueKey(keyColumns) {
+		return keyColumns
+	}
+	return nil
+}
+
+// isUniqueKey reports whether the table definition makes the given columns unique together:
+// a single column declared PrimaryKey() or Unique(), or a unique index on exactly these columns without a predicate.
+func (t *Table) isUniqueKey(columns []string) bool {
+	if len(columns) == 1 {
+		for _, col := range t.Columns {
+			if col.Name == columns[0] && (col.DataType.isPrimaryKey || col.DataType.isUnique) {
+				return true
+			}
+		}
+	}
+	for _, def := range t.Indexes {
+		if !def.Unique || def.Where != "" || len(def.Columns) != len(columns) {
+			continue
+		}
+		matches := true
+		for _, col := range def.Columns {
+			if !slices.Contains(columns, col.Name) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestClaimKeyColumns(t *testing.T) {
+	text := func() ColumnDef { return *DataType{}.Text() }
+	tests := []struct {
+		name  string
+		table Table
+		want  []string
+	}{
+		{
+			name: "primary key before cache key",
+			table: Table{CacheKey: "status", Columns: []Column{
+				{Name: "id", DataType: *DataType{}.Serial().PrimaryKey()},
+				{Name: "status", DataType: text()},
+			}},
+			want: []string{"id"},
+		},
+		{
+			name:  "uuid primary key",
+			table: Table{UUIDPrimaryKey: "uid", CacheKey: "status", Columns: []Column{{Name: "status", DataType: text()}}},
+			want:  []string{"uid"},
+		},
+		{
+			name:  "unique cache key",
+			table: Table{CacheKey: "email", Columns: []Column{{Name: "email", DataType: *DataType{}.Text().Unique()}}},
+			want:  []string{"email"},
+		},
+		{
+			name: "cache keys with a unique index",
+			table: Table{
+				CacheKeys: []string{"tenant", "slug"},
+				Columns:   []Column{{Name: "tenant", DataType: text()}, {Name: "slug", DataType: text()}},
+				Indexes:   []IndexDef{{Columns: IndexColumns("slug", "tenant"), Unique: true}},
+			},
+			want: []string{"tenant", "slug"},
+		},
+		{
+			name: "cache keys with a partial unique index",
+			table: Table{
+				CacheKeys: []string{"tenant", "slug"},
+				Columns:   []Column{{Name: "tenant", DataType: text()}, {Name: "slug", DataType: text()}},
+				Indexes:   []IndexDef{{Columns: IndexColumns("tenant", "slug"), Unique: true, Where: "deleted_at IS NULL"}},
+			},
+		},
+		{
+			name:  "non-unique cache key",
+			table: Table{CacheKey: "status", Columns: []Column{{Name: "status", DataType: text()}}},
+		},
+	}
+	for _, test := range tests {
+		if got := test.table.claimKeyColumns(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: claimKeyColumns() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestClaimRowConcurrentWorkers(t *testing.T) {
+	conn := newTestConnection(t)
+	// The non-unique cache key must not be used to identify the claimed row
+	table := newTestTable(t, conn, func(table *Table) {
+		table.CacheKey = "status"
+		table.ClaimColumn = "status"
+		table.ClaimValue = "processing"
+	}, Column{Name: "status", DataType: *DataType{}.Text().NotNull()})
+	for i := 0; i < 3; i++ {
+		if _, err := table.Insert(map[string]interface{}{"status": "pending"}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	// Both workers keep their transaction open until the other has claimed a row too
+	ctx := context.Background()
+	var claimed, commit, done sync.WaitGroup
+	claimed.Add(2)
+	commit.Add(1)
+	done.Add(2)
+	ids := make([]interface{}, 2)
+	errs := make([]error, 2)
+	for i := range ids {
+		go func(i int) {
+			defer done.Done()
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				errs[i] = err
+				claimed.Done()
+				return
+			}
+			defer tx.Rollback(ctx)
+			row, err := table.ClaimRow(ctx, tx, map[string]interface{}{"status": "pending"})
+			if err == nil && row == nil {
+				t.Errorf("worker %d found no row to claim", i)
+			} else if row != nil {
+				ids[i] = row["id"]
+			}
+			errs[i] = err
+			claimed.Done()
+			commit.Wait()
+			if err == nil {
+				errs[i] = tx.Commit(ctx)
+			}
+		}(i)
+	}
+	claimed.Wait()
+	commit.Done()
+	done.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("worker %d: %v", i, err)
+		}
+	}
+	if ids[0] == nil || ids[0] == ids[1] {
+		t.Fatalf("workers claimed rows %v and %v, want two different rows", ids[0], ids[1])
+	}
+	count, err := table.Count(ctx, map[string]interface{}{"status": "processing"})
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("%d rows are processing after two claims, want 2", count)
+	}
+}
@@ -3,13 +3,17 @@ package modules
 import (
 	"context"
 	"fmt"
-	"log"
+	"math"
+	"strconv"
+	"strings"
 )
 
 // FetchOne fetches a single row from the table based on the provided arguments.
 //
 // It accepts variable arguments to specify conditions for filtering.
 //   - Strings are treated as raw SQL fragments (e.g., "id = $1").
+//   - Other values are the arguments of the raw fragments' $1, $2, ... placeholders, in order
+//     (e.g., "id = $1", 5). They are renumbered to fit the query, so they can be combined with maps.
 //   - A map[string]interface{} is treated as WHERE conditions (ANDed together).
 //
 // If no columns are specified, it selects all columns (*).
@@ -25,18 +29,20 @@ import (
 //
 //	userData, err := UsersTable.FetchOne(map[string]interface{}{"id": 5})
 //
+// Caching: the cache is only consulted when the lookup is exactly by the CacheKey column
+// (option 2 with CacheKey = "id"). Any other lookup, such as option 1 or a CacheKey combined with
+// further conditions, always queries the database; the row found is then cached under its CacheKey.
+//
 // Returns:
 //   - map[string]interface{}: A map representing the fetched row.
 //   - error: An error if the operation fails or no rows are found.
 func (t *Table) FetchOne(whereArgs ...interface{}) (map[string]interface{}, error) {
 	// Try to fetch from cache first
 	if t.Cached {
-		if key, err := t.getCacheKey(whereArgs...); err == nil {
+		if key, ok := t.cacheLookupKey(whereArgs); ok {
 			var cachedResult map[string]interface{}
-			if found, _ := t.getCacheValue(key, &cachedResult); found {
-				if t.DebugMode {
-					log.Println("✅ Returning Cached Hit")
-				}
+			if found, _ := t.getCacheValue(key, &cachedResult); found && !t.isSoftDeleted(cachedResult) {
+				t.debugf("Returning Cached Hit")
 				return cachedResult, nil
 			}
 		}
@@ -44,55 +50,112 @@ func (t *Table) FetchOne(whereArgs ...interface{}) (map[string]interface{}, erro
 
 	argIndex := 1
 
-	where_clause, params := buildWhereClause(whereArgs, &argIndex)
-	selectSQL := fmt.Sprintf("SELECT * FROM %s%s LIMIT 1", t.Name, where_clause)
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	where_clause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
-	}
-
-	defer conn.Release() // Release connection back to pool when done
-
-	if t.DebugMode {
-		log.Println("DEBUG: Executing FetchOne with SQL:", selectSQL, "Params:", params)
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
 	}
+	selectSQL := fmt.Sprintf("SELECT * FROM %s%s LIMIT 1", t.Name, where_clause)
 
-	rows, err := conn.Query(context.Background(), selectSQL, params...)
+	rows, err := t.readRows(t.context(), "FetchOne", selectSQL, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute fetch one: %w", err)
+		return nil, err
 	}
-	defer rows.Close() // Also close the rows when done
-
-	if !rows.Next() {
+	if len(rows) == 0 {
 		return nil, fmt.Errorf("no rows found")
 	}
-	result, err := t.fetchRowResult(rows, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch row: %w", err)
-	}
+	result := rows[0]
 
 	// Save to cache
 	if t.Cached {
-		if t.DebugMode {
-			log.Println("DEBUG: FetchOne - Attempting to set cache")
-		}
+		t.debugf("FetchOne - Attempting to set cache")
 		if key, err := t.getCacheKey(result); err == nil {
 			_ = t.setCache(key, result)
 		} else {
-			if t.DebugMode {
-				log.Println("DEBUG: FetchOne - getCacheKey failed:", err)
-			}
+			t.debugf("FetchOne - getCacheKey failed: %v", err)
 		}
 	} else {
-		if t.DebugMode {
-			log.Println("DEBUG: FetchOne - Caching NOT enabled")
-		}
+		t.debugf("FetchOne - Caching NOT enabled")
 	}
 
 	return result, nil
 }
 
+// GetByKey fetches the row whose CacheKey column equals keyValue, checking the cache first.
+//
+// On a cache miss it runs SELECT * ... WHERE "<CacheKey>" = $1 LIMIT 1 and caches the row found,
+// so the key used for the lookup and for storage always match. It is the fast path for the common
+// "fetch by id" case and also works when caching is disabled.
+//
+// For a composite key (CacheKeys), pass a map[string]interface{} holding every key column.
+//
+// Returns ErrNoCacheKey if no cache key is set, or an error if no row is found.
+//
+// Example:
+//
+//	UsersTable.CacheKey = "id"
+//	user, err := UsersTable.GetByKey(5)
+//
+//	UserRolesTable.CacheKeys = []string{"user_id", "role_id"}
+//	role, err := UserRolesTable.GetByKey(map[string]interface{}{"user_id": 5, "role_id": 2})
+func (t *Table) GetByKey(keyValue interface{}) (map[string]interface{}, error) {
+	keyColumns := t.cacheKeyColumns()
+	if len(keyColumns) == 0 {
+		return nil, ErrNoCacheKey
+	}
+
+	keyValues := []interface{}{keyValue}
+	if len(keyColumns) > 1 {
+		m, ok := keyValue.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("composite cache key %v requires a map[string]interface{} key value", keyColumns)
+		}
+		keyValues = make([]interface{}, len(keyColumns))
+		for i, keyColumn := range keyColumns {
+			val, found := m[keyColumn]
+			if !found {
+				return nil, fmt.Errorf("cache key column '%s' missing from key value", keyColumn)
+			}
+			keyValues[i] = val
+		}
+	}
+	if t.isScoped() {
+		// The cached row is not known to match the scope
+		keyMap := make(map[string]interface{}, len(keyColumns))
+		for i, keyColumn := range keyColumns {
+			keyMap[keyColumn] = keyValues[i]
+		}
+		return t.FetchOne(keyMap)
+	}
+	key := joinCacheKey(keyValues)
+
+	var cachedResult map[string]interface{}
+	if found, _ := t.getCacheValue(key, &cachedResult); found && !t.isSoftDeleted(cachedResult) {
+		return cachedResult, nil
+	}
+
+	conditions := make([]string, len(keyColumns))
+	for i, keyColumn := range keyColumns {
+		conditions[i] = fmt.Sprintf("%s = $%d", QuoteIdentifier(keyColumn), i+1)
+	}
+	if t.filtersSoftDeleted() {
+		if !isValidIdentifier(t.SoftDeleteColumn) {
+			return nil, fmt.Errorf("invalid soft delete column name: '%s'", t.SoftDeleteColumn)
+		}
+		conditions = append(conditions, QuoteIdentifier(t.SoftDeleteColumn)+" IS NULL")
+	}
+	selectSQL := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT 1", t.Name, strings.Join(conditions, " AND "))
+	rows, err := t.readRows(t.context(), "GetByKey", selectSQL, keyValues)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows found")
+	}
+
+	_ = t.setCache(key, rows[0])
+	return rows[0], nil
+}
+
 // FetchMany fetches multiple rows from the table based on the provided arguments.
 // It accepts variable arguments to specify conditions for filtering.
 //
@@ -107,51 +170,77 @@ func (t *Table) FetchOne(whereArgs ...interface{}) (map[string]interface{}, erro
 //   - error: An error if the operation fails.
 func (t *Table) FetchMany(whereArgs ...interface{}) ([]map[string]interface{}, error) {
 	argIndex := 1
-	where_clause, params := buildWhereClause(whereArgs, &argIndex)
+	where_clause, params, err := t.readWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
 	selectSQL := fmt.Sprintf("SELECT * FROM %s%s", t.Name, where_clause)
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	results, err := t.readRows(t.context(), "FetchMany", selectSQL, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, err
 	}
-	defer conn.Release() // Release connection back to pool when done
 
-	if t.DebugMode {
-		log.Println("DEBUG: Executing FetchMany with SQL:", selectSQL, "Params:", params)
+	t.cacheRowsAsync(results)
+
+	return results, nil
+}
+
+// GetPage fetches a paginated list of rows.
+// page: Page number (starts at 1). Defaults to 1 if <= 0.
+// limit: Number of items per page. Defaults to 10 if <= 0.
+// orderBy: Column to sort by. Defaults to the table's primary key column (or its first column) if empty.
+// order: Sort direction ("ASC" or "DESC"). Defaults to "DESC" if empty.
+// whereArgs: Conditions for filtering (same as FetchMany).
+func (t *Table) GetPage(page, limit int, orderBy, order string, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if orderBy == "" {
+		col, err := t.defaultOrderColumn()
+		if err != nil {
+			return nil, err
+		}
+		orderBy = QuoteIdentifier(col)
+	}
+	if order == "" {
+		order = "DESC"
 	}
 
-	rows, err := conn.Query(context.Background(), selectSQL, params...)
+	offset := (page - 1) * limit
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute fetch many: %w", err)
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
 	}
 
-	defer rows.Close() // Also close the rows when done
+	// Add pagination and sorting
+	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s %s LIMIT %d OFFSET %d",
+		t.Name, whereClause, orderBy, order, limit, offset)
 
-	results, err := t.fetchRowsResult(rows)
+	results, err := t.readRows(t.context(), "GetPage", query, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rows: %w", err)
+		return nil, err
 	}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
-			}
-		}(results)
-	}
+	t.cacheRowsAsync(results)
 
 	return results, nil
 }
 
-// GetPage fetches a paginated list of rows.
+// GetPageWithTotal fetches a paginated list of rows and the total count of rows matching the criteria.
 // page: Page number (starts at 1). Defaults to 1 if <= 0.
 // limit: Number of items per page. Defaults to 10 if <= 0.
-// orderBy: Column to sort by. Defaults to "id" if empty.
+// orderBy: Column to sort by. Defaults to the table's primary key column (or its first column) if empty.
 // order: Sort direction ("ASC" or "DESC"). Defaults to "DESC" if empty.
 // whereArgs: Conditions for filtering (same as FetchMany).
-func (t *Table) GetPage(page, limit int, orderBy, order string, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+// Returns:
+// - []map[string]interface{}: The rows for the current page.
+// - int64: The total number of rows matching the criteria.
+// - error: An error if the operation fails.
+func (t *Table) GetPageWithTotal(page, limit int, orderBy, order string, whereArgs ...interface{}) ([]map[string]interface{}, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -159,7 +248,11 @@ func (t *Table) GetPage(page, limit int, orderBy, order string, whereArgs ...int
 		limit = 10
 	}
 	if orderBy == "" {
-		orderBy = "id"
+		col, err := t.defaultOrderColumn()
+		if err != nil {
+			return nil, 0, err
+		}
+		orderBy = QuoteIdentifier(col)
 	}
 	if order == "" {
 		order = "DESC"
@@ -167,121 +260,313 @@ func (t *Table) GetPage(page, limit int, orderBy, order string, whereArgs ...int
 
 	offset := (page - 1) * limit
 	argIndex := 1
-	whereClause, params := buildWhereClause(whereArgs, &argIndex)
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build where clause: %w", err)
+	}
 
-	// Add pagination and sorting
+	// 1. Get Total Count
+	countQuery := fmt.Sprintf("SELECT COUNT(*) AS total FROM %s%s", t.Name, whereClause)
+	countRows, err := t.readRows(t.context(), "GetPageWithTotal", countQuery, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+	totalCount, _ := countRows[0]["total"].(int64)
+
+	// 2. Get Data
 	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s %s LIMIT %d OFFSET %d",
 		t.Name, whereClause, orderBy, order, limit, offset)
 
-	conn, err := t.Connection.GetConnection()
+	results, err := t.readRows(t.context(), "GetPageWithTotal", query, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, 0, err
+	}
+
+	t.cacheRowsAsync(results)
+
+	return results, totalCount, nil
+}
+
+// OrderBySpec describes a single column in an ORDER BY clause.
+type OrderBySpec struct {
+	// Column is the column name to sort by. It is validated and quoted.
+	Column string
+	// Direction is the sort direction ("ASC" or "DESC"). Defaults to "ASC" if empty.
+	Direction string
+	// NullsFirst sorts NULL values before non-NULL values. Otherwise NULLs are sorted last.
+	NullsFirst bool
+}
+
+// toSQL renders the spec as an ORDER BY item, e.g. "\"name\" ASC NULLS LAST".
+func (o OrderBySpec) toSQL() (string, error) {
+	if !isValidIdentifier(o.Column) {
+		return "", fmt.Errorf("invalid order by column: '%s'", o.Column)
+	}
+	direction := strings.ToUpper(o.Direction)
+	if direction == "" {
+		direction = "ASC"
+	}
+	if direction != "ASC" && direction != "DESC" {
+		return "", fmt.Errorf("invalid sort direction for column '%s': '%s'", o.Column, o.Direction)
 	}
-	defer conn.Release()
+	nulls := "NULLS LAST"
+	if o.NullsFirst {
+		nulls = "NULLS FIRST"
+	}
+	return fmt.Sprintf("%s %s %s", QuoteIdentifier(o.Column), direction, nulls), nil
+}
 
-	if t.DebugMode {
-		log.Println("DEBUG: Executing GetPage with SQL:", query, "Params:", params)
+// GetPageOrdered fetches a paginated list of rows sorted by multiple columns.
+// page: Page number (starts at 1). Defaults to 1 if <= 0.
+// limit: Number of items per page. Defaults to 10 if <= 0.
+// orderBy: Columns to sort by, in priority order. If empty, no ORDER BY is applied.
+// whereArgs: Conditions for filtering (same as FetchMany).
+//
+// Example:
+//
+//	users, err := UsersTable.GetPageOrdered(1, 20, []OrderBySpec{
+//	    {Column: "last_login", Direction: "DESC", NullsFirst: false},
+//	    {Column: "name", Direction: "ASC"},
+//	})
+func (t *Table) GetPageOrdered(page, limit int, orderBy []OrderBySpec, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
 	}
 
-	rows, err := conn.Query(context.Background(), query, params...)
+	orderClause, err := buildOrderByClause(orderBy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute GetPage: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	results, err := t.fetchRowsResult(rows)
+	offset := (page - 1) * limit
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rows: %w", err)
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
 	}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
-			}
-		}(results)
+	query := fmt.Sprintf("SELECT * FROM %s%s%s LIMIT %d OFFSET %d",
+		QuoteIdentifier(t.Name), whereClause, orderClause, limit, offset)
+
+	results, err := t.readRows(t.context(), "GetPageOrdered", query, params)
+	if err != nil {
+		return nil, err
 	}
 
+	t.cacheRowsAsync(results)
+
 	return results, nil
 }
 
-// GetPageWithTotal fetches a paginated list of rows and the total count of rows matching the criteria.
-// page: Page number (starts at 1). Defaults to 1 if <= 0.
-// limit: Number of items per page. Defaults to 10 if <= 0.
-// orderBy: Column to sort by. Defaults to "id" if empty.
-// order: Sort direction ("ASC" or "DESC"). Defaults to "DESC" if empty.
-// whereArgs: Conditions for filtering (same as FetchMany).
+// GetPageWithTotalOrdered fetches a paginated list of rows sorted by multiple columns,
+// along with the total count of rows matching the criteria.
+// It accepts the same arguments as GetPageOrdered.
 // Returns:
 // - []map[string]interface{}: The rows for the current page.
 // - int64: The total number of rows matching the criteria.
 // - error: An error if the operation fails.
-func (t *Table) GetPageWithTotal(page, limit int, orderBy, order string, whereArgs ...interface{}) ([]map[string]interface{}, int64, error) {
+func (t *Table) GetPageWithTotalOrdered(page, limit int, orderBy []OrderBySpec, whereArgs ...interface{}) ([]map[string]interface{}, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if limit <= 0 {
 		limit = 10
 	}
-	if orderBy == "" {
-		orderBy = "id"
-	}
-	if order == "" {
-		order = "DESC"
+
+	orderClause, err := buildOrderByClause(orderBy)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	offset := (page - 1) * limit
 	argIndex := 1
-	whereClause, params := buildWhereClause(whereArgs, &argIndex)
-
-	conn, err := t.Connection.GetConnection()
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, 0, fmt.Errorf("failed to build where clause: %w", err)
 	}
-	defer conn.Release()
 
 	// 1. Get Total Count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", t.Name, whereClause)
-	var totalCount int64
-	err = conn.QueryRow(context.Background(), countQuery, params...).Scan(&totalCount)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) AS total FROM %s%s", QuoteIdentifier(t.Name), whereClause)
+	countRows, err := t.readRows(t.context(), "GetPageWithTotalOrdered", countQuery, params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
 	}
+	totalCount, _ := countRows[0]["total"].(int64)
 
 	// 2. Get Data
-	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s %s LIMIT %d OFFSET %d",
-		t.Name, whereClause, orderBy, order, limit, offset)
+	query := fmt.Sprintf("SELECT * FROM %s%s%s LIMIT %d OFFSET %d",
+		QuoteIdentifier(t.Name), whereClause, orderClause, limit, offset)
+
+	results, err := t.readRows(t.context(), "GetPageWithTotalOrdered", query, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	t.cacheRowsAsync(results)
+
+	return results, totalCount, nil
+}
+
+// GetPageAfter fetches a page of rows using keyset (cursor-based) pagination.
+//
+// Unlike GetPage, it does not use OFFSET, so the cost of fetching a page does not
+// grow with its depth. Rows are filtered with cursorColumn > afterValue (or < for DESC)
+// and ordered by cursorColumn.
+//
+// cursorColumn: Column to paginate on. It should be unique and indexed (e.g. the primary key).
+// afterValue: The cursor returned by the previous call. Pass nil to fetch the first page.
+// limit: Number of items per page. Defaults to 10 if <= 0.
+// order: Sort direction ("ASC" or "DESC"). Defaults to "ASC" if empty.
+// whereArgs: Conditions for filtering (same as FetchMany).
+//
+// Returns:
+//   - []map[string]interface{}: The rows for the current page.
+//   - interface{}: The cursor to pass as afterValue for the next page, or nil if there are no more rows
+//     (one extra row is fetched to tell, so a last page of exactly limit rows returns nil).
+//   - error: An error if the operation fails.
+//
+// Example:
+//
+//	var cursor interface{}
+//	for {
+//	    rows, next, err := UsersTable.GetPageAfter("id", cursor, 100, "ASC")
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    // process rows...
+//	    if next == nil {
+//	        break
+//	    }
+//	    cursor = next
+//	}
+func (t *Table) GetPageAfter(cursorColumn string, afterValue interface{}, limit int, order string, whereArgs ...interface{}) ([]map[string]interface{}, interface{}, error) {
+	if !isValidIdentifier(cursorColumn) {
+		return nil, nil, fmt.Errorf("invalid cursor column: '%s'", cursorColumn)
+	}
+	order = strings.ToUpper(order)
+	if order == "" {
+		order = "ASC"
+	}
+	if order != "ASC" && order != "DESC" {
+		return nil, nil, fmt.Errorf("invalid sort order: '%s'", order)
+	}
 
-	if t.DebugMode {
-		log.Println("DEBUG: Executing GetPageWithTotal with SQL:", query, "Params:", params)
+	results, token, err := t.keysetPage("GetPageAfter", afterValue, limit, cursorColumn, order == "DESC", whereArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !token.HasMore {
+		return results, nil, nil
 	}
+	return results, token.Key, nil
+}
+
+// NextPageToken describes where a keyset page ends, for fetching the adjacent page.
+type NextPageToken struct {
+	// Key is the order column value of the last row returned by GetNextPage (or the first row
+	// returned by GetPrevPage). Pass it to the same method to continue in that direction.
+	Key interface{}
+	// HasMore reports whether more rows exist beyond this page in that direction.
+	HasMore bool
+}
 
-	rows, err := conn.Query(context.Background(), query, params...)
+// GetNextPage fetches the page of rows following afterKey using keyset pagination
+// (WHERE "orderBy" > $1 ORDER BY "orderBy" LIMIT n), which stays fast for deep pages unlike OFFSET.
+//
+// afterKey: The Key of the previous page's token. Pass nil to fetch the first page.
+// limit: Number of items per page. Defaults to 10 if <= 0.
+// orderBy: Column to paginate on. It should be unique and indexed. Defaults to the primary key column if empty.
+// desc: Paginate in descending order (WHERE "orderBy" < $1 ORDER BY "orderBy" DESC).
+// whereArgs: Additional conditions for filtering (same as FetchMany).
+//
+// Example:
+//
+//	rows, token, err := UsersTable.GetNextPage(nil, 50, "id", false)
+//	for err == nil && token.HasMore {
+//	    rows, token, err = UsersTable.GetNextPage(token.Key, 50, "id", false)
+//	}
+func (t *Table) GetNextPage(afterKey interface{}, limit int, orderBy string, desc bool, whereArgs ...interface{}) ([]map[string]interface{}, NextPageToken, error) {
+	return t.keysetPage("GetNextPage", afterKey, limit, orderBy, desc, whereArgs)
+}
+
+// GetPrevPage fetches the page of rows preceding beforeKey using keyset pagination, the reverse of GetNextPage.
+// Rows are returned in the same order as GetNextPage would return them. The returned token's Key
+// is the first row's value, to pass as beforeKey for the page before.
+func (t *Table) GetPrevPage(beforeKey interface{}, limit int, orderBy string, desc bool, whereArgs ...interface{}) ([]map[string]interface{}, NextPageToken, error) {
+	// Walking backwards is walking forwards in the opposite direction, then restoring the order
+	rows, token, err := t.keysetPage("GetPrevPage", beforeKey, limit, orderBy, !desc, whereArgs)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to execute GetPageWithTotal: %w", err)
+		return nil, NextPageToken{}, err
+	}
+	reversed := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		reversed[len(rows)-1-i] = row
+	}
+	return reversed, token, nil
+}
+
+// keysetPage fetches up to limit rows after key in the given direction, fetching one extra row to
+// determine whether more rows follow.
+func (t *Table) keysetPage(operation string, key interface{}, limit int, orderBy string, desc bool, whereArgs []interface{}) ([]map[string]interface{}, NextPageToken, error) {
+	if orderBy == "" {
+		defaultColumn, err := t.defaultOrderColumn()
+		if err != nil {
+			return nil, NextPageToken{}, err
+		}
+		orderBy = defaultColumn
+	}
+	if !isValidIdentifier(orderBy) {
+		return nil, NextPageToken{}, fmt.Errorf("invalid order column: '%s'", orderBy)
+	}
+	if limit <= 0 {
+		limit = 10
 	}
-	defer rows.Close()
 
-	results, err := t.fetchRowsResult(rows)
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+	if key != nil {
+		keyCond := Gt(key)
+		if desc {
+			keyCond = Lt(key)
+		}
+		// Copy so the caller's slice is never appended to
+		whereArgs = append(append([]interface{}{}, whereArgs...), map[string]interface{}{orderBy: keyCond})
+	}
+
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch rows: %w", err)
+		return nil, NextPageToken{}, fmt.Errorf("failed to build where clause: %w", err)
 	}
+	query := fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s %s LIMIT %d",
+		QuoteIdentifier(t.Name), whereClause, QuoteIdentifier(orderBy), order, limit+1)
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
-			}
-		}(results)
+	results, err := t.readRows(t.context(), operation, query, params)
+	if err != nil {
+		return nil, NextPageToken{}, err
 	}
 
-	return results, totalCount, nil
+	token := NextPageToken{}
+	if len(results) > limit {
+		results = results[:limit]
+		token.HasMore = true
+	}
+	if len(results) > 0 {
+		token.Key = results[len(results)-1][orderBy]
+	}
+
+	t.cacheRowsAsync(results)
+
+	return results, token, nil
 }
 
 // FetchAll retrieves all rows from the table.
+// If SoftDeleteColumn is set, soft-deleted rows are left out; use Unscoped().FetchAll() to include them.
 //
 // It automatically quotes the table name to ensure safety.
 //
@@ -296,33 +581,325 @@ func (t *Table) GetPageWithTotal(page, limit int, orderBy, order string, whereAr
 //	    log.Println("Error fetching all users:", err)
 //	}
 func (t *Table) FetchAll() ([]map[string]interface{}, error) {
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	if t.isScoped() || t.filtersSoftDeleted() {
+		return t.FetchMany()
+	}
+	selectSQL := fmt.Sprintf("SELECT * FROM %s", t.Name)
+	results, err := t.readRows(t.context(), "FetchAll", selectSQL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, err
 	}
-	defer conn.Release() // Release connection back to pool when done
 
-	selectSQL := fmt.Sprintf("SELECT * FROM %s", t.Name)
-	rows, err := conn.Query(context.Background(), selectSQL)
+	t.cacheRowsAsync(results)
+
+	return results, nil
+}
+
+// FetchDeleted fetches the rows marked deleted in SoftDeleteColumn (SoftDeleteColumn IS NOT NULL)
+// that match whereArgs, in the same format as FetchMany. Scopes applied with Scope still apply.
+//
+// Example:
+//
+//	UsersTable.SoftDeleteColumn = "deleted_at"
+//	deletedUsers, err := UsersTable.FetchDeleted(map[string]interface{}{"country": "BD"})
+func (t *Table) FetchDeleted(whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	if t.SoftDeleteColumn == "" {
+		return nil, fmt.Errorf("SoftDeleteColumn is not set for table '%s'", t.Name)
+	}
+	clone := t.clone()
+	clone.unscoped = true
+	deleted := map[string]interface{}{t.SoftDeleteColumn: IsNotNull()}
+	return clone.FetchMany(append([]interface{}{deleted}, whereArgs...)...)
+}
+
+// defaultBatchSize is the batch size FetchInBatches and FetchInBatchesKeyset use when batchSize <= 0.
+const defaultBatchSize = 100
+
+// FetchInBatches calls fn with the rows matching whereArgs, batchSize rows at a time, so large tables
+// can be processed without loading them into memory at once. Batches are read with LIMIT/OFFSET,
+// ordered by the table's primary key column (or its first column). batchSize defaults to 100 if <= 0.
+//
+// Iteration stops at the first error returned by fn, which is returned as is.
+// Rows inserted or deleted during the iteration can shift the offsets, so rows may be skipped or
+// seen twice; use FetchInBatchesKeyset on a unique column when the table changes meanwhile,
+// and for large tables, since OFFSET reads every skipped row again.
+//
+// The rows are not cached.
+//
+// Example:
+//
+//	err := UsersTable.FetchInBatches(ctx, 500, func(batch []map[string]interface{}) error {
+//	    return exportUsers(batch)
+//	}, map[string]interface{}{"active": true})
+func (t *Table) FetchInBatches(ctx context.Context, batchSize int, fn func(batch []map[string]interface{}) error, whereArgs ...interface{}) error {
+	orderBy, err := t.defaultOrderColumn()
+	if err != nil {
+		return err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	for offset := 0; ; offset += batchSize {
+		suffix := fmt.Sprintf(" ORDER BY %s ASC LIMIT %d OFFSET %d", QuoteIdentifier(orderBy), batchSize, offset)
+		batch, err := t.fetchBatch(ctx, "FetchInBatches", whereArgs, suffix)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+	}
+}
+
+// FetchInBatchesKeyset is like FetchInBatches, but pages with keyset pagination on pkCol
+// (WHERE "pkCol" > last value ORDER BY "pkCol" LIMIT n), so every batch costs the same however deep
+// the iteration is, and concurrent inserts or deletes never cause rows to be skipped or repeated.
+// pkCol must be unique and should be indexed, e.g. the primary key.
+//
+// Example:
+//
+//	err := EventsTable.FetchInBatchesKeyset(ctx, 1000, "id", func(batch []map[string]interface{}) error {
+//	    return archive(batch)
+//	})
+func (t *Table) FetchInBatchesKeyset(ctx context.Context, batchSize int, pkCol string, fn func(batch []map[string]interface{}) error, whereArgs ...interface{}) error {
+	if !isValidIdentifier(pkCol) {
+		return fmt.Errorf("invalid key column: '%s'", pkCol)
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	suffix := fmt.Sprintf(" ORDER BY %s ASC LIMIT %d", QuoteIdentifier(pkCol), batchSize)
+
+	batchArgs := whereArgs
+	for {
+		batch, err := t.fetchBatch(ctx, "FetchInBatchesKeyset", batchArgs, suffix)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+
+		last, found := batch[len(batch)-1][pkCol]
+		if !found || last == nil {
+			return fmt.Errorf("batch rows have no '%s' value", pkCol)
+		}
+		// Copy so the caller's slice is never appended to
+		batchArgs = append(append([]interface{}{}, whereArgs...), map[string]interface{}{pkCol: Gt(last)})
+	}
+}
+
+// fetchBatch selects the rows matching whereArgs followed by suffix (ORDER BY and LIMIT), without caching them.
+func (t *Table) fetchBatch(ctx context.Context, operation string, whereArgs []interface{}, suffix string) ([]map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute get all: %w", err)
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	query := fmt.Sprintf("SELECT * FROM %s%s%s", QuoteIdentifier(t.Name), whereClause, suffix)
+	return t.readRows(ctx, operation, query, params)
+}
+
+// SearchText performs a full-text search on a column and returns the matching rows.
+// If the column is declared as a tsvector it is matched directly (TsvectorMatch);
+// otherwise it is converted with to_tsvector (FullText).
+// Additional whereArgs are ANDed with the search condition (same as FetchMany).
+//
+// Example:
+//
+//	posts, err := PostsTable.SearchText("body", "postgres connection pool", map[string]interface{}{"published": true})
+func (t *Table) SearchText(column, query string, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	if !isValidIdentifier(column) {
+		return nil, fmt.Errorf("invalid search column: '%s'", column)
+	}
+
+	cond := FullText(query)
+	for _, col := range t.Columns {
+		if col.Name == column && strings.EqualFold(col.DataType.Type, "tsvector") {
+			cond = TsvectorMatch(query)
+			break
+		}
 	}
-	defer rows.Close() // Also close the rows when done
-	results, err := t.fetchRowsResult(rows)
+
+	args := append([]interface{}{map[string]interface{}{column: cond}}, whereArgs...)
+	return t.FetchMany(args...)
+}
+
+// Sample returns an approximate random sample of the rows matching whereArgs (same format as FetchMany),
+// using SELECT * FROM "table" TABLESAMPLE method(percent). method is "SYSTEM", which picks whole pages
+// and is fast but clustered, or "BERNOULLI", which picks each row independently and reads the whole table.
+// percent is the share of the table to sample, greater than 0 and at most 100; whereArgs filter the
+// sampled rows, so the result can hold fewer rows than percent suggests.
+//
+// The rows are not cached.
+//
+// Example:
+//
+//	// About 1% of the active users
+//	users, err := UsersTable.Sample(ctx, "BERNOULLI", 1, map[string]interface{}{"active": true})
+func (t *Table) Sample(ctx context.Context, method string, percent float64, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	return t.sample(ctx, "Sample", method, percent, "", whereArgs)
+}
+
+// SampleRepeatable is Sample with REPEATABLE(seed): the same seed returns the same sample
+// as long as the table does not change.
+//
+// Example:
+//
+//	users, err := UsersTable.SampleRepeatable(ctx, "SYSTEM", 5, 42)
+func (t *Table) SampleRepeatable(ctx context.Context, method string, percent, seed float64, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	if math.IsNaN(seed) || math.IsInf(seed, 0) {
+		return nil, fmt.Errorf("invalid sample seed: %v", seed)
+	}
+	repeatable := fmt.Sprintf(" REPEATABLE(%s)", strconv.FormatFloat(seed, 'f', -1, 64))
+	return t.sample(ctx, "SampleRepeatable", method, percent, repeatable, whereArgs)
+}
+
+// sample runs SELECT * FROM "table" TABLESAMPLE method(percent)<repeatable> for the rows matching whereArgs.
+func (t *Table) sample(ctx context.Context, operation, method string, percent float64, repeatable string, whereArgs []interface{}) ([]map[string]interface{}, error) {
+	method = strings.ToUpper(method)
+	if method != "SYSTEM" && method != "BERNOULLI" {
+		return nil, fmt.Errorf("invalid sample method: '%s' (must be SYSTEM or BERNOULLI)", method)
+	}
+	if !(percent > 0 && percent <= 100) {
+		return nil, fmt.Errorf("invalid sample percent: %v (must be greater than 0 and at most 100)", percent)
+	}
+
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch rows: %w", err)
+		return nil, fmt.Errorf("failed to build where clause: %w", err)
 	}
+	query := fmt.Sprintf("SELECT * FROM %s TABLESAMPLE %s(%s)%s%s", QuoteIdentifier(t.Name), method,
+		strconv.FormatFloat(percent, 'f', -1, 64), repeatable, whereClause)
+	return t.readRows(ctx, operation, query, params)
+}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
-			}
-		}(results)
+// Count returns the number of rows matching whereArgs (same format as FetchMany) with SELECT COUNT(*).
+// With no whereArgs it counts every row of the table.
+//
+// Example:
+//
+//	adults, err := UsersTable.Count(ctx, map[string]interface{}{"age": pggo.Gte(18)})
+func (t *Table) Count(ctx context.Context, whereArgs ...interface{}) (int64, error) {
+	return t.count(ctx, "Count", "COUNT(*)", whereArgs)
+}
+
+// CountDistinct returns the number of distinct non-NULL values of column among the rows matching
+// whereArgs, with SELECT COUNT(DISTINCT "column"). Like Count, it always queries the database.
+//
+// Example:
+//
+//	// Number of customers who placed an order
+//	customers, err := OrdersTable.CountDistinct(ctx, "customer_id")
+func (t *Table) CountDistinct(ctx context.Context, column string, whereArgs ...interface{}) (int64, error) {
+	if !isValidIdentifier(column) {
+		return 0, fmt.Errorf("invalid column name: '%s'", column)
 	}
+	return t.count(ctx, "CountDistinct", fmt.Sprintf("COUNT(DISTINCT %s)", QuoteIdentifier(column)), whereArgs)
+}
 
-	return results, nil
+// count runs SELECT <countExpr> for the rows matching whereArgs.
+func (t *Table) count(ctx context.Context, operation, countExpr string, whereArgs []interface{}) (int64, error) {
+	argIndex := 1
+	whereClause, params, err := t.readWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	countQuery := fmt.Sprintf("SELECT %s AS total FROM %s%s", countExpr, QuoteIdentifier(t.Name), whereClause)
+
+	rows, err := t.readRows(ctx, operation, countQuery, params)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("failed to count rows: no result returned")
+	}
+	total, _ := rows[0]["total"].(int64)
+	t.debugf("%s on %s returned %d", operation, t.Name, total)
+	return total, nil
+}
+
+// defaultHierarchyDepth is the maxDepth of FetchHierarchy when none is given.
+const defaultHierarchyDepth = 100
+
+// FetchHierarchy returns the row whose idCol equals rootID and its descendants, following
+// parentCol (the parent's idCol value) with a recursive CTE, for trees such as org charts or categories.
+//
+// Descendants more than maxDepth levels below the root are not returned, which also stops the
+// recursion on a cycle in the parent links. A maxDepth of zero or less means 100.
+//
+// Like the other fetch methods, it skips soft-deleted rows and applies the active scopes, in both the
+// root and the descendants: a descendant is only reached through visible parents.
+// Unlike them, each row has an extra "hierarchy_depth" column: 0 for the root,
+// 1 for its children, and so on. Rows are ordered by depth.
+//
+// Example:
+//
+//	// Category 3 and its subcategories, up to 5 levels down
+//	rows, err := CategoriesTable.FetchHierarchy(ctx, "id", "parent_id", 3, 5)
+func (t *Table) FetchHierarchy(ctx context.Context, idCol, parentCol string, rootID interface{}, maxDepth int) ([]map[string]interface{}, error) {
+	query, err := t.hierarchyQuery(idCol, parentCol, rootID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+	return query.FetchMany(ctx)
+}
+
+// hierarchyQuery builds the recursive query of FetchHierarchy.
+func (t *Table) hierarchyQuery(idCol, parentCol string, rootID interface{}, maxDepth int) (*QueryBuilder, error) {
+	if !isValidIdentifier(idCol) {
+		return nil, fmt.Errorf("invalid id column name: '%s'", idCol)
+	}
+	if !isValidIdentifier(parentCol) {
+		return nil, fmt.Errorf("invalid parent column name: '%s'", parentCol)
+	}
+	if maxDepth <= 0 {
+		maxDepth = defaultHierarchyDepth
+	}
+	tableName := QuoteIdentifier(t.Name)
+	id := QuoteIdentifier(idCol)
+	parent := QuoteIdentifier(parentCol)
+
+	// Both terms read the table through the soft-delete filter and the scopes. $1 is the root and $2 the
+	// depth; the filters' parameters follow, numbered across both terms.
+	args := []interface{}{rootID, maxDepth}
+	argIndex := 3
+	visible := make([]string, 2)
+	for i := range visible {
+		whereClause, params, err := t.readWhereClause(nil, &argIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build where clause: %w", err)
+		}
+		visible[i] = tableName
+		if whereClause != "" {
+			visible[i] = fmt.Sprintf("(SELECT * FROM %s%s)", tableName, whereClause)
+		}
+		args = append(args, params...)
+	}
+
+	initialQuery := fmt.Sprintf(`SELECT t.*, 0 AS "hierarchy_depth" FROM %s t WHERE t.%s = $1`, visible[0], id)
+	recursiveQuery := fmt.Sprintf(`SELECT c.*, h."hierarchy_depth" + 1 FROM %s c JOIN "hierarchy" h ON c.%s = h.%s WHERE h."hierarchy_depth" < $2`,
+		visible[1], parent, id)
+
+	return t.Query().
+		WithRecursiveUnion("hierarchy", initialQuery, recursiveQuery, args...).
+		From("hierarchy").
+		OrderBy(OrderBySpec{Column: "hierarchy_depth"}), nil
 }
@@ -0,0 +1,283 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetPageAfterKeepsCallerArgs(t *testing.T) {
+	table := &Table{Name: "items", Connection: DatabaseConnection{DB_URL: unreachableURL, MAX_CONNECTIONS: 1}}
+	whereArgs := make([]interface{}, 1, 4)
+	whereArgs[0] = map[string]interface{}{"active": true}
+
+	// The query fails, but only after the conditions were built
+	_, _, _ = table.GetPageAfter("id", 10, 5, "ASC", whereArgs...)
+	if extra := whereArgs[:2][1]; extra != nil {
+		t.Fatalf("GetPageAfter wrote %v into the caller's whereArgs", extra)
+	}
+}
+
+func TestGetPageAfterLastFullPage(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "name", DataType: *DataType{}.Text()})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if _, err := table.Insert(map[string]interface{}{"name": name}); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	rows, next, err := table.GetPageAfter("id", nil, 2, "ASC")
+	if err != nil || len(rows) != 2 || next == nil {
+		t.Fatalf("first page = %d rows, cursor %v, %v; want 2 rows and a cursor", len(rows), next, err)
+	}
+	// Exactly limit rows remain: no cursor, so no extra empty round trip
+	rows, next, err = table.GetPageAfter("id", next, 2, "ASC")
+	if err != nil || len(rows) != 2 || next != nil {
+		t.Fatalf("last page = %d rows, cursor %v, %v; want 2 rows and no cursor", len(rows), next, err)
+	}
+}
+
+func TestCountDistinctInvalidColumn(t *testing.T) {
+	if _, err := (&Table{Name: "users"}).CountDistinct(context.Background(), `email"; DROP TABLE users; --`); err == nil {
+		t.Fatal("CountDistinct with an invalid column name succeeded")
+	}
+}
+
+func TestCountDistinct(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) {
+		table.CacheKey = "id"
+		table.EnableCache(time.Minute)
+	},
+		Column{Name: "email", DataType: *DataType{}.Text()},
+		Column{Name: "active", DataType: *DataType{}.Boolean()})
+	for _, row := range []map[string]interface{}{
+		{"email": "a@example.com", "active": true},
+		{"email": "a@example.com", "active": false},
+		{"email": "b@example.com", "active": true},
+		{"email": "c@example.com", "active": true},
+		{"email": nil, "active": true},
+	} {
+		if _, err := table.Insert(row); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	var sql string
+	table.OnQuery(func(e QueryEvent) { sql = e.SQL })
+	if distinct, err := table.CountDistinct(ctx, "email"); err != nil || distinct != 3 {
+		t.Errorf("CountDistinct = %d, %v; want 3", distinct, err)
+	}
+	if want := `SELECT COUNT(DISTINCT "email") AS total FROM "` + table.Name + `"`; sql != want {
+		t.Errorf("CountDistinct ran %s, want %s", sql, want)
+	}
+	if total, err := table.Count(ctx); err != nil || total != 5 {
+		t.Errorf("Count = %d, %v; want 5", total, err)
+	}
+	if distinct, err := table.CountDistinct(ctx, "email", map[string]interface{}{"active": true}); err != nil || distinct != 3 {
+		t.Errorf("CountDistinct of active rows = %d, %v; want 3", distinct, err)
+	}
+	if distinct, err := table.CountDistinct(ctx, "email", map[string]interface{}{"active": false}); err != nil || distinct != 1 {
+		t.Errorf("CountDistinct of inactive rows = %d, %v; want 1", distinct, err)
+	}
+}
+
+func TestFetchInBatchesArguments(t *testing.T) {
+	table := &Table{Name: "items", Columns: []Column{{Name: "id", DataType: *DataType{}.Serial().PrimaryKey()}}}
+	noop := func([]map[string]interface{}) error { return nil }
+	if err := table.FetchInBatchesKeyset(context.Background(), 10, "id; --", noop); err == nil {
+		t.Error("FetchInBatchesKeyset with an invalid key column succeeded")
+	}
+
+	// A cancelled context stops the iteration before any query
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := table.FetchInBatches(ctx, 10, noop); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchInBatches with a cancelled context = %v, want context.Canceled", err)
+	}
+	if err := table.FetchInBatchesKeyset(ctx, 10, "id", noop); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchInBatchesKeyset with a cancelled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchInBatchesSeesEveryRowOnce(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "n", DataType: *DataType{}.Integer()})
+	const total = 10000
+	for start := 0; start < total; start += 1000 {
+		rows := make([]map[string]interface{}, 1000)
+		for i := range rows {
+			rows[i] = map[string]interface{}{"n": start + i}
+		}
+		if _, err := table.Returning().InsertMany(rows); err != nil {
+			t.Fatalf("InsertMany: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	iterations := map[string]func(fn func([]map[string]interface{}) error) error{
+		"FetchInBatches": func(fn func([]map[string]interface{}) error) error {
+			return table.FetchInBatches(ctx, 100, fn)
+		},
+		"FetchInBatchesKeyset": func(fn func([]map[string]interface{}) error) error {
+			return table.FetchInBatchesKeyset(ctx, 100, "id", fn)
+		},
+	}
+	for name, iterate := range iterations {
+		seen := make(map[interface{}]int, total)
+		batches := 0
+		err := iterate(func(batch []map[string]interface{}) error {
+			batches++
+			if len(batch) > 100 {
+				t.Errorf("%s: batch of %d rows, want at most 100", name, len(batch))
+			}
+			for _, row := range batch {
+				seen[row["n"]]++
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(seen) != total || batches != total/100 {
+			t.Errorf("%s saw %d distinct rows in %d batches, want %d in %d", name, len(seen), batches, total, total/100)
+		}
+		for n, count := range seen {
+			if count != 1 {
+				t.Errorf("%s saw row %v %d times", name, n, count)
+			}
+		}
+
+		// An error from fn stops the iteration and is returned
+		stop := errors.New("stop")
+		batches = 0
+		err = iterate(func([]map[string]interface{}) error {
+			batches++
+			if batches == 3 {
+				return stop
+			}
+			return nil
+		})
+		if !errors.Is(err, stop) || batches != 3 {
+			t.Errorf("%s with fn failing on batch 3 = %v after %d batches, want the error after 3", name, err, batches)
+		}
+	}
+}
+
+func TestFetchHierarchyMaxDepth(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil, Column{Name: "parent_id", DataType: *DataType{}.Integer()})
+	ctx := context.Background()
+
+	// A chain 1 <- 2 <- 3, closed into a cycle by making 3 the parent of 1
+	var ids []interface{}
+	var parent interface{}
+	for i := 0; i < 3; i++ {
+		row, err := table.Insert(map[string]interface{}{"parent_id": parent})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		ids = append(ids, row["id"])
+		parent = row["id"]
+	}
+	if _, err := table.Update(map[string]interface{}{"parent_id": ids[2]}, map[string]interface{}{"id": ids[0]}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	rows, err := table.FetchHierarchy(ctx, "id", "parent_id", ids[0], 1)
+	if err != nil {
+		t.Fatalf("FetchHierarchy: %v", err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != ids[0] || rows[1]["id"] != ids[1] || rows[1]["hierarchy_depth"] != int32(1) {
+		t.Errorf("FetchHierarchy with maxDepth 1 = %v, want the root and its child", rows)
+	}
+
+	rows, err = table.FetchHierarchy(ctx, "id", "parent_id", ids[0], 0)
+	if err != nil {
+		t.Fatalf("FetchHierarchy: %v", err)
+	}
+	if len(rows) != defaultHierarchyDepth+1 {
+		t.Errorf("FetchHierarchy of a cycle returned %d rows, want %d", len(rows), defaultHierarchyDepth+1)
+	}
+}
+
+func TestFetchHierarchySkipsHiddenRows(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) {
+		table.SoftDeleteColumn = "deleted_at"
+	},
+		Column{Name: "parent_id", DataType: *DataType{}.Integer()},
+		Column{Name: "name", DataType: *DataType{}.Text()},
+		Column{Name: "deleted_at", DataType: *DataType{}.Timestamptz()})
+	ctx := context.Background()
+
+	// root <- deleted <- orphan, and root <- hidden, hidden by the scope
+	ids := map[string]interface{}{}
+	for _, node := range []struct{ name, parent string }{{"root", ""}, {"deleted", "root"}, {"orphan", "deleted"}, {"hidden", "root"}, {"kept", "root"}} {
+		row, err := table.Insert(map[string]interface{}{"name": node.name, "parent_id": ids[node.parent]})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		ids[node.name] = row["id"]
+	}
+	if _, err := table.Update(map[string]interface{}{"deleted_at": time.Now()}, map[string]interface{}{"id": ids["deleted"]}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	table.DefineScope("shown", "name <> $1", "hidden")
+
+	rows, err := table.Scope("shown").FetchHierarchy(ctx, "id", "parent_id", ids["root"], 0)
+	if err != nil {
+		t.Fatalf("FetchHierarchy: %v", err)
+	}
+	var names []interface{}
+	for _, row := range rows {
+		names = append(names, row["name"])
+	}
+	if len(names) != 2 || names[0] != "root" || names[1] != "kept" {
+		t.Errorf("FetchHierarchy returned %v, want root and kept", names)
+	}
+
+	rows, err = table.Unscoped().FetchHierarchy(ctx, "id", "parent_id", ids["root"], 0)
+	if err != nil {
+		t.Fatalf("FetchHierarchy: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Errorf("Unscoped FetchHierarchy returned %d rows, want all 5", len(rows))
+	}
+}
+
+func TestHierarchyQuery(t *testing.T) {
+	categories := &Table{Name: "categories", SoftDeleteColumn: "deleted_at"}
+	categories.DefineScope("shop", "shop_id = $1", 7)
+
+	query, err := categories.Scope("shop").hierarchyQuery("id", "parent_id", 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args, err := query.ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	visible := func(n int) string {
+		return fmt.Sprintf(`(SELECT * FROM "categories" WHERE "categories"."deleted_at" IS NULL AND shop_id = $%d)`, n)
+	}
+	want := `WITH RECURSIVE "hierarchy" AS (` +
+		`SELECT t.*, 0 AS "hierarchy_depth" FROM ` + visible(3) + ` t WHERE t."id" = $1 UNION ALL ` +
+		`SELECT c.*, h."hierarchy_depth" + 1 FROM ` + visible(4) + ` c JOIN "hierarchy" h ON c."parent_id" = h."id" WHERE h."hierarchy_depth" < $2` +
+		`) SELECT * FROM "hierarchy" ORDER BY "hierarchy_depth" ASC NULLS LAST`
+	if sql != want {
+		t.Errorf("sql = %s\nwant  %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{3, defaultHierarchyDepth, 7, 7}) {
+		t.Errorf("args = %v", args)
+	}
+
+	if _, err := categories.hierarchyQuery("id", "parent id", 3, 0); err == nil {
+		t.Error("hierarchyQuery with an invalid parent column succeeded, want an error")
+	}
+}
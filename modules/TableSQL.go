@@ -0,0 +1,36 @@
+package modules
+
+// BuildInsertSQL returns the SQL statement and arguments that Insert would execute for data,
+// without running it. Use it to preview or audit a write, or to assert on the generated SQL in unit tests.
+// Columns are emitted in sorted order, so the output is deterministic.
+//
+// Example:
+//
+//	query, args, err := UsersTable.BuildInsertSQL(map[string]interface{}{"name": "Ada", "age": 36})
+//	// query: INSERT INTO users ("age", "name") VALUES ($1, $2) RETURNING *
+//	// args:  [36 Ada]
+func (t *Table) BuildInsertSQL(data map[string]interface{}) (string, []interface{}, error) {
+	return t.buildInsertSQL(data, "")
+}
+
+// BuildInsertManySQL returns the SQL statement and arguments that InsertMany would execute for dataList,
+// without running it.
+func (t *Table) BuildInsertManySQL(dataList []map[string]interface{}) (string, []interface{}, error) {
+	return t.buildInsertManySQL(dataList, "")
+}
+
+// BuildUpdateSQL returns the SQL statement and arguments that Update would execute, without running it.
+//
+// Example:
+//
+//	query, args, err := UsersTable.BuildUpdateSQL(map[string]interface{}{"email": "a@b.c"}, map[string]interface{}{"id": 5})
+//	// query: UPDATE users SET "email" = $1 WHERE "id" = $2 RETURNING *
+//	// args:  [a@b.c 5]
+func (t *Table) BuildUpdateSQL(data map[string]interface{}, whereArgs ...interface{}) (string, []interface{}, error) {
+	return t.buildUpdateSQL(data, whereArgs)
+}
+
+// BuildDeleteSQL returns the SQL statement and arguments that Delete would execute, without running it.
+func (t *Table) BuildDeleteSQL(whereArgs ...interface{}) (string, []interface{}, error) {
+	return t.buildDeleteSQL(whereArgs)
+}
@@ -0,0 +1,121 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefineScope registers a named set of conditions, in the same format as FetchMany's whereArgs,
+// that Scope adds to the WHERE clause of any read or write. It returns the table for chaining.
+// Define scopes while setting the table up, before it is used concurrently.
+//
+// Scopes keep common filters such as soft-delete or tenancy in one place. Their raw SQL
+// fragments number their positional arguments from $1 independently of the query's own.
+//
+// Example:
+//
+//	UsersTable.DefineScope("active", map[string]interface{}{"deleted_at": IsNull(), "banned": false})
+//	UsersTable.DefineScope("adults", "age >= $1", 18)
+func (t *Table) DefineScope(name string, conditions ...interface{}) *Table {
+	if t.scopes == nil {
+		t.scopes = make(map[string][]interface{})
+	}
+	t.scopes[name] = conditions
+	return t
+}
+
+// Scope returns a shallow copy of the table whose reads and writes (FetchOne, FetchMany, GetPage and its
+// variants, Count, Query, Update, Delete, ...) also match the conditions of the named scopes,
+// registered with DefineScope. Scopes are ANDed with each other and with the call's own conditions.
+//
+// Scoped reads are never answered from the cache, since a cached row is not known to match the scope.
+// An unknown scope name makes every statement of the copy fail.
+//
+// Example:
+//
+//	// WHERE "banned" = $1 AND "deleted_at" IS NULL AND "country" = $2
+//	users, err := UsersTable.Scope("active").FetchMany(map[string]interface{}{"country": "BD"})
+func (t *Table) Scope(names ...string) *Table {
+	clone := t.clone()
+	clone.activeScopes = append([][]interface{}{}, t.activeScopes...)
+	for _, name := range names {
+		conditions, found := t.scopes[name]
+		if !found {
+			if clone.scopeErr == nil {
+				clone.scopeErr = fmt.Errorf("unknown scope '%s' on table '%s'", name, t.Name)
+			}
+			continue
+		}
+		clone.activeScopes = append(clone.activeScopes, conditions)
+	}
+	return &clone
+}
+
+// Unscoped returns a shallow copy of the table without the SoftDeleteColumn filter and without the scopes
+// applied with Scope, for one-off queries that must see every row, e.g. UsersTable.Unscoped().FetchAll().
+func (t *Table) Unscoped() *Table {
+	clone := t.clone()
+	clone.activeScopes = nil
+	clone.scopeErr = nil
+	clone.unscoped = true
+	return &clone
+}
+
+// filtersSoftDeleted reports whether reads skip the rows marked deleted in SoftDeleteColumn.
+func (t *Table) filtersSoftDeleted() bool {
+	return t.SoftDeleteColumn != "" && !t.unscoped
+}
+
+// isSoftDeleted reports whether reads filter soft-deleted rows and row is one of them.
+func (t *Table) isSoftDeleted(row map[string]interface{}) bool {
+	return t.filtersSoftDeleted() && row[t.SoftDeleteColumn] != nil
+}
+
+// isScoped reports whether Scope was applied to the table.
+func (t *Table) isScoped() bool {
+	return len(t.activeScopes) > 0 || t.scopeErr != nil
+}
+
+// readWhereClause is scopedWhereClause for reads: it also skips soft-deleted rows (SoftDeleteColumn IS NULL).
+// The SoftDeleteColumn is qualified with the table name, so joined tables may have a column of the same name.
+func (t *Table) readWhereClause(whereArgs []interface{}, argIndex *int) (string, []interface{}, error) {
+	if !t.filtersSoftDeleted() {
+		return t.scopedWhereClause(whereArgs, argIndex)
+	}
+	if !isValidIdentifier(t.SoftDeleteColumn) {
+		return "", nil, fmt.Errorf("invalid soft delete column name: '%s'", t.SoftDeleteColumn)
+	}
+	notDeleted := []interface{}{fmt.Sprintf("%s.%s IS NULL", QuoteIdentifier(t.Name), QuoteIdentifier(t.SoftDeleteColumn))}
+	return t.buildScopedWhere(append([][]interface{}{notDeleted}, t.activeScopes...), whereArgs, argIndex)
+}
+
+// scopedWhereClause is buildWhereClause with the conditions of the active scopes in front of whereArgs.
+func (t *Table) scopedWhereClause(whereArgs []interface{}, argIndex *int) (string, []interface{}, error) {
+	return t.buildScopedWhere(t.activeScopes, whereArgs, argIndex)
+}
+
+// buildScopedWhere builds a WHERE clause from the scope conditions followed by whereArgs.
+// Each scope is built on its own, so its positional arguments do not mix with the caller's.
+func (t *Table) buildScopedWhere(scopes [][]interface{}, whereArgs []interface{}, argIndex *int) (string, []interface{}, error) {
+	if t.scopeErr != nil {
+		return "", nil, t.scopeErr
+	}
+	if len(scopes) == 0 {
+		return buildWhereClause(whereArgs, argIndex)
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, scopeArgs := range append(append([][]interface{}{}, scopes...), whereArgs) {
+		scopeConditions, scopeParams, err := buildConditions(scopeArgs, argIndex)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, scopeConditions...)
+		args = append(args, scopeParams...)
+	}
+	if len(conditions) == 0 {
+		return "", args, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
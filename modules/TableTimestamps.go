@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"time"
+)
+
+// TimestampConfig names the columns whose timestamps are managed automatically.
+// Leave a field empty to disable that timestamp.
+type TimestampConfig struct {
+	// CreatedAt is set to the current UTC time by Insert and InsertMany (and their variants)
+	// when the inserted data does not contain it.
+	CreatedAt string
+	// UpdatedAt is set to the current UTC time by every Update and BulkUpdate,
+	// overriding any value provided by the caller.
+	UpdatedAt string
+}
+
+// definedColumnSet returns the names of the columns writes may set: the defined Columns
+// plus the managed timestamp, version and UUID key columns, which may not be listed in Columns.
+func (t *Table) definedColumnSet() map[string]bool {
+	validColumns := make(map[string]bool, len(t.Columns)+2)
+	for _, col := range t.Columns {
+		validColumns[col.Name] = true
+	}
+	if t.TimestampColumns.CreatedAt != "" {
+		validColumns[t.TimestampColumns.CreatedAt] = true
+	}
+	if t.TimestampColumns.UpdatedAt != "" {
+		validColumns[t.TimestampColumns.UpdatedAt] = true
+	}
+	if t.VersionColumn != "" {
+		validColumns[t.VersionColumn] = true
+	}
+	if t.UUIDPrimaryKey != "" {
+		validColumns[t.UUIDPrimaryKey] = true
+	}
+	return validColumns
+}
+
+// withCreatedAt returns data with the CreatedAt column set to now if it is configured and missing.
+// The caller's map is not modified.
+func (t *Table) withCreatedAt(data map[string]interface{}, now time.Time) map[string]interface{} {
+	createdAt := t.TimestampColumns.CreatedAt
+	if createdAt == "" {
+		return data
+	}
+	if _, ok := data[createdAt]; ok {
+		return data
+	}
+	stamped := make(map[string]interface{}, len(data)+1)
+	for key, val := range data {
+		stamped[key] = val
+	}
+	stamped[createdAt] = now
+	return stamped
+}
+
+// withUpdatedAt returns data with the UpdatedAt column set to now if it is configured,
+// overriding any provided value. The caller's map is not modified.
+func (t *Table) withUpdatedAt(data map[string]interface{}, now time.Time) map[string]interface{} {
+	updatedAt := t.TimestampColumns.UpdatedAt
+	if updatedAt == "" {
+		return data
+	}
+	stamped := make(map[string]interface{}, len(data)+1)
+	for key, val := range data {
+		stamped[key] = val
+	}
+	stamped[updatedAt] = now
+	return stamped
+}
+
+// addTimestampColumns adds the managed timestamp columns to Columns if they are not defined,
+// as timestamptz NOT NULL DEFAULT NOW(), so CreateTable and Migrate create them.
+func (t *Table) addTimestampColumns() {
+	for _, name := range []string{t.TimestampColumns.CreatedAt, t.TimestampColumns.UpdatedAt} {
+		if name == "" || !t.columnNotExists(name, t.Columns) {
+			continue
+		}
+		t.Columns = append(t.Columns, Column{Name: name, DataType: *DataType{}.Timestamptz().NotNull().DefaultRaw("NOW()")})
+	}
+}
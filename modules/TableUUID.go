@@ -0,0 +1,59 @@
+package modules
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// newUUID returns a random (version 4) UUID in its canonical string form.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate UUID: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// withUUIDPrimaryKey returns data with the UUIDPrimaryKey column filled in if it is configured and missing:
+// a Go-generated UUID, or gen_random_uuid() evaluated by PostgreSQL if UUIDGeneratedByDB is set.
+// The caller's map is not modified.
+func (t *Table) withUUIDPrimaryKey(data map[string]interface{}) (map[string]interface{}, error) {
+	pk := t.UUIDPrimaryKey
+	if pk == "" {
+		return data, nil
+	}
+	if _, ok := data[pk]; ok {
+		return data, nil
+	}
+
+	var value interface{} = sqlExpression("gen_random_uuid()")
+	if !t.UUIDGeneratedByDB {
+		id, err := newUUID()
+		if err != nil {
+			return nil, err
+		}
+		value = id
+	}
+
+	withKey := make(map[string]interface{}, len(data)+1)
+	for key, val := range data {
+		withKey[key] = val
+	}
+	withKey[pk] = value
+	return withKey, nil
+}
+
+// wrapUUIDError explains a missing gen_random_uuid() function when UUIDs are generated by the database.
+func (t *Table) wrapUUIDError(err error) error {
+	var pgErr *pgconn.PgError
+	if t.UUIDPrimaryKey != "" && t.UUIDGeneratedByDB && errors.As(err, &pgErr) && pgErr.Code == "42883" {
+		return fmt.Errorf("UUIDGeneratedByDB requires gen_random_uuid(), built in since PostgreSQL 13 "+
+			"(enable the pgcrypto extension on older versions): %w", err)
+	}
+	return err
+}
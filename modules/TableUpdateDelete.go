@@ -1,20 +1,34 @@
 package modules
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
+// ErrFullTableWrite is returned by Update and Delete when they have no conditions and would change every row.
+// Use UpdateAll or DeleteAll, or set Table.AllowFullTableWrite, to do so on purpose.
+var ErrFullTableWrite = errors.New("refusing to update or delete every row without conditions; use UpdateAll/DeleteAll or set AllowFullTableWrite")
+
 // Update updates rows in the table based on the provided conditions.
 //
 // It automatically filters out any keys in the data map that do not correspond to defined columns in the table.
 // Column names are safely quoted to prevent identifier injection.
 // Values are passed as parameters to prevent SQL injection.
 //
+// Versioning: if VersionColumn is set, it is incremented. If data holds it, it is the version the row
+// was read at rather than a new value: only rows still at that version are updated. If none is, but
+// rows match whereArgs at another version, ErrVersionConflict is returned.
+//
+// Caching: the updated rows replace their cached copies; other cached rows are kept.
+// Updating a cache key column clears the whole cache, since the rows' old keys are unknown.
+//
 // Parameters:
 //   - data: A map where keys are column names to update and values are the new values.
 //   - whereArgs: Conditions to identify which rows to update. Can be a map or raw SQL string with args.
+//     The raw string's $1, $2, ... refer to its args, not to the SET parameters; they are renumbered.
+//     Conditions are required: without any, ErrFullTableWrite is returned (see UpdateAll).
 //
 // Returns:
 //   - []map[string]interface{}: A slice of maps representing the updated rows.
@@ -29,84 +43,249 @@ import (
 //	    log.Println("Error updating user:", err)
 //	}
 func (t *Table) Update(data map[string]interface{}, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	updateSQL, args, err := t.buildUpdateSQL(data, whereArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	results, affected, err := t.writeRows(t.context(), "Update", updateSQL, args)
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 && t.checksVersion(data) {
+		if err := t.versionConflict(whereArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	t.refreshCachedRows(sortedKeys(data), results)
+	t.emit(EventUpdate, results, whereArgs)
+	return results, nil
+}
+
+// buildUpdateSQL builds an UPDATE statement and its arguments.
+// SET columns are emitted in sorted order so the generated SQL is deterministic.
+func (t *Table) buildUpdateSQL(data map[string]interface{}, whereArgs []interface{}) (string, []interface{}, error) {
 	if len(data) == 0 {
-		return nil, fmt.Errorf("no data to update")
+		return "", nil, fmt.Errorf("no data to update")
 	}
 
-	// Filter columns to match defined schema (ignore unknown columns)
-	validColumns := make(map[string]bool)
-	for _, col := range t.Columns {
-		validColumns[col.Name] = true
+	// Reject malformed keys before filtering, so untrusted input never reaches the SQL
+	if err := validateMapKeys(data); err != nil {
+		return "", nil, err
 	}
+	data = t.withUpdatedAt(data, time.Now().UTC())
+	data, versionCondition := t.splitVersion(data)
+
+	// Filter columns to match defined schema (ignore unknown columns)
+	validColumns := t.definedColumnSet()
 
 	// 1. Process SET clause
 	setParts := make([]string, 0, len(data))
 	args := make([]interface{}, 0, len(data))
 	argIndex := 1
 
-	for col, val := range data {
+	for _, col := range sortedKeys(data) {
 		if validColumns[col] {
 			setParts = append(setParts, fmt.Sprintf("%s = $%d", QuoteIdentifier(col), argIndex))
-			args = append(args, val)
+			args = append(args, data[col])
 			argIndex++
 		}
 	}
 
-	if len(setParts) == 0 {
-		return nil, fmt.Errorf("no valid columns provided for update")
+	if len(setParts) == 0 && versionCondition == nil {
+		return "", nil, fmt.Errorf("no valid columns provided for update")
+	}
+	if t.VersionColumn != "" {
+		setParts = append(setParts, t.versionIncrement(""))
+	}
+	if versionCondition != nil {
+		// The version condition alone would still match every row at that version, so the
+		// caller's conditions (and the scopes) must restrict the update on their own
+		if !t.AllowFullTableWrite {
+			probeIndex := 1
+			callerClause, _, err := t.scopedWhereClause(whereArgs, &probeIndex)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to build where clause: %w", err)
+			}
+			if callerClause == "" {
+				return "", nil, ErrFullTableWrite
+			}
+		}
+		whereArgs = append(append([]interface{}{}, whereArgs...), versionCondition)
 	}
 
 	setClause := strings.Join(setParts, ", ")
 
 	// 2. Process WHERE clause
-	whereClause, whereArgsList := buildWhereClause(whereArgs, &argIndex)
+	whereClause, whereArgsList, err := t.writeWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	if whereClause == "" && !t.AllowFullTableWrite {
+		return "", nil, ErrFullTableWrite
+	}
 	args = append(args, whereArgsList...)
 
 	// 3. Process RETURNING clause
-	returningClause := " RETURNING *"
+	returningClause, err := t.returningClause("")
+	if err != nil {
+		return "", nil, err
+	}
 
 	// 4. Build SQL
 	updateSQL := fmt.Sprintf("UPDATE %s SET %s%s%s", t.Name, setClause, whereClause, returningClause)
+	return updateSQL, args, nil
+}
 
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+// BulkUpdate updates many rows with different values in a single statement.
+//
+// It builds an UPDATE ... FROM (VALUES ...) statement that matches each row by pkColumn.
+// Every map in updates must contain pkColumn and the same set of columns as the first map;
+// keys that are not defined table columns are ignored. Each value is cast to its column's
+// declared type so PostgreSQL can match the VALUES list to the table.
+//
+// Parameters:
+//   - pkColumn: The column used to match rows (usually the primary key).
+//   - updates: One map per row, containing pkColumn and the columns to update.
+//
+// If VersionColumn is set, it is incremented. If the rows hold it, it is the version each row was read at:
+// rows no longer at that version are not updated and are missing from the result.
+//
+// Returns:
+//   - []map[string]interface{}: The updated rows.
+//   - error: An error if the input is invalid or the update fails.
+//
+// Example:
+//
+//	updatedRows, err := UsersTable.BulkUpdate("id", []map[string]interface{}{
+//	    {"id": 1, "status": "active"},
+//	    {"id": 2, "status": "banned"},
+//	})
+func (t *Table) BulkUpdate(pkColumn string, updates []map[string]interface{}) ([]map[string]interface{}, error) {
+	updateSQL, args, columns, err := t.buildBulkUpdateSQL(pkColumn, updates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, err
 	}
-	defer conn.Release() // Release connection back to pool when done
 
-	// Execute Query
-	rows, err := conn.Query(context.Background(), updateSQL, args...)
+	results, err := t.queryRows(t.context(), "BulkUpdate", updateSQL, args)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute update with returning: %w", err)
+		return nil, err
 	}
-	defer rows.Close() // Also close the rows when done
 
-	results, err := t.fetchRowsResult(rows)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch returned rows: %w", err)
+	t.refreshCachedRows(columns, results)
+	t.emit(EventBulkUpdate, results, nil)
+	return results, nil
+}
+
+// buildBulkUpdateSQL builds the UPDATE ... FROM (VALUES ...) statement of BulkUpdate and its arguments.
+// It also returns the updated columns, without pkColumn.
+func (t *Table) buildBulkUpdateSQL(pkColumn string, updates []map[string]interface{}) (string, []interface{}, []string, error) {
+	if len(updates) == 0 {
+		return "", nil, nil, fmt.Errorf("no data to update")
 	}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.setCache(key, row)
-				}
+	columnDefs := make(map[string]ColumnDef)
+	for _, col := range t.Columns {
+		columnDefs[col.Name] = col.DataType
+	}
+	if _, ok := columnDefs[pkColumn]; !ok {
+		return "", nil, nil, fmt.Errorf("primary key column '%s' is not defined in table '%s'", pkColumn, t.Name)
+	}
+	if version := t.VersionColumn; version != "" {
+		if _, ok := columnDefs[version]; !ok {
+			columnDefs[version] = *DataType{}.Bigint()
+		}
+	}
+
+	// Determine the columns to update from the first row, filtering unknown ones
+	if err := validateMapKeys(updates[0]); err != nil {
+		return "", nil, nil, err
+	}
+	rawColumns := []string{pkColumn}
+	for _, col := range sortedKeys(updates[0]) {
+		if _, ok := columnDefs[col]; ok && col != pkColumn && col != t.TimestampColumns.UpdatedAt {
+			rawColumns = append(rawColumns, col)
+		}
+	}
+	if len(rawColumns) == 1 {
+		return "", nil, nil, fmt.Errorf("no valid columns provided for update")
+	}
+
+	// Build the VALUES list with sequential parameters, cast to the column types
+	valueRows := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)*len(rawColumns))
+	argIndex := 1
+	for i, data := range updates {
+		if err := validateMapKeys(data); err != nil {
+			return "", nil, nil, err
+		}
+		placeholders := make([]string, len(rawColumns))
+		for j, col := range rawColumns {
+			val, ok := data[col]
+			if !ok {
+				return "", nil, nil, fmt.Errorf("row %d is missing column '%s'", i, col)
 			}
-		}(results)
+			colDef := columnDefs[col]
+			placeholders[j] = fmt.Sprintf("$%d::%s", argIndex, colDef.castType())
+			args = append(args, val)
+			argIndex++
+		}
+		valueRows = append(valueRows, "("+strings.Join(placeholders, ", ")+")")
 	}
 
-	t.invalidateCache()
-	return results, nil
+	tableName := QuoteIdentifier(t.Name)
+	quotedPK := QuoteIdentifier(pkColumn)
+	quotedColumns := make([]string, len(rawColumns))
+	setParts := make([]string, 0, len(rawColumns))
+	versionMatch := ""
+	for i, col := range rawColumns {
+		quotedColumns[i] = QuoteIdentifier(col)
+		switch {
+		case i == 0:
+		case col == t.VersionColumn:
+			// The rows hold the version they were read at: match it instead of setting it
+			versionMatch = fmt.Sprintf(" AND %s.%s = v.%s", tableName, quotedColumns[i], quotedColumns[i])
+		default:
+			setParts = append(setParts, fmt.Sprintf("%s = v.%s", quotedColumns[i], quotedColumns[i]))
+		}
+	}
+	if updatedAt := t.TimestampColumns.UpdatedAt; updatedAt != "" {
+		// The managed timestamp overrides any value provided in the rows
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", QuoteIdentifier(updatedAt), argIndex))
+		args = append(args, time.Now().UTC())
+		argIndex++
+	}
+	if t.VersionColumn != "" {
+		setParts = append(setParts, t.versionIncrement(tableName))
+	}
+
+	returningClause, err := t.returningClause(tableName)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s FROM (VALUES %s) AS v(%s) WHERE %s.%s = v.%s%s%s",
+		tableName,
+		strings.Join(setParts, ", "),
+		strings.Join(valueRows, ", "),
+		strings.Join(quotedColumns, ", "),
+		tableName, quotedPK, quotedPK,
+		versionMatch,
+		returningClause,
+	)
+	return updateSQL, args, rawColumns[1:], nil
 }
 
 // Delete deletes rows from the table based on the provided conditions.
 //
+// Every deleted row is returned, so to empty a large table use Truncate instead.
+//
 // It uses parameterized queries for values and quotes identifiers in the WHERE clause (if map syntax is used) to prevent SQL injection.
 //
 // Parameters:
 //   - whereArgs: Conditions to identify which rows to delete. Can be a map or raw SQL string with args.
+//     Conditions are required: without any, ErrFullTableWrite is returned (see DeleteAll).
 //
 // Returns:
 //   - []map[string]interface{}: A slice of maps representing the deleted rows.
@@ -120,44 +299,122 @@ func (t *Table) Update(data map[string]interface{}, whereArgs ...interface{}) ([
 //	    log.Println("Error deleting user:", err)
 //	}
 func (t *Table) Delete(whereArgs ...interface{}) ([]map[string]interface{}, error) {
-	// 1. Process WHERE clause
-	argIndex := 1
-	whereClause, whereArgsList := buildWhereClause(whereArgs, &argIndex)
-	// 2. Process RETURNING clause
-	returningClause := " RETURNING *"
-
-	// 3. Build SQL
-	deleteSQL := fmt.Sprintf("DELETE FROM %s%s%s", t.Name, whereClause, returningClause)
-
-	// Acquire connection from pool
-	conn, err := t.Connection.GetConnection()
+	deleteSQL, whereArgsList, err := t.buildDeleteSQL(whereArgs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+		return nil, err
 	}
-	defer conn.Release() // Release connection back to pool when done
 
-	// Execute Query
-	rows, err := conn.Query(context.Background(), deleteSQL, whereArgsList...)
+	results, err := t.queryRows(t.context(), "Delete", deleteSQL, whereArgsList)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute delete with returning: %w", err)
+		return nil, err
 	}
-	defer rows.Close() // Also close the rows when done
 
-	results, err := t.fetchRowsResult(rows)
+	t.uncacheRows(results)
+	t.emit(EventDelete, results, whereArgs)
+	return results, nil
+}
+
+// UpdateLimit is Update restricted to the first limit matching rows in orderBy order, e.g. to process
+// a large backlog in chunks. PostgreSQL has no LIMIT on UPDATE, so the rows are selected by primary key:
+// UPDATE ... WHERE "id" IN (SELECT "id" FROM "table" WHERE ... ORDER BY ... LIMIT n).
+// The table needs a primary key column (PrimaryKey() or UUIDPrimaryKey). Without orderBy, which
+// matching rows are updated is unspecified. Conditions are optional, since the limit bounds the write.
+//
+// Example:
+//
+//	// Mark the 100 oldest pending jobs as queued
+//	jobs, err := JobsTable.UpdateLimit(map[string]interface{}{"status": "queued"}, 100,
+//	    []OrderBySpec{{Column: "created_at"}}, map[string]interface{}{"status": "pending"})
+func (t *Table) UpdateLimit(data map[string]interface{}, limit int, orderBy []OrderBySpec, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	clone := t.clone()
+	clone.writeLimit = &writeLimit{limit: limit, orderBy: orderBy}
+	return clone.Update(data, whereArgs...)
+}
+
+// DeleteLimit is Delete restricted to the first limit matching rows in orderBy order, like UpdateLimit:
+// DELETE FROM ... WHERE "id" IN (SELECT "id" FROM "table" WHERE ... ORDER BY ... LIMIT n).
+//
+// Example:
+//
+//	// Purge the 1000 oldest events
+//	purged, err := EventsTable.DeleteLimit(1000, []OrderBySpec{{Column: "created_at"}})
+func (t *Table) DeleteLimit(limit int, orderBy []OrderBySpec, whereArgs ...interface{}) ([]map[string]interface{}, error) {
+	clone := t.clone()
+	clone.writeLimit = &writeLimit{limit: limit, orderBy: orderBy}
+	return clone.Delete(whereArgs...)
+}
+
+// writeLimit restricts an Update or Delete to the first rows in an order.
+type writeLimit struct {
+	limit   int
+	orderBy []OrderBySpec
+}
+
+// writeWhereClause is scopedWhereClause for Update and Delete. With a writeLimit, the conditions select
+// the primary keys of the rows to change in a subquery: WHERE "pk" IN (SELECT "pk" ... LIMIT n).
+func (t *Table) writeWhereClause(whereArgs []interface{}, argIndex *int) (string, []interface{}, error) {
+	whereClause, args, err := t.scopedWhereClause(whereArgs, argIndex)
+	if err != nil || t.writeLimit == nil {
+		return whereClause, args, err
+	}
+	if t.writeLimit.limit <= 0 {
+		return "", nil, fmt.Errorf("limit must be positive: %d", t.writeLimit.limit)
+	}
+	pk, err := t.primaryKeyColumn()
+	if err != nil {
+		return "", nil, err
+	}
+	orderClause, err := buildOrderByClause(t.writeLimit.orderBy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch returned rows: %w", err)
+		return "", nil, err
 	}
+	quotedPK := QuoteIdentifier(pk)
+	limited := fmt.Sprintf(" WHERE %s IN (SELECT %s FROM %s%s%s LIMIT %d)",
+		quotedPK, quotedPK, QuoteIdentifier(t.Name), whereClause, orderClause, t.writeLimit.limit)
+	return limited, args, nil
+}
 
-	if t.Cached {
-		go func(rows []map[string]interface{}) {
-			for _, row := range rows {
-				if key, err := t.getCacheKey(row); err == nil {
-					_ = t.deleteCache(key)
-				}
-			}
-		}(results)
+// UpdateAll sets the given columns on every row of the table. Unlike Update, it takes no conditions,
+// so updating the whole table is always deliberate.
+//
+// Example:
+//
+//	_, err := UsersTable.UpdateAll(map[string]interface{}{"newsletter": false})
+func (t *Table) UpdateAll(data map[string]interface{}) ([]map[string]interface{}, error) {
+	return t.fullTableWriter().Update(data)
+}
+
+// DeleteAll deletes every row of the table and returns them. Unlike Delete, it takes no conditions,
+// so deleting the whole table is always deliberate. On large tables, Truncate is much faster.
+func (t *Table) DeleteAll() ([]map[string]interface{}, error) {
+	return t.fullTableWriter().Delete()
+}
+
+// fullTableWriter returns a copy of the table that allows writes without conditions.
+func (t *Table) fullTableWriter() *Table {
+	clone := t.clone()
+	clone.AllowFullTableWrite = true
+	return &clone
+}
+
+// buildDeleteSQL builds a DELETE statement and its arguments.
+func (t *Table) buildDeleteSQL(whereArgs []interface{}) (string, []interface{}, error) {
+	// 1. Process WHERE clause
+	argIndex := 1
+	whereClause, whereArgsList, err := t.writeWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build where clause: %w", err)
+	}
+	if whereClause == "" && !t.AllowFullTableWrite {
+		return "", nil, ErrFullTableWrite
+	}
+	// 2. Process RETURNING clause
+	returningClause, err := t.returningClause("")
+	if err != nil {
+		return "", nil, err
 	}
 
-	t.invalidateCache()
-	return results, nil
+	// 3. Build SQL
+	deleteSQL := fmt.Sprintf("DELETE FROM %s%s%s", t.Name, whereClause, returningClause)
+	return deleteSQL, whereArgsList, nil
 }
@@ -0,0 +1,151 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func bulkTestTable() *Table {
+	return &Table{
+		Name: "items",
+		Columns: []Column{
+			{Name: "id", DataType: *DataType{}.Serial().PrimaryKey()},
+			{Name: "name", DataType: *DataType{}.Text()},
+			{Name: "stock", DataType: *DataType{}.Integer()},
+		},
+	}
+}
+
+func TestBuildBulkUpdateSQL(t *testing.T) {
+	table := bulkTestTable()
+	sql, args, columns, err := table.buildBulkUpdateSQL("id", []map[string]interface{}{
+		{"id": 1, "name": "a", "stock": 5, "unknown": true},
+		{"id": 2, "name": "b", "stock": 6},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE "items" SET "name" = v."name", "stock" = v."stock" ` +
+		`FROM (VALUES ($1::integer, $2::text, $3::integer), ($4::integer, $5::text, $6::integer)) AS v("id", "name", "stock") ` +
+		`WHERE "items"."id" = v."id" RETURNING "items".*`
+	if sql != want {
+		t.Errorf("sql = %s\nwant  %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, "a", 5, 2, "b", 6}) {
+		t.Errorf("args = %v", args)
+	}
+	if !reflect.DeepEqual(columns, []string{"name", "stock"}) {
+		t.Errorf("columns = %v", columns)
+	}
+
+	table.VersionColumn = "version"
+	sql, _, _, err = table.buildBulkUpdateSQL("id", []map[string]interface{}{{"id": 1, "name": "a", "version": 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `UPDATE "items" SET "name" = v."name", "version" = "items"."version" + 1 ` +
+		`FROM (VALUES ($1::integer, $2::text, $3::bigint)) AS v("id", "name", "version") ` +
+		`WHERE "items"."id" = v."id" AND "items"."version" = v."version" RETURNING "items".*`
+	if sql != want {
+		t.Errorf("versioned sql = %s\nwant  %s", sql, want)
+	}
+}
+
+func TestBuildBulkUpdateSQLErrors(t *testing.T) {
+	table := bulkTestTable()
+	tests := map[string]struct {
+		pkColumn string
+		updates  []map[string]interface{}
+	}{
+		"no rows":               {"id", nil},
+		"undefined key column":  {"sku", []map[string]interface{}{{"sku": 1, "name": "a"}}},
+		"row without the key":   {"id", []map[string]interface{}{{"id": 1, "name": "a"}, {"name": "b"}}},
+		"row without a column":  {"id", []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "stock": 3}}},
+		"nothing to update":     {"id", []map[string]interface{}{{"id": 1, "unknown": "a"}}},
+		"invalid column name":   {"id", []map[string]interface{}{{"id": 1, "name\"": "a"}}},
+		"invalid later row key": {"id", []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "name": "b", "x;": 1}}},
+	}
+	for name, test := range tests {
+		if _, _, _, err := table.buildBulkUpdateSQL(test.pkColumn, test.updates); err == nil {
+			t.Errorf("%s: buildBulkUpdateSQL succeeded, want an error", name)
+		}
+	}
+}
+
+func TestBulkUpdateManyRows(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, nil,
+		Column{Name: "name", DataType: *DataType{}.Text()},
+		Column{Name: "stock", DataType: *DataType{}.Integer()})
+
+	inserts := make([]map[string]interface{}, 100)
+	for i := range inserts {
+		inserts[i] = map[string]interface{}{"name": fmt.Sprintf("item %d", i), "stock": 0}
+	}
+	rows, err := table.InsertMany(inserts)
+	if err != nil {
+		t.Fatalf("InsertMany: %v", err)
+	}
+
+	updates := make([]map[string]interface{}, len(rows))
+	want := make(map[int32]int32, len(rows))
+	for i, row := range rows {
+		id := row["id"].(int32)
+		updates[i] = map[string]interface{}{"id": id, "stock": id * 10}
+		want[id] = id * 10
+	}
+
+	var statements atomic.Int64
+	table.OnQuery(func(QueryEvent) { statements.Add(1) })
+	updated, err := table.BulkUpdate("id", updates)
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if n := statements.Load(); n != 1 {
+		t.Errorf("BulkUpdate ran %d statements, want 1", n)
+	}
+	if len(updated) != len(rows) {
+		t.Errorf("BulkUpdate returned %d rows, want %d", len(updated), len(rows))
+	}
+
+	all, err := table.FetchAll()
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	for _, row := range all {
+		if id := row["id"].(int32); row["stock"] != want[id] {
+			t.Errorf("row %d has stock %v, want %d", id, row["stock"], want[id])
+		}
+	}
+}
+
+func TestUncacheRowsKeepsOtherRows(t *testing.T) {
+	table := &Table{Name: "items", CacheKey: "id"}
+	table.EnableCache(time.Minute)
+	for id := 1; id <= 3; id++ {
+		if err := table.setCache(joinCacheKey([]interface{}{id}), map[string]interface{}{"id": id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table.uncacheRows([]map[string]interface{}{{"id": 1}, {"id": 2}})
+	if stats := table.CacheStats(); stats.Size != 1 {
+		t.Errorf("cache holds %d rows after deleting 2 of 3, want 1", stats.Size)
+	}
+	var row map[string]interface{}
+	if found, _ := table.getCacheValue(joinCacheKey([]interface{}{2}), &row); found {
+		t.Error("the deleted row 2 is still cached")
+	}
+	if found, _ := table.getCacheValue(joinCacheKey([]interface{}{3}), &row); !found {
+		t.Error("row 3 was removed from the cache, but it was not deleted")
+	}
+
+	// Without the cache key in the returned rows, the deleted entries cannot be found
+	table.Returning("name").uncacheRows([]map[string]interface{}{{"name": "a"}})
+	if stats := table.CacheStats(); stats.Size != 0 {
+		t.Errorf("cache holds %d rows after a delete returning no cache key, want 0", stats.Size)
+	}
+}
@@ -0,0 +1,97 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is returned by Update when VersionColumn is set, the data holds the version the row
+// was read at, and the row matching the conditions no longer has that version: another write changed
+// it in the meantime.
+// Fetch the row again and retry the update with its current version.
+var ErrVersionConflict = errors.New("version conflict: the row was changed by another write")
+
+// withInitialVersion returns data with the VersionColumn set to 1 if it is configured and missing.
+// The caller's map is not modified.
+func (t *Table) withInitialVersion(data map[string]interface{}) map[string]interface{} {
+	version := t.VersionColumn
+	if version == "" {
+		return data
+	}
+	if _, ok := data[version]; ok {
+		return data
+	}
+	versioned := make(map[string]interface{}, len(data)+1)
+	for key, val := range data {
+		versioned[key] = val
+	}
+	versioned[version] = 1
+	return versioned
+}
+
+// splitVersion removes the VersionColumn from the data of an update. If data held it, the returned
+// condition matches only rows still at that version. The caller's map is not modified.
+func (t *Table) splitVersion(data map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	version := t.VersionColumn
+	expected, ok := data[version]
+	if version == "" || !ok {
+		return data, nil
+	}
+	rest := make(map[string]interface{}, len(data))
+	for key, val := range data {
+		if key != version {
+			rest[key] = val
+		}
+	}
+	return rest, map[string]interface{}{version: expected}
+}
+
+// checksVersion reports whether an update of data only applies to rows at the version it holds.
+func (t *Table) checksVersion(data map[string]interface{}) bool {
+	if t.VersionColumn == "" {
+		return false
+	}
+	_, ok := data[t.VersionColumn]
+	return ok
+}
+
+// versionIncrement returns the SET entry that increments the VersionColumn. If qualifier is not empty,
+// the current value is qualified with it, for statements where the column name alone is ambiguous.
+func (t *Table) versionIncrement(qualifier string) string {
+	quoted := QuoteIdentifier(t.VersionColumn)
+	current := quoted
+	if qualifier != "" {
+		current = qualifier + "." + quoted
+	}
+	return fmt.Sprintf("%s = %s + 1", quoted, current)
+}
+
+// versionConflict is called when a versioned Update changed no row. It returns ErrVersionConflict if rows
+// match whereArgs at another version, and nil if no row matches them at all (e.g. an unknown id).
+func (t *Table) versionConflict(whereArgs []interface{}) error {
+	argIndex := 1
+	whereClause, args, err := t.writeWhereClause(whereArgs, &argIndex)
+	if err != nil {
+		return fmt.Errorf("failed to build where clause: %w", err)
+	}
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s%s) AS found", QuoteIdentifier(t.Name), whereClause)
+	rows, err := t.queryRows(t.context(), "Update", query, args)
+	if err != nil {
+		return fmt.Errorf("failed to check the row version: %w", err)
+	}
+	if len(rows) > 0 {
+		if found, _ := rows[0]["found"].(bool); found {
+			return ErrVersionConflict
+		}
+	}
+	return nil
+}
+
+// addVersionColumn adds the VersionColumn to Columns if it is not defined,
+// as bigint NOT NULL DEFAULT 1, so CreateTable and Migrate create it.
+func (t *Table) addVersionColumn() {
+	if t.VersionColumn == "" || !t.columnNotExists(t.VersionColumn, t.Columns) {
+		return
+	}
+	t.Columns = append(t.Columns, Column{Name: t.VersionColumn, DataType: *DataType{}.Bigint().NotNull().DefaultRaw("1")})
+}
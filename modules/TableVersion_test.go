@@ -0,0 +1,216 @@
+package modules
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func versionedTestTable() *Table {
+	return &Table{
+		Name:          "items",
+		VersionColumn: "version",
+		Columns: []Column{
+			{Name: "id", DataType: *DataType{}.Serial().PrimaryKey()},
+			{Name: "name", DataType: *DataType{}.Text()},
+		},
+	}
+}
+
+func TestBuildUpdateSQLVersion(t *testing.T) {
+	table := versionedTestTable()
+
+	sql, args, err := table.buildUpdateSQL(map[string]interface{}{"name": "a", "version": 3}, []interface{}{map[string]interface{}{"id": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `UPDATE items SET "name" = $1, "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3 RETURNING *`
+	if sql != want {
+		t.Errorf("sql = %s\nwant  %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", 1, 3}) {
+		t.Errorf("args = %v", args)
+	}
+
+	sql, args, err = table.buildUpdateSQL(map[string]interface{}{"name": "a"}, []interface{}{"id = $1", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `UPDATE items SET "name" = $1, "version" = "version" + 1 WHERE id = $2 RETURNING *`
+	if sql != want {
+		t.Errorf("sql = %s\nwant  %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", 1}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestBuildUpdateSQLVersionKeepsCallerArgs(t *testing.T) {
+	table := versionedTestTable()
+	whereArgs := make([]interface{}, 1, 4)
+	whereArgs[0] = map[string]interface{}{"id": 1}
+	if _, _, err := table.buildUpdateSQL(map[string]interface{}{"name": "a", "version": 3}, whereArgs); err != nil {
+		t.Fatal(err)
+	}
+	if extra := whereArgs[:2][1]; extra != nil {
+		t.Errorf("buildUpdateSQL wrote %v into the caller's whereArgs", extra)
+	}
+}
+
+func TestInsertSetsInitialVersion(t *testing.T) {
+	table := versionedTestTable()
+	sql, args, err := table.buildInsertSQL(map[string]interface{}{"name": "a"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `INSERT INTO items ("name", "version") VALUES ($1, $2) RETURNING *`; sql != want {
+		t.Errorf("sql = %s\nwant  %s", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"a", 1}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestConflictClauseIncrementsVersion(t *testing.T) {
+	table := versionedTestTable()
+	clause, err := table.buildConflictClause([]string{"id", "name", "version"}, []string{"id"}, ConflictDoUpdate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ` ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "version" = "items"."version" + 1`; clause != want {
+		t.Errorf("clause = %s\nwant     %s", clause, want)
+	}
+}
+
+// versionTestTable creates a versioned table in the test database with one row, and returns it and the row.
+func versionTestTable(t *testing.T) (*Table, map[string]interface{}) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) { table.VersionColumn = "version" },
+		Column{Name: "name", DataType: *DataType{}.Text()})
+	row, err := table.Insert(map[string]interface{}{"name": "a"})
+	if err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	if version := toInt64(t, row["version"]); version != 1 {
+		t.Fatalf("inserted version = %d, want 1", version)
+	}
+	return table, row
+}
+
+func toInt64(t *testing.T, value interface{}) int64 {
+	t.Helper()
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	t.Fatalf("unexpected version value %v (%T)", value, value)
+	return 0
+}
+
+func TestUpdateStaleVersionConflicts(t *testing.T) {
+	table, row := versionTestTable(t)
+	where := map[string]interface{}{"id": row["id"]}
+
+	// Two writers read the row at version 1 and update it concurrently: exactly one wins
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = table.Update(map[string]interface{}{"name": strings.Repeat("b", i+1), "version": row["version"]}, where)
+		}(i)
+	}
+	wg.Wait()
+
+	conflicts := 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrVersionConflict):
+			conflicts++
+		case err != nil:
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	if conflicts != 1 {
+		t.Fatalf("%d of 2 concurrent updates at the same version conflicted, want 1", conflicts)
+	}
+
+	current, err := table.FetchOne(where)
+	if err != nil {
+		t.Fatalf("FetchOne: %v", err)
+	}
+	if version := toInt64(t, current["version"]); version != 2 {
+		t.Fatalf("version = %d, want 2", version)
+	}
+
+	// Without RETURNING the conflict is still detected
+	if _, err := table.Returning().Update(map[string]interface{}{"name": "c", "version": row["version"]}, where); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("stale Update with Returning() = %v, want ErrVersionConflict", err)
+	}
+	// An unknown row is not a conflict
+	rows, err := table.Update(map[string]interface{}{"name": "c", "version": 1}, map[string]interface{}{"id": -1})
+	if err != nil || len(rows) != 0 {
+		t.Fatalf("Update of a missing row = %v, %v; want no rows and no error", rows, err)
+	}
+}
+
+func TestBulkUpdateVersion(t *testing.T) {
+	table, row := versionTestTable(t)
+	where := map[string]interface{}{"id": row["id"]}
+
+	// Without the version column the version is still incremented
+	if _, err := table.BulkUpdate("id", []map[string]interface{}{{"id": row["id"], "name": "b"}}); err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	current, err := table.FetchOne(where)
+	if err != nil {
+		t.Fatalf("FetchOne: %v", err)
+	}
+	if version := toInt64(t, current["version"]); version != 2 {
+		t.Fatalf("version after BulkUpdate = %d, want 2", version)
+	}
+
+	// A row carrying a stale version is skipped instead of writing the stale version back
+	rows, err := table.BulkUpdate("id", []map[string]interface{}{{"id": row["id"], "name": "c", "version": row["version"]}})
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if len(rows) != 0 {
+		t.Fatalf("stale BulkUpdate updated %v", rows)
+	}
+
+	rows, err = table.BulkUpdate("id", []map[string]interface{}{{"id": row["id"], "name": "c", "version": current["version"]}})
+	if err != nil {
+		t.Fatalf("BulkUpdate: %v", err)
+	}
+	if len(rows) != 1 || toInt64(t, rows[0]["version"]) != 3 || rows[0]["name"] != "c" {
+		t.Fatalf("BulkUpdate at the current version = %v, want name c at version 3", rows)
+	}
+}
+
+func TestBuildUpdateSQLVersionRequiresConditions(t *testing.T) {
+	table := versionedTestTable()
+	data := map[string]interface{}{"name": "a", "version": 3}
+	if _, _, err := table.buildUpdateSQL(data, nil); !errors.Is(err, ErrFullTableWrite) {
+		t.Errorf("buildUpdateSQL with only a version: err = %v, want ErrFullTableWrite", err)
+	}
+	if _, _, err := table.buildUpdateSQL(map[string]interface{}{"version": 3}, nil); !errors.Is(err, ErrFullTableWrite) {
+		t.Errorf("buildUpdateSQL of only the version: err = %v, want ErrFullTableWrite", err)
+	}
+
+	scoped := table.DefineScope("tenant", map[string]interface{}{"tenant_id": 7}).Scope("tenant")
+	if _, _, err := scoped.buildUpdateSQL(data, nil); err != nil {
+		t.Errorf("buildUpdateSQL with a scope and a version: %v", err)
+	}
+	table.AllowFullTableWrite = true
+	if _, _, err := table.buildUpdateSQL(data, nil); err != nil {
+		t.Errorf("buildUpdateSQL with AllowFullTableWrite: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTruncateRequiresContext(t *testing.T) {
+	if err := (&Table{Name: "items"}).Truncate(nil, true, false); err == nil {
+		t.Fatal("Truncate with a nil context succeeded")
+	}
+}
+
+func TestTruncateRestartIdentity(t *testing.T) {
+	conn := newTestConnection(t)
+	table := newTestTable(t, conn, func(table *Table) {
+		table.CacheKey = "id"
+		table.EnableCache(time.Minute)
+	}, Column{Name: "name", DataType: *DataType{}.Text()})
+	ctx := context.Background()
+
+	insert := func() int32 {
+		t.Helper()
+		row, err := table.Insert(map[string]interface{}{"name": "a"})
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+		return row["id"].(int32)
+	}
+	insert()
+	insert()
+
+	if err := table.Truncate(ctx, false, false); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+	if id := insert(); id != 3 {
+		t.Errorf("id after Truncate without restartIdentity = %d, want 3", id)
+	}
+
+	table.cacheTasks.wg.Wait() // Let Insert cache its rows before they are truncated
+	if err := table.Truncate(ctx, true, false); err != nil {
+		t.Fatalf("Truncate: %v", err)
+	}
+	if count, err := table.Count(ctx); err != nil || count != 0 {
+		t.Fatalf("Count after Truncate = %d, %v; want 0", count, err)
+	}
+	if stats := table.CacheStats(); stats == nil || stats.Size != 0 {
+		t.Errorf("CacheStats after Truncate = %+v, want an empty cache", stats)
+	}
+	if id := insert(); id != 1 {
+		t.Errorf("id after Truncate with restartIdentity = %d, want 1", id)
+	}
+}
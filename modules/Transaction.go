@@ -0,0 +1,62 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Transaction is a database transaction started with DatabaseConnection.Begin.
+// It must be finished with Commit or Rollback; calling Rollback after Commit is a safe no-op,
+// so `defer tx.Rollback(ctx)` is the recommended pattern.
+type Transaction struct {
+	// Tx is the underlying pgx transaction, for operations PgGo does not wrap.
+	Tx pgx.Tx
+}
+
+// Begin starts a new transaction on a pooled connection.
+// The connection is held until the transaction is committed or rolled back.
+//
+// Example:
+//
+//	tx, err := db.Begin(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	defer tx.Rollback(ctx)
+//	// ... statements using tx ...
+//	return tx.Commit(ctx)
+func (conf *DatabaseConnection) Begin(ctx context.Context) (*Transaction, error) {
+	pool, err := conf.getPool()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &Transaction{Tx: tx}, nil
+}
+
+// Commit commits the transaction.
+func (tx *Transaction) Commit(ctx context.Context) error {
+	if err := tx.Tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback rolls the transaction back. It is a no-op if the transaction was already committed or rolled back.
+func (tx *Transaction) Rollback(ctx context.Context) error {
+	if err := tx.Tx.Rollback(ctx); err != nil && err != pgx.ErrTxClosed {
+		return fmt.Errorf("failed to rollback transaction: %w", err)
+	}
+	return nil
+}
+
+// Exec executes a statement that returns no rows inside the transaction.
+func (tx *Transaction) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return tx.Tx.Exec(ctx, sql, args...)
+}
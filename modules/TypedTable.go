@@ -0,0 +1,455 @@
+package modules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TypedTable wraps a Table to read and write rows as values of the struct type T instead of maps.
+// Struct fields map to columns like in TableFromStruct: by their `db` tag, or the field name in snake_case.
+// Conditions, caching, scopes and hooks are those of the wrapped Table.
+//
+// Example:
+//
+//	type User struct {
+//	    ID    int64  `db:"id,primary"`
+//	    Email string `db:"email,unique,notnull"`
+//	}
+//	Users, err := pggo.NewTypedTable[User](UsersTable)
+//	user, err := Users.FetchOne(map[string]interface{}{"email": "a@example.com"})
+//	fmt.Println(user.ID)
+type TypedTable[T any] struct {
+	// Table is the wrapped table, for the operations TypedTable does not cover.
+	Table  *Table
+	fields []typedField
+}
+
+// typedField is a struct field of a TypedTable's type and the column it maps to.
+type typedField struct {
+	column string
+	index  []int
+	// generated reports whether the column is filled by the database (primary or default: tag option)
+	// when the field holds its zero value.
+	generated bool
+}
+
+// NewTypedTable returns a TypedTable for the rows of table as values of the struct type T.
+// Returns an error if T is not a struct or a field's column name is invalid.
+func NewTypedTable[T any](table *Table) (*TypedTable[T], error) {
+	if table == nil {
+		return nil, fmt.Errorf("NewTypedTable requires a table")
+	}
+	fields, err := typedFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return &TypedTable[T]{Table: table, fields: fields}, nil
+}
+
+// typedFieldCache holds the fields of the struct types already mapped by typedFieldsOf.
+var typedFieldCache sync.Map // reflect.Type -> []typedField
+
+// typedFieldsOf returns the fields of a struct type that map to columns.
+func typedFieldsOf(typ reflect.Type) ([]typedField, error) {
+	if cached, found := typedFieldCache.Load(typ); found {
+		return cached.([]typedField), nil
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("a struct type is required, got %s", typ)
+	}
+
+	var fields []typedField
+	err := walkStructFields(typ, nil, func(field reflect.StructField, tag string, index []int) error {
+		name, options := fieldColumnName(field, tag)
+		if !isValidIdentifier(name) {
+			return fmt.Errorf("invalid column name '%s' for field %s", name, field.Name)
+		}
+		generated := false
+		for _, option := range strings.Split(options, ",") {
+			if option == "primary" || strings.HasPrefix(option, "default:") {
+				generated = true
+			}
+		}
+		fields = append(fields, typedField{column: name, index: index, generated: generated})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("struct %s has no columns", typ)
+	}
+	typedFieldCache.Store(typ, fields)
+	return fields, nil
+}
+
+// ScanRow converts a row, as returned by the Table methods, to a *T. Fields map to columns by their
+// `db` tag like in TableFromStruct; columns without a matching field are ignored.
+// The error for a column that cannot be assigned names the column and the field type.
+//
+// Example:
+//
+//	row, err := UsersTable.FetchOne(map[string]interface{}{"id": 5})
+//	user, err := pggo.ScanRow[User](row)
+func ScanRow[T any](row map[string]interface{}) (*T, error) {
+	fields, err := typedFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return scanFields[T](fields, row)
+}
+
+// FetchOneAs fetches a single row like Table.FetchOne, running with ctx, and returns it as a *T (see ScanRow).
+//
+// Example:
+//
+//	user, err := pggo.FetchOneAs[User](UsersTable, ctx, map[string]interface{}{"email": email})
+func FetchOneAs[T any](table *Table, ctx context.Context, whereArgs ...interface{}) (*T, error) {
+	row, err := table.WithContext(ctx).FetchOne(whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return ScanRow[T](row)
+}
+
+// FetchManyAs fetches the rows matching whereArgs like Table.FetchMany, running with ctx, and returns them as a []T.
+func FetchManyAs[T any](table *Table, ctx context.Context, whereArgs ...interface{}) ([]T, error) {
+	fields, err := typedFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := table.WithContext(ctx).FetchMany(whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll[T](fields, rows)
+}
+
+// InsertStruct inserts the columns of value like Table.Insert, running with ctx, and returns the inserted row
+// as a T, with the values the database generated. Like TypedTable.Insert, fields tagged primary or
+// default:<expr> are left out while they hold their zero value.
+//
+// Example:
+//
+//	user, err := pggo.InsertStruct(UsersTable, ctx, User{Email: "a@example.com"})
+func InsertStruct[T any](table *Table, ctx context.Context, value T) (T, error) {
+	fields, err := typedFieldsOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return value, err
+	}
+	row, err := table.WithContext(ctx).Insert(structRow(fields, reflect.ValueOf(&value).Elem()))
+	if err != nil || row == nil {
+		return value, err
+	}
+	inserted, err := scanFields[T](fields, row)
+	if err != nil {
+		return value, err
+	}
+	return *inserted, nil
+}
+
+// FetchOne fetches a single row like Table.FetchOne and returns it as a *T.
+func (tt *TypedTable[T]) FetchOne(whereArgs ...interface{}) (*T, error) {
+	row, err := tt.Table.FetchOne(whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return tt.fromRow(row)
+}
+
+// FetchMany fetches the rows matching whereArgs like Table.FetchMany and returns them as a []T.
+func (tt *TypedTable[T]) FetchMany(whereArgs ...interface{}) ([]T, error) {
+	rows, err := tt.Table.FetchMany(whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAll[T](tt.fields, rows)
+}
+
+// Insert inserts value like Table.Insert and returns the inserted row as a *T, with the values the
+// database generated. Fields tagged primary or default:<expr> are left out while they hold their zero
+// value, so the database fills them (e.g. a serial id or a created_at default).
+// If the table returns no rows (Returning with no columns), value is returned unchanged.
+func (tt *TypedTable[T]) Insert(value *T) (*T, error) {
+	if value == nil {
+		return nil, fmt.Errorf("Insert requires a value")
+	}
+	row, err := tt.Table.Insert(structRow(tt.fields, reflect.ValueOf(value).Elem()))
+	if err != nil {
+		return nil, err
+	}
+	if row == nil {
+		return value, nil
+	}
+	return tt.fromRow(row)
+}
+
+// fromRow converts a row to a *T.
+func (tt *TypedTable[T]) fromRow(row map[string]interface{}) (*T, error) {
+	return scanFields[T](tt.fields, row)
+}
+
+// structRow returns the columns of a struct value as a map, leaving out the generated columns that hold their zero value.
+func structRow(fields []typedField, rv reflect.Value) map[string]interface{} {
+	row := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		fv, err := rv.FieldByIndexErr(field.index)
+		if err != nil {
+			continue // Field of a nil embedded pointer
+		}
+		if field.generated && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			row[field.column] = nil
+			continue
+		}
+		row[field.column] = fv.Interface()
+	}
+	return row
+}
+
+// scanAll converts rows to a []T.
+func scanAll[T any](fields []typedField, rows []map[string]interface{}) ([]T, error) {
+	results := make([]T, 0, len(rows))
+	for _, row := range rows {
+		value, err := scanFields[T](fields, row)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *value)
+	}
+	return results, nil
+}
+
+// scanFields converts a row to a *T. Columns without a matching field are ignored.
+func scanFields[T any](fields []typedField, row map[string]interface{}) (*T, error) {
+	result := new(T)
+	rv := reflect.ValueOf(result).Elem()
+	for _, field := range fields {
+		value, found := row[field.column]
+		if !found {
+			continue
+		}
+		if err := assignColumn(fieldByIndexAlloc(rv, field.index), value); err != nil {
+			return nil, fmt.Errorf("failed to scan column '%s': %w", field.column, err)
+		}
+	}
+	return result, nil
+}
+
+// fieldByIndexAlloc returns the nested field at index, allocating nil embedded struct pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+	return v
+}
+
+// assignColumn sets a struct field to a column value as pgx decoded it. NULL sets the zero value,
+// numbers convert between numeric kinds, numeric converts to int and float fields, a uuid converts to
+// a string field, and fields implementing sql.Scanner scan the value. A number that the field cannot
+// hold exactly (out of range, or a fraction for an integer field) is an error rather than truncated.
+func assignColumn(field reflect.Value, value interface{}) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignColumn(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+		return nil
+	case isNumericKind(rv.Kind()) && isNumericKind(field.Kind()):
+		return setNumber(field, rv)
+	}
+
+	switch v := value.(type) {
+	case pgtype.Numeric:
+		if isNumericKind(field.Kind()) {
+			return setNumeric(field, v)
+		}
+	case [16]byte:
+		if field.Kind() == reflect.String {
+			field.SetString(fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16]))
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot assign %T to field of type %s", value, field.Type())
+}
+
+// setNumber sets a numeric field to the numeric value rv, failing if the field cannot hold it exactly.
+// Integers converted to a float field may be rounded, as with any float.
+func setNumber(field, rv reflect.Value) error {
+	outOfRange := func() error {
+		return fmt.Errorf("%v (%s) is out of range for field of type %s", rv, rv.Type(), field.Type())
+	}
+	switch {
+	case isIntKind(field.Kind()):
+		var n int64
+		switch {
+		case isIntKind(rv.Kind()):
+			n = rv.Int()
+		case isUintKind(rv.Kind()):
+			if rv.Uint() > math.MaxInt64 {
+				return outOfRange()
+			}
+			n = int64(rv.Uint())
+		default:
+			f := rv.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("%v (%s) is not an integer, cannot assign it to field of type %s", rv, rv.Type(), field.Type())
+			}
+			if f < math.MinInt64 || f >= math.MaxInt64 {
+				return outOfRange()
+			}
+			n = int64(f)
+		}
+		if field.OverflowInt(n) {
+			return outOfRange()
+		}
+		field.SetInt(n)
+	case isUintKind(field.Kind()):
+		var n uint64
+		switch {
+		case isIntKind(rv.Kind()):
+			if rv.Int() < 0 {
+				return outOfRange()
+			}
+			n = uint64(rv.Int())
+		case isUintKind(rv.Kind()):
+			n = rv.Uint()
+		default:
+			f := rv.Float()
+			if f != math.Trunc(f) {
+				return fmt.Errorf("%v (%s) is not an integer, cannot assign it to field of type %s", rv, rv.Type(), field.Type())
+			}
+			if f < 0 || f >= math.MaxUint64 {
+				return outOfRange()
+			}
+			n = uint64(f)
+		}
+		if field.OverflowUint(n) {
+			return outOfRange()
+		}
+		field.SetUint(n)
+	default:
+		var f float64
+		switch {
+		case isIntKind(rv.Kind()):
+			f = float64(rv.Int())
+		case isUintKind(rv.Kind()):
+			f = float64(rv.Uint())
+		default:
+			f = rv.Float()
+		}
+		if field.OverflowFloat(f) {
+			return outOfRange()
+		}
+		field.SetFloat(f)
+	}
+	return nil
+}
+
+// setNumeric sets a numeric field to a numeric value. Integer fields are set from the exact value,
+// so large numerics are not rounded through a float.
+func setNumeric(field reflect.Value, v pgtype.Numeric) error {
+	if !v.Valid {
+		field.SetZero()
+		return nil
+	}
+	if field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64 {
+		f, err := v.Float64Value()
+		if err != nil {
+			return err
+		}
+		return setNumber(field, reflect.ValueOf(f.Float64))
+	}
+
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("cannot assign numeric NaN or infinity to field of type %s", field.Type())
+	}
+	n := new(big.Int)
+	if v.Int != nil {
+		n.Set(v.Int)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(v.Exp))), nil)
+	if v.Exp >= 0 {
+		n.Mul(n, scale)
+	} else if _, remainder := n.QuoRem(n, scale, new(big.Int)); remainder.Sign() != 0 {
+		return fmt.Errorf("numeric %s is not an integer, cannot assign it to field of type %s", numericString(v), field.Type())
+	}
+	switch {
+	case n.IsInt64():
+		return setNumber(field, reflect.ValueOf(n.Int64()))
+	case n.IsUint64():
+		return setNumber(field, reflect.ValueOf(n.Uint64()))
+	}
+	return fmt.Errorf("numeric %s is out of range for field of type %s", n, field.Type())
+}
+
+// numericString formats a finite numeric for error messages.
+func numericString(v pgtype.Numeric) string {
+	text, err := v.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(text)
+}
+
+// abs32 returns the absolute value of n.
+func abs32(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// isNumericKind reports whether k is an integer or floating-point kind.
+func isNumericKind(k reflect.Kind) bool {
+	return isIntKind(k) || isUintKind(k) || k == reflect.Float32 || k == reflect.Float64
+}
+
+// isIntKind reports whether k is a signed integer kind.
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// isUintKind reports whether k is an unsigned integer kind.
+func isUintKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
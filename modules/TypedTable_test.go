@@ -0,0 +1,83 @@
+package modules
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type scanNumbers struct {
+	Small    int8    `db:"small"`
+	Count    int     `db:"count"`
+	Unsigned uint16  `db:"unsigned"`
+	Ratio    float32 `db:"ratio"`
+	Big      int64   `db:"big"`
+	Price    float64 `db:"price"`
+	Optional *int32  `db:"optional"`
+}
+
+func numeric(digits string, exp int32) pgtype.Numeric {
+	n, _ := new(big.Int).SetString(digits, 10)
+	return pgtype.Numeric{Int: n, Exp: exp, Valid: true}
+}
+
+func TestScanRowConvertsNumbers(t *testing.T) {
+	got, err := ScanRow[scanNumbers](map[string]interface{}{
+		"small":    int64(-128),
+		"count":    float64(42),
+		"unsigned": int32(65535),
+		"ratio":    float64(0.5),
+		"big":      numeric("9223372036854775807", 0),
+		"price":    numeric("1999", -2),
+		"optional": int64(7),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scanNumbers{Small: -128, Count: 42, Unsigned: 65535, Ratio: 0.5, Big: math.MaxInt64, Price: 19.99}
+	if got.Optional == nil || *got.Optional != 7 {
+		t.Errorf("Optional = %v, want 7", got.Optional)
+	}
+	got.Optional = nil
+	if *got != want {
+		t.Errorf("ScanRow = %+v, want %+v", *got, want)
+	}
+
+	got, err = ScanRow[scanNumbers](map[string]interface{}{"big": numeric("12", 3)})
+	if err != nil || got.Big != 12000 {
+		t.Errorf("ScanRow(12e3) = %v, %v; want 12000", got.Big, err)
+	}
+}
+
+func TestScanRowRejectsLossyNumbers(t *testing.T) {
+	tests := []struct {
+		column string
+		value  interface{}
+	}{
+		{"small", int64(128)},
+		{"small", int64(math.MaxInt64)},
+		{"count", float64(1.5)},
+		{"count", math.NaN()},
+		{"count", float64(1e20)},
+		{"unsigned", int64(-1)},
+		{"unsigned", int64(65536)},
+		{"ratio", float64(1e300)},
+		{"big", numeric("9223372036854775808", 0)},
+		{"big", numeric("15", -1)},
+		{"big", pgtype.Numeric{NaN: true, Valid: true}},
+		{"optional", int64(math.MaxInt32 + 1)},
+	}
+	for _, test := range tests {
+		_, err := ScanRow[scanNumbers](map[string]interface{}{test.column: test.value})
+		if err == nil {
+			t.Errorf("ScanRow(%s: %v) succeeded, want an error", test.column, test.value)
+			continue
+		}
+		if !strings.Contains(err.Error(), "'"+test.column+"'") {
+			t.Errorf("ScanRow(%s: %v) error %q does not name the column", test.column, test.value, err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package modules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testDatabaseURLEnv names the environment variable holding the URL of the database used by the
+// integration tests. They are skipped when it is not set.
+const testDatabaseURLEnv = "PGGO_TEST_DATABASE_URL"
+
+// testTableCounter makes the names of the tables created by newTestTable unique within a run.
+var testTableCounter atomic.Int64
+
+// newTestConnection connects to the test database, skipping the test if none is configured.
+// The connection is closed when the test ends.
+func newTestConnection(t *testing.T) *DatabaseConnection {
+	t.Helper()
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+	conn := &DatabaseConnection{DB_URL: url, MAX_CONNECTIONS: 4}
+	if _, err := conn.ConnectDb(); err != nil {
+		t.Fatalf("ConnectDb: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = conn.Close(ctx)
+	})
+	return conn
+}
+
+// newTestTable creates a uniquely named table with an id serial primary key and the given columns,
+// configured by configure before it is created. The table is dropped when the test ends.
+func newTestTable(t *testing.T, conn *DatabaseConnection, configure func(*Table), columns ...Column) *Table {
+	t.Helper()
+	table := &Table{
+		Name:       fmt.Sprintf("pggo_test_%d_%d", time.Now().UnixNano(), testTableCounter.Add(1)),
+		Connection: *conn,
+		Columns:    append([]Column{{Name: "id", DataType: *DataType{}.Serial().PrimaryKey()}}, columns...),
+	}
+	if configure != nil {
+		configure(table)
+	}
+	if err := table.CreateTable(); err != nil {
+		t.Fatalf("CreateTable: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := table.DropTable(); err != nil {
+			t.Errorf("DropTable: %v", err)
+		}
+	})
+	return table
+}
@@ -0,0 +1,78 @@
+//go:build otel
+
+// Package otel provides an OpenTelemetry QueryHook for PgGo.
+//
+// It lives behind the "otel" build tag so that the core package does not depend on
+// OpenTelemetry. Build with -tags otel and add go.opentelemetry.io/otel to your go.mod to use it.
+//
+// Example:
+//
+//	table.AddQueryHook(otel.NewHook())
+//	user, err := table.WithContext(ctx).FetchOne(map[string]interface{}{"id": 5})
+package otel
+
+import (
+	"context"
+
+	"pggo"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies PgGo as the source of the spans.
+const instrumentationName = "pggo"
+
+// Hook is a pggo.QueryHook that records a span for every statement.
+// Spans are children of the span in the context passed via Table.WithContext.
+type Hook struct {
+	tracer trace.Tracer
+}
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// WithTracerProvider sets the TracerProvider used to create spans.
+// Defaults to the global provider from otel.GetTracerProvider().
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(h *Hook) {
+		h.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// NewHook creates a Hook that traces PgGo statements.
+func NewHook(opts ...Option) *Hook {
+	h := &Hook{tracer: otel.GetTracerProvider().Tracer(instrumentationName)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// BeforeQuery starts a client span tagged with the table name, operation, and statement.
+func (h *Hook) BeforeQuery(ctx context.Context, event *pggo.QueryEvent) context.Context {
+	ctx, _ = h.tracer.Start(ctx, "pggo."+event.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(event.StartTime),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.sql.table", event.Table),
+			attribute.String("db.operation", event.Operation),
+			attribute.String("db.statement", event.SQL),
+		),
+	)
+	return ctx
+}
+
+// AfterQuery records the row count and any error on the span and ends it.
+func (h *Hook) AfterQuery(ctx context.Context, event *pggo.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows", event.RowCount))
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+	span.End()
+}